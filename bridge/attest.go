@@ -0,0 +1,187 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxAttestations bounds how many attestations are kept for /attestation;
+// the oldest are dropped first. The audit log keeps every one.
+const maxAttestations = 10000
+
+// AttestedDecision is the signed statement: who decided what about which
+// request, and when.
+type AttestedDecision struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	App      string `json:"app"`
+	Amount   int64  `json:"amount"`
+	Approved bool   `json:"approved"`
+	Reason   string `json:"reason"`
+	Source   string `json:"source"`
+	// Approver and Approvers are those of the response: who decided, and
+	// for a quorum, everyone who approved.
+	Approver         string   `json:"approver,omitempty"`
+	Approvers        []string `json:"approvers,omitempty"`
+	RequestTimestamp int64    `json:"requestTimestamp"`
+	DecidedAt        int64    `json:"decidedAt"`
+}
+
+// Attestation is an Ed25519 signature over the canonical JSON encoding of a
+// decision. Payload holds the exact signed bytes, so a verifier needs only
+// the payload, signature and public key.
+type Attestation struct {
+	Decision  AttestedDecision `json:"decision"`
+	Payload   []byte           `json:"payload"`
+	Signature []byte           `json:"signature"`
+	PublicKey string           `json:"publicKey"`
+	Algorithm string           `json:"algorithm"`
+}
+
+// Verify reports whether the signature is valid for the payload and the
+// payload matches Decision.
+func (a Attestation) Verify() bool {
+	pub, err := hex.DecodeString(a.PublicKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize || a.Algorithm != "ed25519" {
+		return false
+	}
+	if !ed25519.Verify(ed25519.PublicKey(pub), a.Payload, a.Signature) {
+		return false
+	}
+	canonical, err := json.Marshal(a.Decision)
+	return err == nil && string(canonical) == string(a.Payload)
+}
+
+// Attester signs every decision the bridge records and keeps recent
+// attestations for retrieval. It is safe for concurrent use. A nil *Attester
+// signs nothing.
+type Attester struct {
+	key ed25519.PrivateKey
+
+	mu    sync.Mutex
+	byID  map[string]Attestation
+	order []string
+}
+
+// NewAttester creates an attester signing with key.
+func NewAttester(key ed25519.PrivateKey) *Attester {
+	return &Attester{key: key, byID: make(map[string]Attestation)}
+}
+
+// LoadAttester reads a hex-encoded Ed25519 seed from path, generating and
+// saving a new one if the file does not exist.
+func LoadAttester(path string) (*Attester, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		seed := make([]byte, ed25519.SeedSize)
+		if _, err := rand.Read(seed); err != nil {
+			return nil, fmt.Errorf("failed to generate attestation key: %w", err)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return nil, fmt.Errorf("failed to create attestation key directory: %w", err)
+		}
+		if err := os.WriteFile(path, []byte(hex.EncodeToString(seed)+"\n"), 0o600); err != nil {
+			return nil, fmt.Errorf("failed to save attestation key: %w", err)
+		}
+		return NewAttester(ed25519.NewKeyFromSeed(seed)), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attestation key: %w", err)
+	}
+	seed, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("attestation key %s must hold a hex-encoded %d-byte Ed25519 seed", path, ed25519.SeedSize)
+	}
+	return NewAttester(ed25519.NewKeyFromSeed(seed)), nil
+}
+
+// PublicKey returns the hex-encoded verification key.
+func (a *Attester) PublicKey() string {
+	return hex.EncodeToString(a.key.Public().(ed25519.PublicKey))
+}
+
+// attest signs the decision resp made for req and keeps the attestation.
+func (a *Attester) attest(source string, req PermissionRequest, resp PermissionResponse, decidedAt time.Time) *Attestation {
+	if a == nil {
+		return nil
+	}
+	decision := AttestedDecision{
+		ID:               req.ID,
+		Type:             req.Type,
+		App:              req.App,
+		Amount:           req.Amount,
+		Approved:         resp.Approved,
+		Reason:           resp.Reason,
+		Source:           source,
+		Approver:         resp.Approver,
+		Approvers:        resp.Approvers,
+		RequestTimestamp: req.Timestamp,
+		DecidedAt:        decidedAt.Unix(),
+	}
+	payload, _ := json.Marshal(decision)
+	att := Attestation{
+		Decision:  decision,
+		Payload:   payload,
+		Signature: ed25519.Sign(a.key, payload),
+		PublicKey: a.PublicKey(),
+		Algorithm: "ed25519",
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.byID[req.ID]; !ok {
+		a.order = append(a.order, req.ID)
+	}
+	a.byID[req.ID] = att
+	for len(a.order) > maxAttestations {
+		delete(a.byID, a.order[0])
+		a.order = a.order[1:]
+	}
+	return &att
+}
+
+func (a *Attester) get(id string) (Attestation, bool) {
+	if a == nil {
+		return Attestation{}, false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	att, ok := a.byID[id]
+	return att, ok
+}
+
+// ---------------------------------------------------------------------------
+// GET /attestation?id=<request id> — signed proof of a past decision
+// ---------------------------------------------------------------------------
+
+func (bs *BridgeServer) handleAttestation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !bs.authorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if bs.attester == nil {
+		http.Error(w, `{"error":"attestation disabled"}`, http.StatusNotFound)
+		return
+	}
+	att, ok := bs.attester.get(r.URL.Query().Get("id"))
+	if !ok {
+		http.Error(w, `{"error":"unknown id"}`, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(att)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Resolution sources recorded in the audit log.
+const (
+	SourceTelegram = "telegram"
+	SourceSlack    = "slack"
+	SourceRespond  = "respond"
+	SourceAuto     = "auto"
+	SourceTimeout  = "timeout"
+	// SourceUndeliverable marks requests denied because no prompt could
+	// be delivered.
+	SourceUndeliverable = "undeliverable"
+	// SourceShutdown marks requests denied because the bridge stopped.
+	SourceShutdown = "shutdown"
+)
+
+// AuditEntry is one line of the audit log: a resolved request, the decision
+// and where the decision came from.
+type AuditEntry struct {
+	Time     time.Time          `json:"time"`
+	Source   string             `json:"source"`
+	Request  PermissionRequest  `json:"request"`
+	Response PermissionResponse `json:"response"`
+	// Attestation is the signed decision, when the bridge signs decisions.
+	Attestation *Attestation `json:"attestation,omitempty"`
+}
+
+// AuditSink is where the bridge writes each decision as it is made: the
+// JSONL file of an AuditLog, a syslog collector, or several of them.
+type AuditSink interface {
+	Record(entry AuditEntry) error
+	Close() error
+}
+
+// AuditSinks records every entry to each of its sinks, so that a sink
+// failing loses the entry only there.
+type AuditSinks []AuditSink
+
+// Record writes entry to every sink, returning their errors joined.
+func (s AuditSinks) Record(entry AuditEntry) error {
+	var errs []error
+	for _, sink := range s {
+		errs = append(errs, sink.Record(entry))
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes every sink, returning their errors joined.
+func (s AuditSinks) Close() error {
+	var errs []error
+	for _, sink := range s {
+		errs = append(errs, sink.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// AuditLog appends AuditEntry records to a JSONL file. It is safe for
+// concurrent use. A nil *AuditLog discards every record.
+type AuditLog struct {
+	mu    sync.Mutex
+	f     *os.File
+	fsync bool
+}
+
+// OpenAuditLog opens (creating if needed) the append-only audit file at path.
+// With fsync set, every record is flushed to stable storage before Record
+// returns, trading throughput for durability.
+func OpenAuditLog(path string, fsync bool) (*AuditLog, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &AuditLog{f: f, fsync: fsync}, nil
+}
+
+// Record appends entry as a single JSON line.
+func (a *AuditLog) Record(entry AuditEntry) error {
+	if a == nil {
+		return nil
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.f.Write(line); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	if a.fsync {
+		if err := a.f.Sync(); err != nil {
+			return fmt.Errorf("failed to sync audit log: %w", err)
+		}
+	}
+	return nil
+}
+
+// Close closes the underlying file.
+func (a *AuditLog) Close() error {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.f.Close()
+}
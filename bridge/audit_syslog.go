@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// syslogFacility is local0, the facility left to local use.
+	syslogFacility = 16
+	// Severities of approved and denied decisions.
+	syslogSeverityInfo   = 6
+	syslogSeverityNotice = 5
+	// syslogAppName names the bridge in every message.
+	syslogAppName = "gebunden-bridge"
+	// syslogSDID names the structured data of a decision. 32473 is the
+	// enterprise number RFC 5612 reserves for documentation, as no other
+	// is registered for Gebunden.
+	syslogSDID = "decision@32473"
+	// syslogTimeout bounds dialing the collector and writing to it.
+	syslogTimeout = 5 * time.Second
+)
+
+// SyslogSink sends AuditEntry records to a syslog collector as RFC 5424
+// messages: approvals at severity informational, denials at notice, both
+// from facility local0. The structured data carries the request ID, type,
+// app, source and reason for filtering, and the message the entry as
+// JSON, as the audit log holds it. Over TCP, messages are framed by octet
+// counting (RFC 6587) and the connection is dialed again once if a write
+// fails. It is safe for concurrent use. A nil *SyslogSink discards every
+// record.
+type SyslogSink struct {
+	mu       sync.Mutex
+	network  string
+	addr     string
+	conn     net.Conn
+	hostname string
+	pid      int
+}
+
+// OpenSyslogSink connects to the collector at target, given as
+// udp://host:port or tcp://host:port.
+func OpenSyslogSink(target string) (*SyslogSink, error) {
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "udp" && u.Scheme != "tcp") || u.Port() == "" {
+		return nil, fmt.Errorf("invalid syslog address %q: want udp://host:port or tcp://host:port", target)
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	s := &SyslogSink{network: u.Scheme, addr: u.Host, hostname: syslogHeaderField(hostname, 255), pid: os.Getpid()}
+	if err := s.dial(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *SyslogSink) dial() error {
+	conn, err := net.DialTimeout(s.network, s.addr, syslogTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	s.conn = conn
+	return nil
+}
+
+// Record sends entry as one syslog message.
+func (s *SyslogSink) Record(entry AuditEntry) error {
+	if s == nil {
+		return nil
+	}
+	msg, err := s.format(entry)
+	if err != nil {
+		return err
+	}
+	if s.network == "tcp" {
+		msg = append([]byte(fmt.Sprintf("%d ", len(msg))), msg...)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return fmt.Errorf("failed to send audit entry to syslog: %w", net.ErrClosed)
+	}
+	err = s.write(msg)
+	if err != nil && s.network == "tcp" {
+		s.conn.Close()
+		if err = s.dial(); err == nil {
+			err = s.write(msg)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to send audit entry to syslog: %w", err)
+	}
+	return nil
+}
+
+func (s *SyslogSink) write(msg []byte) error {
+	s.conn.SetWriteDeadline(time.Now().Add(syslogTimeout))
+	_, err := s.conn.Write(msg)
+	return err
+}
+
+// format renders entry as an RFC 5424 message.
+func (s *SyslogSink) format(entry AuditEntry) ([]byte, error) {
+	body, err := json.Marshal(entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+	severity, msgID := syslogSeverityNotice, "denied"
+	if entry.Response.Approved {
+		severity, msgID = syslogSeverityInfo, "approved"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %d %s [%s", syslogFacility*8+severity,
+		entry.Time.UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		s.hostname, syslogAppName, s.pid, msgID, syslogSDID)
+	for _, param := range [][2]string{
+		{"id", entry.Request.ID},
+		{"type", entry.Request.Type},
+		{"app", entry.Request.App},
+		{"source", entry.Source},
+		{"reason", entry.Response.Reason},
+	} {
+		fmt.Fprintf(&b, ` %s="%s"`, param[0], syslogParamValue(param[1]))
+	}
+	// The BOM marks the message as UTF-8.
+	b.WriteString("] \xEF\xBB\xBF")
+	b.Write(body)
+	return []byte(b.String()), nil
+}
+
+// Close closes the connection to the collector.
+func (s *SyslogSink) Close() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// syslogHeaderField makes v fit a header field: printable ASCII without
+// spaces, at most limit long.
+func syslogHeaderField(v string, limit int) string {
+	v = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return -1
+		}
+		return r
+	}, v)
+	if len(v) > limit {
+		v = v[:limit]
+	}
+	if v == "" {
+		return "-"
+	}
+	return v
+}
+
+// syslogParamValue escapes the characters RFC 5424 reserves in a
+// structured data parameter value.
+var syslogParamValue = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace
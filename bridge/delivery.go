@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Policies for a prompt that could not be delivered after every attempt.
+const (
+	// UndeliverableDeny denies the request immediately.
+	UndeliverableDeny = "deny"
+	// UndeliverableQueue keeps the request pending on the dead-letter list
+	// and retries delivery periodically until it is decided or times out.
+	UndeliverableQueue = "queue"
+)
+
+const (
+	defaultSendAttempts   = 3
+	defaultSendRetryDelay = time.Second
+	deadLetterInterval    = 30 * time.Second
+)
+
+// permanentError marks a delivery failure that retrying cannot fix, such as a
+// rejected bot token or chat ID.
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// permanent wraps err so deliver gives up without further attempts.
+func permanent(err error) error { return &permanentError{err: err} }
+
+// isPermanentStatus reports whether an HTTP status from a chat API means the
+// request itself is wrong (4xx other than rate limiting) rather than the
+// service being temporarily unavailable.
+func isPermanentStatus(status int) bool {
+	return status >= 400 && status < 500 && status != http.StatusTooManyRequests
+}
+
+// notify delivers the prompt for req, retrying up to bs.sendAttempts times.
+// If every attempt fails the undeliverable policy is applied.
+func (bs *BridgeServer) notify(req PermissionRequest) {
+	if bs.notifier == nil {
+		return
+	}
+	req.Label = bs.label
+	if req.Type == "spend" && bs.fiat != nil {
+		req.Fiat, _ = bs.fiat.Format(req.Amount)
+	}
+	if bs.deliver(req) {
+		// A decision may have settled the request while it was being sent.
+		if !bs.isPending(req.ID) {
+			bs.forgetPrompt(req.ID)
+		}
+		return
+	}
+
+	switch bs.undeliverable {
+	case UndeliverableQueue:
+		bs.mu.Lock()
+		if _, ok := bs.pending[req.ID]; ok {
+			bs.deadLetters[req.ID] = req
+		}
+		bs.mu.Unlock()
+		bs.logger.Warn("Prompt queued for redelivery", "id", req.ID)
+	default:
+		bs.logger.Warn("Prompt undeliverable, denying", "id", req.ID)
+		bs.resolve(PermissionResponse{
+			ID:       req.ID,
+			Approved: false,
+			Reason:   ReasonSendFailed,
+			Message:  "prompt delivery failed",
+		}, SourceUndeliverable)
+	}
+}
+
+// deliver makes up to bs.sendAttempts attempts to send req, doubling the
+// delay after each transient failure. It gives up at once on a permanent
+// failure or if the request is decided in the meantime.
+func (bs *BridgeServer) deliver(req PermissionRequest) bool {
+	delay := bs.sendRetryDelay
+	for attempt := 1; attempt <= bs.sendAttempts; attempt++ {
+		err := bs.notifier.Send(req)
+		if err == nil {
+			return true
+		}
+		var perm *permanentError
+		isPermanent := errors.As(err, &perm)
+		bs.logger.Error("Prompt delivery failed", "error", err, "id", req.ID,
+			"attempt", attempt, "of", bs.sendAttempts, "permanent", isPermanent)
+		if isPermanent || attempt == bs.sendAttempts || !bs.isPending(req.ID) {
+			break
+		}
+		select {
+		case <-time.After(delay):
+		case <-bs.stopCh:
+			return false
+		}
+		delay *= 2
+	}
+	return false
+}
+
+// forgetPrompt lets the notifier drop what it kept of the prompt for a
+// settled request.
+func (bs *BridgeServer) forgetPrompt(id string) {
+	if rn, ok := bs.notifier.(ResolveNotifier); ok {
+		rn.Resolved(id)
+	}
+}
+
+func (bs *BridgeServer) isPending(id string) bool {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	_, ok := bs.pending[id]
+	return ok
+}
+
+// redeliverDeadLetters periodically retries queued prompts whose requests
+// are still pending.
+func (bs *BridgeServer) redeliverDeadLetters() {
+	ticker := time.NewTicker(deadLetterInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-bs.stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		bs.mu.Lock()
+		queued := make([]PermissionRequest, 0, len(bs.deadLetters))
+		for id, req := range bs.deadLetters {
+			if _, ok := bs.pending[id]; !ok {
+				delete(bs.deadLetters, id)
+				continue
+			}
+			queued = append(queued, req)
+		}
+		bs.mu.Unlock()
+
+		for _, req := range queued {
+			if err := bs.notifier.Send(req); err != nil {
+				bs.logger.Warn("Dead-letter redelivery failed", "id", req.ID, "error", err)
+				continue
+			}
+			bs.mu.Lock()
+			delete(bs.deadLetters, req.ID)
+			bs.mu.Unlock()
+			bs.logger.Info("Dead-letter prompt delivered", "id", req.ID)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// GET /dead-letters — prompts waiting for redelivery
+// ---------------------------------------------------------------------------
+
+func (bs *BridgeServer) handleDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !bs.authorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	bs.mu.Lock()
+	requests := make([]PermissionRequest, 0, len(bs.deadLetters))
+	for _, req := range bs.deadLetters {
+		requests = append(requests, req)
+	}
+	bs.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(requests)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	fiatRateTTL     = 60 * time.Second
+	fiatRateTimeout = 5 * time.Second
+	// A failed lookup is retried after fiatRateBackoff, doubling with each
+	// further failure up to fiatRateMaxBackoff.
+	fiatRateBackoff    = 5 * time.Second
+	fiatRateMaxBackoff = 5 * time.Minute
+	satsPerBSV         = 1e8
+)
+
+// FiatRates converts satoshi amounts to an approximate fiat value using an
+// exchange rate fetched from a configurable source and cached for a minute.
+// While the source is failing the last good rate keeps being used.
+type FiatRates struct {
+	logger   *slog.Logger
+	url      string
+	currency string
+	client   *http.Client
+
+	mu       sync.Mutex
+	rate     float64
+	fetched  time.Time
+	fetching bool      // a lookup is in flight
+	failures int       // consecutive failed lookups
+	retryAt  time.Time // no lookup before this after a failure
+}
+
+var errNoFiatRate = errors.New("no exchange rate available yet")
+
+// NewFiatRates creates a converter for currency (e.g. "USD"). rateURL is
+// fetched with "{currency}" replaced by the lower-case currency code; the
+// response must be JSON holding the BSV price either under a key equal to the
+// currency code (at any depth, as CoinGecko returns it) or under "rate".
+func NewFiatRates(logger *slog.Logger, rateURL, currency string) *FiatRates {
+	return &FiatRates{
+		logger:   logger,
+		url:      rateURL,
+		currency: strings.ToUpper(currency),
+		client:   &http.Client{Timeout: fiatRateTimeout},
+	}
+}
+
+// Format returns the approximate fiat value of sats, e.g. "≈ $12.34 USD".
+// ok is false if no rate is available.
+func (f *FiatRates) Format(sats int64) (s string, ok bool) {
+	rate, err := f.currentRate()
+	if err != nil {
+		f.logger.Warn("Exchange rate lookup failed", "error", err)
+	}
+	if rate <= 0 {
+		return "", false
+	}
+	value := float64(sats) / satsPerBSV * rate
+	symbol := map[string]string{"USD": "$", "EUR": "€", "GBP": "£", "JPY": "¥"}[f.currency]
+	if value < 0.01 {
+		return fmt.Sprintf("≈ <%s0.01 %s", symbol, f.currency), true
+	}
+	return fmt.Sprintf("≈ %s%.2f %s", symbol, value, f.currency), true
+}
+
+// currentRate returns the cached rate, fetching a new one once it is older
+// than fiatRateTTL. The fetch runs without holding f.mu, and only one runs at
+// a time; meanwhile, and after a failure, callers get the last good rate.
+// err reports a failed fetch even when a stale rate is returned with it.
+func (f *FiatRates) currentRate() (float64, error) {
+	f.mu.Lock()
+	rate := f.rate
+	now := time.Now()
+	if rate > 0 && now.Sub(f.fetched) < fiatRateTTL || f.fetching || now.Before(f.retryAt) {
+		f.mu.Unlock()
+		if rate <= 0 {
+			return 0, errNoFiatRate
+		}
+		return rate, nil
+	}
+	f.fetching = true
+	f.mu.Unlock()
+
+	fresh, err := f.fetch()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fetching = false
+	if err != nil {
+		f.failures++
+		backoff := fiatRateBackoff
+		for i := 1; i < f.failures && backoff < fiatRateMaxBackoff; i++ {
+			backoff *= 2
+		}
+		f.retryAt = time.Now().Add(min(backoff, fiatRateMaxBackoff))
+		return f.rate, err
+	}
+	f.rate, f.fetched, f.failures, f.retryAt = fresh, time.Now(), 0, time.Time{}
+	return fresh, nil
+}
+
+// fetch asks the rate source for the current rate.
+func (f *FiatRates) fetch() (float64, error) {
+	url := strings.ReplaceAll(f.url, "{currency}", strings.ToLower(f.currency))
+	resp, err := f.client.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("rate source returned status %d", resp.StatusCode)
+	}
+	var body interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("failed to decode rate response: %w", err)
+	}
+	rate, ok := findRate(body, f.currency)
+	if !ok || rate <= 0 {
+		return 0, fmt.Errorf("no %s rate in response", f.currency)
+	}
+	return rate, nil
+}
+
+// findRate searches v for a number (or numeric string) keyed by currency or
+// "rate", case-insensitively. At each level a currency key wins over "rate",
+// and nested objects are searched in key order, so the same response always
+// gives the same rate.
+func findRate(v interface{}, currency string) (float64, bool) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return 0, false
+	}
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, want := range []string{currency, "rate"} {
+		for _, k := range keys {
+			if !strings.EqualFold(k, want) {
+				continue
+			}
+			switch n := obj[k].(type) {
+			case float64:
+				return n, true
+			case string:
+				if f, err := strconv.ParseFloat(n, 64); err == nil {
+					return f, true
+				}
+			}
+		}
+	}
+	for _, k := range keys {
+		if rate, ok := findRate(obj[k], currency); ok {
+			return rate, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// Without flock, only the in-process mutex orders writers; processes
+// sharing a grants file can still lose each other's writes.
+
+func lockFile(*os.File) error { return nil }
+
+func unlockFile(*os.File) error { return nil }
@@ -1,19 +1,25 @@
 package main
 
 import (
-	"bytes"
+	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 )
@@ -32,45 +38,231 @@ type PermissionRequest struct {
 	Asset     string                 `json:"asset,omitempty"`
 	Timestamp int64                  `json:"timestamp"`
 	ExtraData map[string]interface{} `json:"extra_data,omitempty"`
+	// RequiredApprovals is how many distinct approvers must approve before
+	// the request is granted. Zero or one means a single approval suffices;
+	// any denial denies immediately.
+	RequiredApprovals int `json:"requiredApprovals,omitempty"`
+
+	// Fiat is the approximate fiat value of Amount, filled in by the bridge
+	// when an exchange rate source is configured.
+	Fiat string `json:"-"`
+	// Label names the wallet this bridge serves, filled in by the bridge from
+	// its -label flag so prompts from several wallets can share a chat.
+	Label string `json:"-"`
 }
 
 type PermissionResponse struct {
 	ID       string `json:"id"`
 	Approved bool   `json:"approved"`
-	Reason   string `json:"reason,omitempty"`
+	// Reason is one of the Reason* constants, stable enough for the wallet
+	// to branch on.
+	Reason string `json:"reason,omitempty"`
+	// Message is a human-readable explanation to go with Reason, e.g. which
+	// channel the user answered on or why a request was invalid.
+	Message  string `json:"message,omitempty"`
+	Duration string `json:"duration,omitempty"`
+	// Remember asks the bridge to apply this approval to matching requests
+	// from the same app for the configured remember duration. It has no
+	// effect on denials.
+	Remember bool `json:"remember,omitempty"`
+	// Approver identifies who made the decision within its source, e.g. a
+	// Telegram user ID, or whatever a /respond caller names itself. Only
+	// Telegram and Slack user IDs count toward RequiredApprovals; the name
+	// a /respond caller gives is not authenticated.
+	Approver string `json:"approver,omitempty"`
+	// Approvers lists, as "source:approver", everyone who approved a request
+	// needing several approvals. The bridge fills it in; callers' values
+	// are ignored.
+	Approvers []string `json:"approvers,omitempty"`
+}
+
+// Reasons reported in PermissionResponse.Reason.
+const (
+	ReasonUserApproved   = "user_approved"
+	ReasonUserDenied     = "user_denied"
+	ReasonAutoApproved   = "auto_approved"
+	ReasonRemembered     = "remembered"
+	ReasonTimeout        = "timeout"
+	ReasonSendFailed     = "send_failed"
+	ReasonShutdown       = "shutdown"
+	ReasonPaused         = "paused"
+	ReasonInvalidRequest = "invalid_request"
+)
+
+// knownReasons holds every Reason* constant.
+var knownReasons = map[string]bool{
+	ReasonUserApproved: true, ReasonUserDenied: true, ReasonAutoApproved: true,
+	ReasonRemembered: true, ReasonTimeout: true, ReasonSendFailed: true,
+	ReasonShutdown: true, ReasonPaused: true, ReasonInvalidRequest: true,
+}
+
+// userReason is the reason for a decision a person made.
+func userReason(approved bool) string {
+	if approved {
+		return ReasonUserApproved
+	}
+	return ReasonUserDenied
 }
 
 const permissionTimeout = 180 * time.Second
 
+// shutdownTimeout bounds how long Stop waits for in-flight handlers.
+const shutdownTimeout = 5 * time.Second
+
+// Grant durations an approver can pick for protocol and basket requests.
+// The wallet records the grant with the matching expiry; "once" (or an
+// empty duration) applies only to the request being answered.
+const (
+	GrantOnce    = "once"
+	Grant1h      = "1h"
+	Grant24h     = "24h"
+	GrantForever = "forever"
+)
+
+var grantDurations = []string{Grant1h, Grant24h, GrantForever}
+
 // ---------------------------------------------------------------------------
 // BridgeServer
 // ---------------------------------------------------------------------------
 
+// BridgeConfig holds the settings a BridgeServer is constructed with.
+type BridgeConfig struct {
+	// Listen is the host the HTTP server binds to. Empty selects 127.0.0.1;
+	// anything else exposes the approval API beyond this machine.
+	Listen string
+	Port   int
+	// TLSCert and TLSKey, when both set, serve HTTPS with this key pair.
+	TLSCert string
+	TLSKey  string
+	// Logger is used for all bridge output. Nil selects an info-level text
+	// logger on stdout.
+	Logger *slog.Logger
+	// Notifier delivers prompts to the approver. Nil disables prompting;
+	// decisions can then only arrive via /respond.
+	Notifier Notifier
+	// Secret, when set, is required as a bearer token by /respond and /pending.
+	Secret string
+	// AutoApproveUnder approves spend requests below this many satoshis
+	// without prompting. Zero disables auto-approval.
+	AutoApproveUnder int64
+	// Audit, when set, records every resolved request.
+	Audit AuditSink
+	// SendAttempts bounds how often a prompt is sent before the
+	// Undeliverable policy applies. Zero selects the default of 3.
+	SendAttempts int
+	// SendRetryDelay is the pause after the first failed send attempt; it
+	// doubles after each further failure. Zero selects 1s.
+	SendRetryDelay time.Duration
+	// Undeliverable is UndeliverableDeny (the default) or UndeliverableQueue.
+	Undeliverable string
+	// RememberFor is how long an approval marked Remember applies to matching
+	// requests from the same app. Zero disables remembering.
+	RememberFor time.Duration
+	// Grants stores remembered approvals. Nil keeps them in memory.
+	Grants GrantStore
+	// RateLimit caps how many prompts one app can trigger per RateWindow;
+	// further requests get 429. Zero disables the limit.
+	RateLimit  int
+	RateWindow time.Duration
+	// Attester, when set, signs every recorded decision.
+	Attester *Attester
+	// Fiat, when set, adds an approximate fiat value to spend prompts.
+	Fiat *FiatRates
+	// Label is prefixed to every prompt, e.g. "[Treasury]".
+	Label string
+	// QuorumApprovals, when above one, is the RequiredApprovals applied to
+	// spends of at least QuorumOver satoshis that don't set their own.
+	QuorumApprovals int
+	QuorumOver      int64
+}
+
 type BridgeServer struct {
-	logger        *slog.Logger
-	port          int
-	telegramToken string
-	telegramChat  string
-	pending       map[string]pendingEntry
-	mu            sync.Mutex
-	stopCh        chan struct{}
+	logger           *slog.Logger
+	listen           string
+	port             int
+	tlsCert          string
+	tlsKey           string
+	notifier         Notifier
+	secret           string
+	autoApproveUnder int64
+	audit            AuditSink
+	sendAttempts     int
+	sendRetryDelay   time.Duration
+	undeliverable    string
+	deadLetters      map[string]PermissionRequest
+	rememberFor      time.Duration
+	remembered       *decisionCache
+	fiat             *FiatRates
+	label            string
+	quorumApprovals  int
+	quorumOver       int64
+	paused           atomic.Bool
+	metrics          *bridgeMetrics
+	recent           *recentDecisions
+	limiter          *appRateLimiter
+	attester         *Attester
+	pending          map[string]pendingEntry
+	server           *http.Server
+	mu               sync.Mutex
+	stopCh           chan struct{}
+	stopOnce         sync.Once
 }
 
 type pendingEntry struct {
-	request PermissionRequest
-	ch      chan PermissionResponse
+	request   PermissionRequest
+	ch        chan PermissionResponse
+	created   time.Time
+	approvers map[string]bool // distinct approvals so far, for RequiredApprovals > 1
 }
 
-func NewBridgeServer(port int, telegramToken, telegramChat string) *BridgeServer {
-	return &BridgeServer{
-		logger: slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+func NewBridgeServer(cfg BridgeConfig) *BridgeServer {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
 			Level: slog.LevelInfo,
-		})),
-		port:          port,
-		telegramToken: telegramToken,
-		telegramChat:  telegramChat,
-		pending:       make(map[string]pendingEntry),
-		stopCh:        make(chan struct{}),
+		}))
+	}
+	if cfg.Listen == "" {
+		cfg.Listen = "127.0.0.1"
+	}
+	if cfg.SendAttempts <= 0 {
+		cfg.SendAttempts = defaultSendAttempts
+	}
+	if cfg.SendRetryDelay <= 0 {
+		cfg.SendRetryDelay = defaultSendRetryDelay
+	}
+	if cfg.Undeliverable == "" {
+		cfg.Undeliverable = UndeliverableDeny
+	}
+	if cfg.Grants == nil {
+		cfg.Grants = NewMemoryGrantStore()
+	}
+	return &BridgeServer{
+		logger:           logger,
+		listen:           cfg.Listen,
+		port:             cfg.Port,
+		tlsCert:          cfg.TLSCert,
+		tlsKey:           cfg.TLSKey,
+		notifier:         cfg.Notifier,
+		secret:           cfg.Secret,
+		autoApproveUnder: cfg.AutoApproveUnder,
+		audit:            cfg.Audit,
+		sendAttempts:     cfg.SendAttempts,
+		sendRetryDelay:   cfg.SendRetryDelay,
+		undeliverable:    cfg.Undeliverable,
+		deadLetters:      make(map[string]PermissionRequest),
+		rememberFor:      cfg.RememberFor,
+		remembered:       newDecisionCache(cfg.Grants, logger),
+		fiat:             cfg.Fiat,
+		label:            cfg.Label,
+		quorumApprovals:  cfg.QuorumApprovals,
+		quorumOver:       cfg.QuorumOver,
+		metrics:          newBridgeMetrics(),
+		recent:           newRecentDecisions(recentDecisionTTL),
+		limiter:          newAppRateLimiter(cfg.RateLimit, cfg.RateWindow),
+		attester:         cfg.Attester,
+		pending:          make(map[string]pendingEntry),
+		stopCh:           make(chan struct{}),
 	}
 }
 
@@ -79,20 +271,103 @@ func (bs *BridgeServer) Start() error {
 	mux.HandleFunc("/request-permission", bs.handlePermissionRequest)
 	mux.HandleFunc("/respond", bs.handleResponse)
 	mux.HandleFunc("/pending", bs.handlePending)
-	mux.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) {
-		json.NewEncoder(w).Encode(map[string]bool{"ok": true})
-	})
+	mux.HandleFunc("/dead-letters", bs.handleDeadLetters)
+	mux.HandleFunc("/remembered", bs.handleRemembered)
+	mux.HandleFunc("/pause", bs.handlePause)
+	mux.HandleFunc("/resume", bs.handleResume)
+	mux.HandleFunc("/health", bs.handleHealth)
+	mux.HandleFunc("/stats", bs.handleStats)
+	mux.HandleFunc("/metrics", bs.handleMetrics)
+	mux.HandleFunc("/evaluate", bs.handleEvaluate)
+	mux.HandleFunc("/attestation", bs.handleAttestation)
+
+	if bs.notifier != nil {
+		bs.notifier.Start(bs.resolve, mux, bs.stopCh)
+		if bs.undeliverable == UndeliverableQueue {
+			go bs.redeliverDeadLetters()
+		}
+	}
 
-	if bs.telegramToken != "" {
-		go bs.pollTelegramUpdates()
+	addr := net.JoinHostPort(bs.listen, strconv.Itoa(bs.port))
+	srv := &http.Server{Addr: addr, Handler: mux}
+	bs.mu.Lock()
+	bs.server = srv
+	bs.mu.Unlock()
+
+	var err error
+	if bs.tlsCert != "" && bs.tlsKey != "" {
+		bs.logger.Info("Bridge listening", "addr", addr, "tls", true)
+		err = srv.ListenAndServeTLS(bs.tlsCert, bs.tlsKey)
+	} else {
+		bs.logger.Info("Bridge listening", "addr", addr, "tls", false)
+		err = srv.ListenAndServe()
+	}
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
 	}
+	return nil
+}
 
-	addr := fmt.Sprintf("127.0.0.1:%d", bs.port)
-	bs.logger.Info("Bridge listening", "addr", addr)
-	return http.ListenAndServe(addr, mux)
+// validateTLSFiles checks that the TLS flags are either both empty or both
+// name readable files.
+func validateTLSFiles(cert, key string) error {
+	if (cert == "") != (key == "") {
+		return errors.New("-tls-cert and -tls-key must be given together")
+	}
+	for _, path := range []string{cert, key} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("TLS file: %w", err)
+		}
+	}
+	return nil
 }
 
-func (bs *BridgeServer) Stop() { close(bs.stopCh) }
+// Stop denies every pending request with "bridge shutting down", stops the
+// notifier and waits (up to shutdownTimeout) for handlers to deliver their
+// responses. It is safe to call more than once.
+func (bs *BridgeServer) Stop() {
+	bs.stopOnce.Do(func() { close(bs.stopCh) })
+
+	bs.mu.Lock()
+	ids := make([]string, 0, len(bs.pending))
+	for id := range bs.pending {
+		ids = append(ids, id)
+	}
+	srv := bs.server
+	bs.mu.Unlock()
+
+	for _, id := range ids {
+		bs.resolve(shutdownResponse(id), SourceShutdown)
+	}
+	if len(ids) > 0 {
+		bs.logger.Info("Denied pending requests on shutdown", "count", len(ids))
+	}
+
+	if srv != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil {
+			bs.logger.Error("Bridge server shutdown error", "error", err)
+		}
+	}
+}
+
+func shutdownResponse(id string) PermissionResponse {
+	return PermissionResponse{ID: id, Approved: false, Reason: ReasonShutdown, Message: "bridge shutting down"}
+}
+
+// authorized reports whether r carries the configured bridge secret as a
+// bearer token. When no secret is configured every request is authorized.
+func (bs *BridgeServer) authorized(r *http.Request) bool {
+	if bs.secret == "" {
+		return true
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(bs.secret)) == 1
+}
 
 // ---------------------------------------------------------------------------
 // POST /request-permission — wallet pushes here, blocks until decision
@@ -103,6 +378,10 @@ func (bs *BridgeServer) handlePermissionRequest(w http.ResponseWriter, r *http.R
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	if bs.paused.Load() {
+		http.Error(w, `{"error":"bridge paused"}`, http.StatusServiceUnavailable)
+		return
+	}
 	var req PermissionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, `{"error":"bad request"}`, http.StatusBadRequest)
@@ -112,31 +391,141 @@ func (bs *BridgeServer) handlePermissionRequest(w http.ResponseWriter, r *http.R
 	bs.logger.Info("Permission request", "id", req.ID, "type", req.Type,
 		"app", req.App, "amount", req.Amount)
 
+	if err := validateRequest(req); err != nil {
+		bs.logger.Warn("Rejected invalid permission request", "id", req.ID, "error", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error(), "id": req.ID})
+		return
+	}
+	bs.metrics.requested(req.Type)
+	bs.applyQuorum(&req)
+	if req.Timestamp == 0 {
+		req.Timestamp = time.Now().Unix()
+	}
+
+	if d := bs.policy(req); d.Decision != PolicyPrompt {
+		approved := d.Decision == PolicyAutoApprove
+		bs.logger.Info("Answered without prompting", "id", req.ID, "app", req.App,
+			"type", req.Type, "amount", req.Amount, "approved", approved, "reason", d.Reason)
+		resp := PermissionResponse{
+			ID:       req.ID,
+			Approved: approved,
+			Reason:   d.Reason,
+			Message:  d.Message,
+		}
+		bs.metrics.decided(req.Type, approved)
+		bs.recordAudit(d.source, req, resp)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	if !bs.limiter.allow(req.App, time.Now()) {
+		bs.logger.Warn("Rate limited permission request", "id", req.ID, "app", req.App, "type", req.Type)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(map[string]string{"error": "rate limited", "id": req.ID})
+		return
+	}
+
 	ch := make(chan PermissionResponse, 1)
 	bs.mu.Lock()
-	bs.pending[req.ID] = pendingEntry{request: req, ch: ch}
+	entry := pendingEntry{request: req, ch: ch, created: time.Now()}
+	if req.RequiredApprovals > 1 {
+		entry.approvers = make(map[string]bool)
+	}
+	bs.pending[req.ID] = entry
 	bs.mu.Unlock()
 
-	// Send Telegram prompt if configured
-	go bs.sendToTelegram(req)
+	// Send prompt if a notifier is configured
+	go bs.notify(req)
 
 	select {
 	case resp := <-ch:
-		bs.mu.Lock()
-		delete(bs.pending, req.ID)
-		bs.mu.Unlock()
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(resp)
 
+	case <-bs.stopCh:
+		// Deny unless a decision claimed the request first; either way the
+		// claiming decision is delivered on ch.
+		bs.resolve(shutdownResponse(req.ID), SourceShutdown)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(<-ch)
+
 	case <-time.After(permissionTimeout):
 		bs.mu.Lock()
+		_, stillPending := bs.pending[req.ID]
 		delete(bs.pending, req.ID)
 		bs.mu.Unlock()
+		if !stillPending {
+			// A decision claimed the request just as the timer fired.
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(<-ch)
+			return
+		}
+		bs.metrics.timedOut(req.Type)
+		timeout := PermissionResponse{ID: req.ID, Reason: ReasonTimeout, Message: "no decision was made in time"}
+		bs.recent.put(timeout)
+		if en, ok := bs.notifier.(ExpiryNotifier); ok {
+			go en.Expire(req)
+		}
+		bs.recordAudit(SourceTimeout, req, timeout)
 		w.WriteHeader(http.StatusGatewayTimeout)
 		fmt.Fprintf(w, `{"error":"timeout","id":"%s"}`, req.ID)
 	}
 }
 
+func (bs *BridgeServer) recordAudit(source string, req PermissionRequest, resp PermissionResponse) {
+	now := time.Now().UTC()
+	attestation := bs.attester.attest(source, req, resp, now)
+	if bs.audit == nil {
+		return
+	}
+	err := bs.audit.Record(AuditEntry{
+		Time:        now,
+		Source:      source,
+		Request:     req,
+		Response:    resp,
+		Attestation: attestation,
+	})
+	if err != nil {
+		bs.logger.Error("Audit write failed", "error", err, "id", req.ID)
+	}
+}
+
+// groupCountFields maps each count a group request may declare in ExtraData
+// to the item array that must accompany it.
+var groupCountFields = map[string]string{
+	"protocolCount":     "protocols",
+	"basketCount":       "baskets",
+	"certificateCount":  "certificates",
+	"counterpartyCount": "counterparties",
+}
+
+// validateRequest rejects requests whose prompt would misrepresent what is
+// being asked. Group requests must list exactly as many items as they declare.
+func validateRequest(req PermissionRequest) error {
+	if req.Type != "group" {
+		return nil
+	}
+	for countKey, itemsKey := range groupCountFields {
+		raw, ok := req.ExtraData[countKey]
+		if !ok {
+			continue
+		}
+		count, ok := raw.(float64)
+		if !ok || count < 0 || count != float64(int(count)) {
+			return fmt.Errorf("group %s must be a non-negative integer, got %v", countKey, raw)
+		}
+		items, _ := req.ExtraData[itemsKey].([]interface{})
+		if int(count) != len(items) {
+			return fmt.Errorf("group %s is %d but %d %s were listed", countKey, int(count), len(items), itemsKey)
+		}
+	}
+	return nil
+}
+
 // ---------------------------------------------------------------------------
 // POST /respond — external decision (fallback for non-Telegram setups)
 // ---------------------------------------------------------------------------
@@ -146,18 +535,47 @@ func (bs *BridgeServer) handleResponse(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	if !bs.authorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
 	var resp PermissionResponse
 	if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
 		http.Error(w, "bad request", http.StatusBadRequest)
 		return
 	}
-	bs.resolve(resp.ID, resp.Approved, resp.Reason)
+	// Callers may send free text as the reason; keep it as the message so
+	// the reason stays one of the known values.
+	if !knownReasons[resp.Reason] {
+		if resp.Message == "" {
+			resp.Message = resp.Reason
+		}
+		resp.Reason = userReason(resp.Approved)
+	}
 	w.Header().Set("Content-Type", "application/json")
-	w.Write([]byte(`{"ok":true}`))
+	if resp.Approved && bs.needsQuorum(resp.ID) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": false, "error": "approvals needing several approvers must come from Telegram or Slack", "id": resp.ID})
+		return
+	}
+	remaining, ok := bs.resolve(resp, SourceRespond)
+	if ok && remaining > 0 {
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "remaining": remaining})
+		return
+	}
+	// The request is settled, by this call or an earlier decision; report
+	// whichever decision won so retried calls get the same answer.
+	decision, known := bs.recent.get(resp.ID)
+	if !known {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": false, "error": "unknown id", "id": resp.ID})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "decision": decision})
 }
 
 // ---------------------------------------------------------------------------
-// GET /pending — for polling agents
+// GET /pending — open requests for polling agents and dashboards
 // ---------------------------------------------------------------------------
 
 func (bs *BridgeServer) handlePending(w http.ResponseWriter, r *http.Request) {
@@ -165,6 +583,10 @@ func (bs *BridgeServer) handlePending(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	if !bs.authorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
 	bs.mu.Lock()
 	requests := make([]PermissionRequest, 0, len(bs.pending))
 	for _, entry := range bs.pending {
@@ -172,51 +594,48 @@ func (bs *BridgeServer) handlePending(w http.ResponseWriter, r *http.Request) {
 	}
 	bs.mu.Unlock()
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{"pending": requests, "count": len(requests)})
+	json.NewEncoder(w).Encode(requests)
 }
 
 // ---------------------------------------------------------------------------
-// Telegram: send prompt with inline buttons
+// Notifiers: deliver prompts to the approver and report decisions back
 // ---------------------------------------------------------------------------
 
-func (bs *BridgeServer) sendToTelegram(req PermissionRequest) {
-	if bs.telegramToken == "" || bs.telegramChat == "" {
-		return
-	}
-
-	text := formatPrompt(req)
-	approveLabel := promptButton(req.Type)
-	keyboard := [][]map[string]interface{}{
-		{
-			{"text": approveLabel, "callback_data": fmt.Sprintf("approve:%s", req.ID)},
-			{"text": "❌ Deny", "callback_data": fmt.Sprintf("deny:%s", req.ID)},
-		},
-	}
-
-	payload := map[string]interface{}{
-		"chat_id":      bs.telegramChat,
-		"text":         text,
-		"parse_mode":   "HTML",
-		"reply_markup": map[string]interface{}{"inline_keyboard": keyboard},
-	}
-	payloadJSON, _ := json.Marshal(payload)
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", bs.telegramToken)
+// DecisionFunc receives an approver's decision for a pending request and
+// reports whether the request was still waiting for one. source names the
+// channel the decision arrived on (see the Source constants). remaining is
+// the number of further approvals the request needs before it is granted;
+// it is zero once the decision has settled the request.
+type DecisionFunc func(resp PermissionResponse, source string) (remaining int, ok bool)
+
+// Notifier delivers permission prompts to a human approver over some chat
+// channel. Decisions are reported asynchronously through the DecisionFunc
+// passed to Start.
+type Notifier interface {
+	// Send delivers the prompt for req.
+	Send(req PermissionRequest) error
+	// Start begins listening for decisions, either in a background goroutine
+	// or by registering callback handlers on mux, until stop is closed.
+	Start(decide DecisionFunc, mux *http.ServeMux, stop <-chan struct{})
+}
 
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(payloadJSON))
-	if err != nil {
-		bs.logger.Error("Telegram send failed", "error", err, "id", req.ID)
-		return
-	}
-	defer resp.Body.Close()
+// ExpiryNotifier is implemented by notifiers that can update a delivered
+// prompt once its request times out without a decision.
+type ExpiryNotifier interface {
+	Expire(req PermissionRequest)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		bs.logger.Error("Telegram API error", "status", resp.StatusCode, "body", string(body))
-		return
-	}
-	bs.logger.Info("Prompt sent to Telegram", "id", req.ID, "type", req.Type)
+// ResolveNotifier is implemented by notifiers that keep state for a delivered
+// prompt, such as the message it was sent as, until its request is settled.
+// Resolved is called once the request with id is settled from any source.
+type ResolveNotifier interface {
+	Resolved(id string)
 }
 
+// ---------------------------------------------------------------------------
+// Prompt content, shared by every notifier
+// ---------------------------------------------------------------------------
+
 func promptButton(permType string) string {
 	switch permType {
 	case "spend":
@@ -236,75 +655,239 @@ func promptButton(permType string) string {
 	}
 }
 
-func formatPrompt(req PermissionRequest) string {
-	var b strings.Builder
+// offersGrantDuration reports whether the prompt for permType lets the approver
+// choose how long the grant lasts.
+func offersGrantDuration(permType string) bool {
+	return permType == "protocol" || permType == "basket"
+}
+
+func grantLabel(duration string) string {
+	switch duration {
+	case Grant1h:
+		return "1 hour"
+	case Grant24h:
+		return "24 hours"
+	case GrantForever:
+		return "Forever"
+	default:
+		return "Once"
+	}
+}
+
+// promptAction is one button on a prompt. Style is "primary", "danger" or
+// empty, for channels that support styled buttons.
+type promptAction struct {
+	Label string
+	Data  string
+	Style string
+}
+
+// promptActions lays out the buttons for req as rows. rememberFor, when
+// non-zero, adds an "Always allow" button for types that can be remembered.
+func promptActions(req PermissionRequest, rememberFor time.Duration) [][]promptAction {
+	rows := [][]promptAction{{
+		{Label: promptButton(req.Type), Data: "approve:" + req.ID, Style: "primary"},
+		{Label: "❌ Deny", Data: "deny:" + req.ID, Style: "danger"},
+	}}
+	if offersGrantDuration(req.Type) {
+		row := make([]promptAction, 0, len(grantDurations))
+		for _, d := range grantDurations {
+			row = append(row, promptAction{
+				Label: "⏱ " + grantLabel(d),
+				Data:  fmt.Sprintf("approve_%s:%s", d, req.ID),
+			})
+		}
+		rows = append(rows, row)
+	}
+	if rememberFor > 0 && remembersDecision(req.Type) {
+		rows = append(rows, []promptAction{{
+			Label: "🔁 Always allow for " + shortDuration(rememberFor),
+			Data:  "remember:" + req.ID,
+		}})
+	}
+	return rows
+}
+
+// decisionLabel is appended to a prompt once the approver has answered it.
+func decisionLabel(a callbackAction, rememberFor time.Duration) string {
+	if !a.Approved {
+		return "❌ Denied"
+	}
+	if a.Remember {
+		return "✅ Always allowed for " + shortDuration(rememberFor)
+	}
+	if a.Duration != "" {
+		return "✅ Approved (" + grantLabel(a.Duration) + ")"
+	}
+	return "✅ Approved"
+}
+
+// promptField is one labelled line of a prompt. Bullet fields render as
+// "• Label: Value" list items; Code fields render the value in monospace.
+type promptField struct {
+	Label  string
+	Value  string
+	Code   bool
+	Bullet bool
+}
+
+// prompt is the channel-neutral content of a permission prompt.
+type prompt struct {
+	Label  string
+	Icon   string
+	Title  string
+	Fields []promptField
+}
+
+// buildPrompt lays out the per-type content of the prompt for req.
+func buildPrompt(req PermissionRequest) prompt {
+	p := prompt{Label: req.Label}
+	add := func(label string, value interface{}) {
+		p.Fields = append(p.Fields, promptField{Label: label, Value: fmt.Sprint(value)})
+	}
+	addCode := func(label string, value interface{}) {
+		p.Fields = append(p.Fields, promptField{Label: label, Value: fmt.Sprint(value), Code: true})
+	}
+	addBullet := func(label string, value string) {
+		p.Fields = append(p.Fields, promptField{Label: label, Value: value, Bullet: true})
+	}
 
 	switch req.Type {
 	case "spend":
-		b.WriteString("💸 <b>Spending Authorization</b>\n\n")
-		b.WriteString(fmt.Sprintf("<b>App:</b> <code>%s</code>\n", h(req.App)))
+		p.Icon, p.Title = "💸", "Spending Authorization"
+		addCode("App", req.App)
 		if req.Amount > 0 {
-			b.WriteString(fmt.Sprintf("<b>Amount:</b> %d sats\n", req.Amount))
+			add("Amount", fmt.Sprintf("%d sats", req.Amount))
+		}
+		if req.Fiat != "" {
+			add("Value", req.Fiat)
 		}
 		if req.Message != "" {
-			b.WriteString(fmt.Sprintf("<b>Description:</b> %s\n", h(req.Message)))
+			add("Description", req.Message)
 		}
 
 	case "protocol":
-		b.WriteString("🔗 <b>Protocol Access Request</b>\n\n")
-		b.WriteString(fmt.Sprintf("<b>App:</b> <code>%s</code>\n", h(req.App)))
+		p.Icon, p.Title = "🔗", "Protocol Access Request"
+		addCode("App", req.App)
 		if pid, ok := req.ExtraData["protocolID"]; ok {
-			b.WriteString(fmt.Sprintf("<b>Protocol:</b> %v\n", pid))
+			add("Protocol", pid)
 		}
 		if sl, ok := req.ExtraData["securityLevel"]; ok {
-			b.WriteString(fmt.Sprintf("<b>Security Level:</b> %v\n", sl))
+			add("Security Level", sl)
 		}
 		if req.Message != "" {
-			b.WriteString(fmt.Sprintf("<b>Reason:</b> %s\n", h(req.Message)))
+			add("Reason", req.Message)
 		}
 
 	case "basket":
-		b.WriteString("🧺 <b>Basket Access Request</b>\n\n")
-		b.WriteString(fmt.Sprintf("<b>App:</b> <code>%s</code>\n", h(req.App)))
+		p.Icon, p.Title = "🧺", "Basket Access Request"
+		addCode("App", req.App)
 		if basket, ok := req.ExtraData["basket"]; ok {
-			b.WriteString(fmt.Sprintf("<b>Basket:</b> %v\n", basket))
+			add("Basket", basket)
 		}
 
 	case "certificate":
-		b.WriteString("📜 <b>Certificate Access Request</b>\n\n")
-		b.WriteString(fmt.Sprintf("<b>App:</b> <code>%s</code>\n", h(req.App)))
+		p.Icon, p.Title = "📜", "Certificate Access Request"
+		addCode("App", req.App)
 		if ct, ok := req.ExtraData["certificateType"]; ok {
-			b.WriteString(fmt.Sprintf("<b>Type:</b> %v\n", ct))
+			add("Type", ct)
 		}
 		if vpk, ok := req.ExtraData["verifierPublicKey"]; ok {
-			b.WriteString(fmt.Sprintf("<b>Verifier:</b> <code>%s</code>\n", h(fmt.Sprint(vpk))))
+			addCode("Verifier", vpk)
 		}
 
 	case "group":
-		b.WriteString("📋 <b>Grouped Permission Request</b>\n\n")
-		b.WriteString(fmt.Sprintf("<b>App:</b> <code>%s</code>\n", h(req.App)))
+		p.Icon, p.Title = "📋", "Grouped Permission Request"
+		addCode("App", req.App)
 		if spend, ok := req.ExtraData["spendingAmount"]; ok {
-			b.WriteString(fmt.Sprintf("• Spending: %v sats\n", spend))
+			addBullet("Spending", fmt.Sprintf("%v sats", spend))
 		}
 		if protos, ok := req.ExtraData["protocolCount"]; ok {
-			b.WriteString(fmt.Sprintf("• Protocols: %v\n", protos))
+			addBullet("Protocols", fmt.Sprint(protos))
 		}
 
 	case "counterparty":
-		b.WriteString("🤝 <b>Counterparty Permission</b>\n\n")
-		b.WriteString(fmt.Sprintf("<b>App:</b> <code>%s</code>\n", h(req.App)))
+		p.Icon, p.Title = "🤝", "Counterparty Permission"
+		addCode("App", req.App)
 		if cp, ok := req.ExtraData["counterparty"]; ok {
-			b.WriteString(fmt.Sprintf("<b>Counterparty:</b> <code>%s</code>\n", h(fmt.Sprint(cp))))
+			addCode("Counterparty", cp)
 		}
 
 	default:
-		b.WriteString("🔐 <b>Permission Request</b>\n\n")
-		b.WriteString(fmt.Sprintf("<b>App:</b> <code>%s</code>\n", h(req.App)))
-		b.WriteString(fmt.Sprintf("<b>Type:</b> %s\n", h(req.Type)))
+		p.Icon, p.Title = "🔐", "Permission Request"
+		addCode("App", req.App)
+		add("Type", req.Type)
 	}
 
 	if req.Message != "" && req.Type != "spend" && req.Type != "protocol" {
-		b.WriteString(fmt.Sprintf("<b>Details:</b> %s\n", h(req.Message)))
+		add("Details", req.Message)
+	}
+	if req.RequiredApprovals > 1 {
+		add("Approvals needed", req.RequiredApprovals)
+	}
+	if req.Timestamp > 0 {
+		add("Expires", fmt.Sprintf("in %ds", int(expiresIn(req, time.Now()).Seconds())))
+	}
+	return p
+}
+
+// expiresIn returns how long req has left before permissionTimeout, measured
+// from its Timestamp.
+func expiresIn(req PermissionRequest, now time.Time) time.Duration {
+	age := now.Sub(time.Unix(req.Timestamp, 0))
+	if age < 0 {
+		age = 0
+	}
+	if age >= permissionTimeout {
+		return 0
+	}
+	return (permissionTimeout - age).Truncate(time.Second)
+}
+
+// quorumStatus describes how many more approvals a request needs.
+func quorumStatus(remaining int) string {
+	if remaining == 1 {
+		return "⏳ Approved — 1 more approval needed"
+	}
+	return fmt.Sprintf("⏳ Approved — %d more approvals needed", remaining)
+}
+
+// formatPrompt renders the prompt for req as Telegram HTML.
+func formatPrompt(req PermissionRequest) string {
+	p := buildPrompt(req)
+	var b strings.Builder
+	if p.Label != "" {
+		b.WriteString("[" + h(p.Label) + "] ")
+	}
+	b.WriteString(fmt.Sprintf("%s <b>%s</b>\n\n", p.Icon, h(p.Title)))
+	for _, f := range p.Fields {
+		value := h(f.Value)
+		if f.Code {
+			value = "<code>" + value + "</code>"
+		}
+		if f.Bullet {
+			b.WriteString(fmt.Sprintf("• %s: %s\n", h(f.Label), value))
+		} else {
+			b.WriteString(fmt.Sprintf("<b>%s:</b> %s\n", h(f.Label), value))
+		}
+	}
+	return b.String()
+}
+
+// formatPlainPrompt renders the prompt for req without any markup.
+func formatPlainPrompt(req PermissionRequest) string {
+	p := buildPrompt(req)
+	var b strings.Builder
+	if p.Label != "" {
+		b.WriteString("[" + p.Label + "] ")
+	}
+	b.WriteString(fmt.Sprintf("%s %s\n\n", p.Icon, p.Title))
+	for _, f := range p.Fields {
+		if f.Bullet {
+			b.WriteString(fmt.Sprintf("• %s: %s\n", f.Label, f.Value))
+		} else {
+			b.WriteString(fmt.Sprintf("%s: %s\n", f.Label, f.Value))
+		}
 	}
 	return b.String()
 }
@@ -317,126 +900,129 @@ func h(s string) string {
 	return s
 }
 
-func (bs *BridgeServer) resolve(id string, approved bool, reason string) {
-	bs.mu.Lock()
-	entry, ok := bs.pending[id]
-	bs.mu.Unlock()
-	if ok {
-		entry.ch <- PermissionResponse{ID: id, Approved: approved, Reason: reason}
+// applyQuorum sets RequiredApprovals on high-value spends that don't carry
+// their own.
+func (bs *BridgeServer) applyQuorum(req *PermissionRequest) {
+	if req.RequiredApprovals == 0 && bs.quorumApprovals > 1 &&
+		req.Type == "spend" && req.Amount >= bs.quorumOver {
+		req.RequiredApprovals = bs.quorumApprovals
 	}
 }
 
-// ---------------------------------------------------------------------------
-// Telegram: long-poll for callback_query (button clicks)
-// ---------------------------------------------------------------------------
-
-func (bs *BridgeServer) pollTelegramUpdates() {
-	offset := 0
-	baseURL := fmt.Sprintf("https://api.telegram.org/bot%s", bs.telegramToken)
-
-	for {
-		select {
-		case <-bs.stopCh:
-			return
-		default:
+// resolve delivers resp to the handler waiting on the matching request and
+// reports whether one was waiting. The first decision claims the request;
+// later ones for the same ID are ignored. A request needing several
+// approvals is only claimed by the last of them, or by any denial; until
+// then resolve records the approval and returns how many are still needed.
+func (bs *BridgeServer) resolve(resp PermissionResponse, source string) (remaining int, ok bool) {
+	bs.mu.Lock()
+	entry, ok := bs.pending[resp.ID]
+	if !ok {
+		bs.mu.Unlock()
+		return 0, false
+	}
+	resp.Approvers = nil
+	if resp.Approved && entry.approvers != nil {
+		if !quorumApprover(source, resp.Approver) {
+			remaining = entry.request.RequiredApprovals - len(entry.approvers)
+			bs.mu.Unlock()
+			bs.logger.Warn("Approval not counted toward quorum", "id", resp.ID,
+				"source", source, "approver", resp.Approver, "remaining", remaining)
+			return remaining, true
 		}
-
-		payload := map[string]interface{}{
-			"offset":          offset,
-			"timeout":         30,
-			"allowed_updates": []string{"callback_query"},
+		entry.approvers[source+":"+resp.Approver] = true
+		if remaining = entry.request.RequiredApprovals - len(entry.approvers); remaining > 0 {
+			bs.mu.Unlock()
+			bs.logger.Info("Approval recorded, awaiting quorum", "id", resp.ID,
+				"source", source, "approver", resp.Approver, "remaining", remaining)
+			return remaining, true
 		}
-		body, _ := json.Marshal(payload)
-		resp, err := http.Post(baseURL+"/getUpdates", "application/json", bytes.NewBuffer(body))
-		if err != nil {
-			bs.logger.Error("Telegram poll error", "error", err)
-			time.Sleep(5 * time.Second)
-			continue
+		for approver := range entry.approvers {
+			resp.Approvers = append(resp.Approvers, approver)
 		}
+		sort.Strings(resp.Approvers)
+	}
+	delete(bs.pending, resp.ID)
+	bs.mu.Unlock()
+	bs.forgetPrompt(resp.ID)
+	bs.recent.put(resp)
+	if resp.Remember && resp.Approved && bs.rememberFor > 0 && remembersDecision(entry.request.Type) {
+		bs.remembered.put(entry.request, bs.rememberFor)
+	}
+	entry.ch <- resp
+	bs.metrics.decided(entry.request.Type, resp.Approved)
+	bs.metrics.observeDecision(time.Since(entry.created))
+	bs.recordAudit(source, entry.request, resp)
+	return 0, true
+}
 
-		var result struct {
-			OK     bool `json:"ok"`
-			Result []struct {
-				UpdateID      int `json:"update_id"`
-				CallbackQuery *struct {
-					ID      string `json:"id"`
-					Data    string `json:"data"`
-					Message *struct {
-						MessageID int `json:"message_id"`
-						Chat      struct {
-							ID int64 `json:"id"`
-						} `json:"chat"`
-						Text string `json:"text"`
-					} `json:"message"`
-				} `json:"callback_query"`
-			} `json:"result"`
-		}
-		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-			resp.Body.Close()
-			time.Sleep(2 * time.Second)
-			continue
-		}
-		resp.Body.Close()
+// needsQuorum reports whether the pending request id needs approvals from
+// several approvers.
+func (bs *BridgeServer) needsQuorum(id string) bool {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	entry, ok := bs.pending[id]
+	return ok && entry.approvers != nil
+}
 
-		for _, u := range result.Result {
-			offset = u.UpdateID + 1
-			cq := u.CallbackQuery
-			if cq == nil || cq.Data == "" {
-				continue
-			}
+// quorumApprover reports whether an approval from approver on source
+// counts toward RequiredApprovals. Only chat sources identify their users;
+// anyone holding the bridge token can call /respond under any name.
+func quorumApprover(source, approver string) bool {
+	return (source == SourceTelegram || source == SourceSlack) && approver != ""
+}
 
-			parts := strings.SplitN(cq.Data, ":", 2)
-			if len(parts) != 2 {
-				continue
-			}
-			action, reqID := parts[0], parts[1]
-			approved := action == "approve"
-
-			bs.logger.Info("Telegram callback", "action", action, "reqID", reqID)
-			bs.resolve(reqID, approved, "user via telegram")
-			bs.answerCallback(baseURL, cq.ID, approved)
-
-			if cq.Message != nil {
-				resultLabel := "✅ Approved"
-				if !approved {
-					resultLabel = "❌ Denied"
-				}
-				bs.editMessage(baseURL, cq.Message.Chat.ID, cq.Message.MessageID,
-					cq.Message.Text+"\n\n"+resultLabel)
-			}
-		}
-	}
+// callbackAction is the decision encoded in a prompt button.
+type callbackAction struct {
+	ReqID    string
+	Approved bool
+	Duration string
+	Remember bool
 }
 
-func (bs *BridgeServer) answerCallback(baseURL, callbackID string, approved bool) {
-	text := "✅ Approved"
-	if !approved {
-		text = "❌ Denied"
+// parseCallbackData decodes button data of the form "approve:<id>",
+// "approve_<duration>:<id>", "remember:<id>" or "deny:<id>".
+func parseCallbackData(data string) (callbackAction, bool) {
+	action, reqID, found := strings.Cut(data, ":")
+	if !found || reqID == "" {
+		return callbackAction{}, false
 	}
-	payload, _ := json.Marshal(map[string]interface{}{
-		"callback_query_id": callbackID,
-		"text":              text,
-	})
-	http.Post(baseURL+"/answerCallbackQuery", "application/json", bytes.NewBuffer(payload))
+	action, duration, _ := strings.Cut(action, "_")
+	switch action {
+	case "approve":
+		return callbackAction{ReqID: reqID, Approved: true, Duration: duration}, true
+	case "remember":
+		return callbackAction{ReqID: reqID, Approved: true, Remember: true}, true
+	case "deny":
+		return callbackAction{ReqID: reqID}, true
+	}
+	return callbackAction{}, false
 }
 
-func (bs *BridgeServer) editMessage(baseURL string, chatID int64, messageID int, newText string) {
-	payload, _ := json.Marshal(map[string]interface{}{
-		"chat_id":    chatID,
-		"message_id": messageID,
-		"text":       newText,
-	})
-	http.Post(baseURL+"/editMessageText", "application/json", bytes.NewBuffer(payload))
+// response converts the button decision into the reply for the wallet;
+// message says where the user answered.
+func (a callbackAction) response(message string) PermissionResponse {
+	return PermissionResponse{
+		ID:       a.ReqID,
+		Approved: a.Approved,
+		Reason:   userReason(a.Approved),
+		Message:  message,
+		Duration: a.Duration,
+		Remember: a.Remember,
+	}
 }
 
 // ---------------------------------------------------------------------------
-// Config: read from ~/.gebunden/bridge-config.json or env
+// Config: read from env, ~/.gebunden/bridge-config.json or the OpenClaw config
 // ---------------------------------------------------------------------------
 
+// readBridgeConfig returns the Telegram bot token and chat ID. Each is taken
+// from the first source that sets it: the environment, then
+// ~/.gebunden/bridge-config.json, then ~/.openclaw/openclaw.json.
 func readBridgeConfig() (token, chatID string) {
 	token = os.Getenv("GEBUNDEN_BOT_TOKEN")
 	chatID = os.Getenv("GEBUNDEN_CHAT_ID")
-	if token != "" {
+	if token != "" && chatID != "" {
 		return
 	}
 
@@ -444,23 +1030,114 @@ func readBridgeConfig() (token, chatID string) {
 	if err != nil {
 		return
 	}
-	data, err := os.ReadFile(filepath.Join(home, ".gebunden", "bridge-config.json"))
+	fileToken, fileChat := readGebundenConfig(filepath.Join(home, ".gebunden", "bridge-config.json"))
+	clawToken, clawChat := readOpenClawConfig(filepath.Join(home, ".openclaw", "openclaw.json"))
+	token = firstNonEmpty(token, fileToken, clawToken)
+	chatID = firstNonEmpty(chatID, fileChat, clawChat)
+	return
+}
+
+func readGebundenConfig(path string) (token, chatID string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var cfg struct {
+		TelegramBotToken string     `json:"telegramBotToken"`
+		TelegramChatID   flexString `json:"telegramChatID"`
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return
+	}
+	return cfg.TelegramBotToken, string(cfg.TelegramChatID)
+}
+
+// readOpenClawConfig reads channels.telegram.botToken and chatId from an
+// OpenClaw config file.
+func readOpenClawConfig(path string) (token, chatID string) {
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return
 	}
 	var cfg struct {
-		TelegramBotToken string `json:"telegramBotToken"`
-		TelegramChatID   string `json:"telegramChatID"`
+		Channels struct {
+			Telegram struct {
+				BotToken string     `json:"botToken"`
+				ChatID   flexString `json:"chatId"`
+			} `json:"telegram"`
+		} `json:"channels"`
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return
+	}
+	return cfg.Channels.Telegram.BotToken, string(cfg.Channels.Telegram.ChatID)
+}
+
+// flexString accepts a JSON string or number, since chat IDs appear as both.
+type flexString string
+
+func (f *flexString) UnmarshalJSON(data []byte) error {
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err == nil {
+		*f = flexString(n.String())
+		return nil
 	}
-	if err := json.Unmarshal(data, &cfg); err == nil {
-		if token == "" {
-			token = cfg.TelegramBotToken
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*f = flexString(s)
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
 		}
-		if chatID == "" {
-			chatID = cfg.TelegramChatID
+	}
+	return ""
+}
+
+// splitList splits a comma-separated flag value, dropping empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
 		}
 	}
-	return
+	return items
+}
+
+// newLogger builds the bridge logger writing to w in the given format ("text"
+// or "json") at the given minimum level ("debug", "info", "warn", "error").
+func newLogger(w io.Writer, format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid -log-level %q: %w", level, err)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("unknown -log-format %q (want text or json)", format)
+}
+
+// bridgeStateDir returns dir, or ~/.gebunden when dir is empty. It returns ""
+// if no home directory can be determined.
+func bridgeStateDir(dir string) string {
+	if dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".gebunden")
 }
 
 // ---------------------------------------------------------------------------
@@ -468,11 +1145,48 @@ func readBridgeConfig() (token, chatID string) {
 // ---------------------------------------------------------------------------
 
 func main() {
+	listen := flag.String("listen", "127.0.0.1", "Host to bind the bridge server to (0.0.0.0 exposes it to the network)")
 	bridgePort := flag.Int("port", 18790, "Bridge server port")
 	flagToken := flag.String("telegram-token", "", "Gebunden Telegram Bot Token (overrides config)")
 	flagChat := flag.String("telegram-chat", "", "Telegram chat ID for prompts (overrides config)")
+	flagSecret := flag.String("secret", "", "Bearer secret required by /respond and /pending (overrides GEBUNDEN_BRIDGE_SECRET)")
+	autoApproveUnder := flag.Int64("auto-approve-under", 0, "Auto-approve spend requests below this many sats (0 disables)")
+	notifierKind := flag.String("notifier", "telegram", "Prompt channel: telegram or slack")
+	flagSlackWebhook := flag.String("slack-webhook", "", "Slack incoming webhook URL (overrides GEBUNDEN_SLACK_WEBHOOK)")
+	flagSlackSecret := flag.String("slack-signing-secret", "", "Slack app signing secret (overrides GEBUNDEN_SLACK_SIGNING_SECRET)")
+	auditPath := flag.String("audit-log", "", "Append every permission decision to this JSONL file")
+	auditFsync := flag.Bool("audit-fsync", false, "fsync the audit log after every record")
+	auditSyslog := flag.String("audit-syslog", "", "Send every permission decision to this RFC 5424 syslog collector, as udp://host:port or tcp://host:port")
+	sendAttempts := flag.Int("send-attempts", defaultSendAttempts, "Attempts to deliver each prompt before giving up")
+	undeliverable := flag.String("undeliverable", UndeliverableDeny, "What to do with undeliverable prompts: deny or queue")
+	fiatCurrency := flag.String("fiat", "USD", "Currency for approximate spend values")
+	fiatRateURL := flag.String("fiat-rate-url", "", "Exchange rate source for -fiat, with {currency} placeholder (empty disables)")
+	label := flag.String("label", "", "Wallet label prefixed to every prompt, e.g. Treasury")
+	templatesPath := flag.String("templates", "", "JSON file of per-type Telegram prompt templates (text/template)")
+	rememberFor := flag.Duration("remember-for", 0, "Offer \"Always allow\" and remember such approvals per app for this long (0 disables)")
+	telegramUpdates := flag.String("telegram-updates", strings.Join(defaultAllowedUpdates, ","), "Comma-separated Telegram update types to poll for; add message to accept yes/no replies")
+	telegramMinPoll := flag.Duration("telegram-min-poll-interval", defaultMinPollInterval, "Least time between two Telegram getUpdates calls")
+	tlsCert := flag.String("tls-cert", "", "TLS certificate file; serves HTTPS together with -tls-key")
+	tlsKey := flag.String("tls-key", "", "TLS private key file; serves HTTPS together with -tls-cert")
+	quorum := flag.Int("quorum", 0, "Distinct approvals required for spends of at least -quorum-over sats (0 or 1 disables)")
+	quorumOver := flag.Int64("quorum-over", 0, "Spend amount in sats from which -quorum applies")
+	rateLimit := flag.Int("rate-limit", defaultRateLimit, "Prompts one app may trigger per -rate-window before getting 429 (0 disables)")
+	rateWindow := flag.Duration("rate-window", defaultRateWindow, "Window for -rate-limit")
+	grantsFile := flag.String("grants-file", "", "Persist remembered approvals in this JSON file (share it with the wallet's -grants-file)")
+	attestKey := flag.String("attestation-key", "", "Sign every decision with the Ed25519 seed in this file, creating it if missing")
+	logFormat := flag.String("log-format", "text", "Log output format: text or json")
+	logLevel := flag.String("log-level", "info", "Minimum log level: debug, info, warn or error")
+	stateDir := flag.String("state-dir", "", "Directory for bridge state such as the Telegram poll offset (default ~/.gebunden)")
 	flag.Parse()
 
+	logger, err := newLogger(os.Stdout, *logFormat, *logLevel)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	// Route the standard log package (used for fatal startup errors) through
+	// the same handler.
+	slog.SetDefault(logger)
+
 	configToken, configChat := readBridgeConfig()
 	token := *flagToken
 	if token == "" {
@@ -483,7 +1197,132 @@ func main() {
 		chat = configChat
 	}
 
-	bridge := NewBridgeServer(*bridgePort, token, chat)
+	secret := *flagSecret
+	if secret == "" {
+		secret = os.Getenv("GEBUNDEN_BRIDGE_SECRET")
+	}
+
+	var templates PromptTemplates
+	if *templatesPath != "" {
+		var err error
+		templates, err = LoadPromptTemplates(*templatesPath)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+
+	var notifier Notifier
+	switch *notifierKind {
+	case "telegram":
+		if token != "" {
+			tn := NewTelegramNotifier(logger, token, chat)
+			tn.RememberFor = *rememberFor
+			tn.Templates = templates
+			tn.AllowedUpdates = splitList(*telegramUpdates)
+			if *telegramMinPoll <= 0 {
+				log.Fatalf("Bad -telegram-min-poll-interval %v: must be positive", *telegramMinPoll)
+			}
+			tn.MinPollInterval = *telegramMinPoll
+			if dir := bridgeStateDir(*stateDir); dir != "" {
+				tn.OffsetFile = filepath.Join(dir, "telegram-offset")
+			}
+			notifier = tn
+		}
+	case "slack":
+		webhook := *flagSlackWebhook
+		if webhook == "" {
+			webhook = os.Getenv("GEBUNDEN_SLACK_WEBHOOK")
+		}
+		signingSecret := *flagSlackSecret
+		if signingSecret == "" {
+			signingSecret = os.Getenv("GEBUNDEN_SLACK_SIGNING_SECRET")
+		}
+		if webhook == "" || signingSecret == "" {
+			log.Fatalf("Slack notifier requires -slack-webhook and -slack-signing-secret")
+		}
+		sn := NewSlackNotifier(logger, webhook, signingSecret)
+		sn.RememberFor = *rememberFor
+		notifier = sn
+	default:
+		log.Fatalf("Unknown notifier %q (want telegram or slack)", *notifierKind)
+	}
+
+	if err := validateTLSFiles(*tlsCert, *tlsKey); err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	if *undeliverable != UndeliverableDeny && *undeliverable != UndeliverableQueue {
+		log.Fatalf("Unknown -undeliverable policy %q (want deny or queue)", *undeliverable)
+	}
+
+	var sinks AuditSinks
+	if *auditPath != "" {
+		auditLog, err := OpenAuditLog(*auditPath, *auditFsync)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		sinks = append(sinks, auditLog)
+	}
+	if *auditSyslog != "" {
+		syslog, err := OpenSyslogSink(*auditSyslog)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		sinks = append(sinks, syslog)
+	}
+	var audit AuditSink
+	if len(sinks) > 0 {
+		audit = sinks
+		defer sinks.Close()
+	}
+
+	var fiat *FiatRates
+	if *fiatRateURL != "" {
+		fiat = NewFiatRates(logger, *fiatRateURL, *fiatCurrency)
+	}
+
+	var grants GrantStore
+	if *grantsFile != "" {
+		grants = NewFileGrantStore(*grantsFile)
+	}
+
+	var attester *Attester
+	if *attestKey != "" {
+		var err error
+		attester, err = LoadAttester(*attestKey)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		logger.Info("Signing decisions", "publicKey", attester.PublicKey())
+	}
+
+	bridge := NewBridgeServer(BridgeConfig{
+		Listen:           *listen,
+		Port:             *bridgePort,
+		TLSCert:          *tlsCert,
+		TLSKey:           *tlsKey,
+		Logger:           logger,
+		Notifier:         notifier,
+		Secret:           secret,
+		AutoApproveUnder: *autoApproveUnder,
+		Audit:            audit,
+		SendAttempts:     *sendAttempts,
+		Undeliverable:    *undeliverable,
+		RememberFor:      *rememberFor,
+		Grants:           grants,
+		RateLimit:        *rateLimit,
+		RateWindow:       *rateWindow,
+		Attester:         attester,
+		Fiat:             fiat,
+		Label:            *label,
+		QuorumApprovals:  *quorum,
+		QuorumOver:       *quorumOver,
+	})
+
+	if ip := net.ParseIP(*listen); (ip == nil || !ip.IsLoopback()) && *listen != "localhost" && secret == "" {
+		logger.Warn("Bridge is reachable beyond loopback without -secret; anyone who can connect can approve requests",
+			"listen", *listen)
+	}
 
 	go func() {
 		if err := bridge.Start(); err != nil {
@@ -492,8 +1331,15 @@ func main() {
 	}()
 
 	bridge.logger.Info("Gebunden Bridge started",
+		"listen", *listen,
 		"port", *bridgePort,
-		"telegram", token != "",
+		"tls", *tlsCert != "",
+		"notifier", *notifierKind,
+		"prompts", notifier != nil,
+		"secret", secret != "",
+		"autoApproveUnder", *autoApproveUnder,
+		"auditLog", *auditPath,
+		"auditSyslog", *auditSyslog,
 	)
 
 	sigCh := make(chan os.Signal, 1)
@@ -0,0 +1,1413 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestBridge returns a BridgeServer with Telegram disabled and logging discarded.
+func newTestBridge() *BridgeServer {
+	bs := NewBridgeServer(BridgeConfig{})
+	bs.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	return bs
+}
+
+// stubTelegram returns a server that accepts every Bot API call with {"ok":true}.
+func stubTelegram(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// submitRequest posts req to handlePermissionRequest in the background and
+// returns a channel delivering the recorded response once the handler returns.
+func submitRequest(t *testing.T, bs *BridgeServer, req PermissionRequest) <-chan *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(req)
+	done := make(chan *httptest.ResponseRecorder, 1)
+	go func() {
+		rec := httptest.NewRecorder()
+		bs.handlePermissionRequest(rec, httptest.NewRequest(http.MethodPost, "/request-permission", bytes.NewReader(body)))
+		done <- rec
+	}()
+	waitPending(t, bs, req.ID)
+	return done
+}
+
+func waitPending(t *testing.T, bs *BridgeServer, id string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		bs.mu.Lock()
+		_, ok := bs.pending[id]
+		bs.mu.Unlock()
+		if ok {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("request %s never became pending", id)
+}
+
+func awaitResponse(t *testing.T, done <-chan *httptest.ResponseRecorder) *httptest.ResponseRecorder {
+	t.Helper()
+	select {
+	case rec := <-done:
+		return rec
+	case <-time.After(2 * time.Second):
+		t.Fatal("handler did not return")
+		return nil
+	}
+}
+
+func TestCallbackGrantDuration(t *testing.T) {
+	bs := newTestBridge()
+	tn := NewTelegramNotifier(bs.logger, "token", "chat")
+	tn.apiURL = stubTelegram(t).URL
+
+	done := submitRequest(t, bs, PermissionRequest{
+		ID:        "proto-1",
+		Type:      "protocol",
+		App:       "example.com",
+		ExtraData: map[string]interface{}{"protocolID": "todo list"},
+	})
+
+	tn.handleCallback(bs.resolve, &telegramCallbackQuery{ID: "cb-1", Data: "approve_1h:proto-1"})
+
+	rec := awaitResponse(t, done)
+	var resp PermissionResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if !resp.Approved {
+		t.Fatal("expected approval")
+	}
+	if resp.Duration != Grant1h {
+		t.Fatalf("duration = %q, want %q", resp.Duration, Grant1h)
+	}
+}
+
+// slackActionRequest builds a Slack interaction request pressing the button
+// with value as user, signed with secret at ts.
+func slackActionRequest(secret string, ts time.Time, user, value, responseURL string) *http.Request {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"type":         "block_actions",
+		"user":         map[string]string{"id": user},
+		"actions":      []map[string]string{{"action_id": value, "value": value}},
+		"response_url": responseURL,
+		"message":      map[string]string{"text": "prompt"},
+	})
+	body := url.Values{"payload": {string(payload)}}.Encode()
+	stamp := strconv.FormatInt(ts.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:%s", stamp, body)
+	r := httptest.NewRequest(http.MethodPost, slackActionsPath, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("X-Slack-Request-Timestamp", stamp)
+	r.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return r
+}
+
+func TestSlackActionSignature(t *testing.T) {
+	sn := NewSlackNotifier(newTestBridge().logger, "", "signing-secret")
+	var decisions []PermissionResponse
+	decide := func(resp PermissionResponse, _ string) (int, bool) {
+		decisions = append(decisions, resp)
+		return 0, true
+	}
+	now := time.Now()
+
+	forged := slackActionRequest("signing-secret", now, "U1", "approve:spend-1", "")
+	forged.Header.Set("X-Slack-Signature", "v0="+strings.Repeat("0", 64))
+	tampered := slackActionRequest("signing-secret", now, "U1", "deny:spend-1", "")
+	tampered.Body = io.NopCloser(strings.NewReader(url.Values{"payload": {`{"type":"block_actions","actions":[{"value":"approve:spend-1"}]}`}}.Encode()))
+
+	cases := []struct {
+		name string
+		req  *http.Request
+		want int
+	}{
+		{"wrong secret", slackActionRequest("other-secret", now, "U1", "approve:spend-1", ""), http.StatusUnauthorized},
+		{"forged signature", forged, http.StatusUnauthorized},
+		{"tampered body", tampered, http.StatusUnauthorized},
+		{"replayed", slackActionRequest("signing-secret", now.Add(-10*time.Minute), "U1", "approve:spend-1", ""), http.StatusUnauthorized},
+		{"signed", slackActionRequest("signing-secret", now, "U1", "approve:spend-1", ""), http.StatusOK},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		sn.handleAction(decide, rec, c.req)
+		if rec.Code != c.want {
+			t.Errorf("%s: status %d, want %d", c.name, rec.Code, c.want)
+		}
+	}
+	if len(decisions) != 1 || !decisions[0].Approved || decisions[0].Approver != "U1" {
+		t.Fatalf("decisions = %+v, want only the signed approval by U1", decisions)
+	}
+
+	// Without a signing secret nothing is trusted.
+	unsigned := NewSlackNotifier(newTestBridge().logger, "", "")
+	rec := httptest.NewRecorder()
+	unsigned.handleAction(decide, rec, slackActionRequest("", now, "U1", "approve:spend-1", ""))
+	if rec.Code != http.StatusUnauthorized || len(decisions) != 1 {
+		t.Fatalf("unconfigured secret: status %d after %d decisions, want 401 and none", rec.Code, len(decisions))
+	}
+}
+
+func TestSlackButtonDecisions(t *testing.T) {
+	var mu sync.Mutex
+	var replaced []string
+	responses := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Text string `json:"text"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		replaced = append(replaced, payload.Text)
+		mu.Unlock()
+	}))
+	defer responses.Close()
+
+	sn := NewSlackNotifier(newTestBridge().logger, "", "signing-secret")
+	sn.RememberFor = time.Hour
+	cases := []struct {
+		value    string
+		approved bool
+		duration string
+		remember bool
+		label    string
+	}{
+		{"approve:req-1", true, "", false, "Approved"},
+		{"approve_24h:req-1", true, Grant24h, false, "24 hours"},
+		{"remember:req-1", true, "", true, "Always allowed for 1h"},
+		{"deny:req-1", false, "", false, "Denied"},
+	}
+	for _, c := range cases {
+		var got []PermissionResponse
+		decide := func(resp PermissionResponse, source string) (int, bool) {
+			if source != SourceSlack {
+				t.Errorf("%s: decision from source %q, want %q", c.value, source, SourceSlack)
+			}
+			got = append(got, resp)
+			return 0, true
+		}
+		rec := httptest.NewRecorder()
+		sn.handleAction(decide, rec, slackActionRequest("signing-secret", time.Now(), "U7", c.value, responses.URL))
+		if len(got) != 1 {
+			t.Fatalf("%s: %d decisions, want 1", c.value, len(got))
+		}
+		resp := got[0]
+		if resp.ID != "req-1" || resp.Approved != c.approved || resp.Duration != c.duration || resp.Remember != c.remember || resp.Approver != "U7" {
+			t.Errorf("%s: decision %+v, want approved=%v duration=%q remember=%v by U7", c.value, resp, c.approved, c.duration, c.remember)
+		}
+		mu.Lock()
+		last := replaced[len(replaced)-1]
+		mu.Unlock()
+		if !strings.HasPrefix(last, "prompt") || !strings.Contains(last, c.label) {
+			t.Errorf("%s: prompt replaced with %q, want it labelled %q", c.value, last, c.label)
+		}
+	}
+
+	// Unknown buttons decide nothing.
+	rec := httptest.NewRecorder()
+	sn.handleAction(func(PermissionResponse, string) (int, bool) {
+		t.Error("an unknown button made a decision")
+		return 0, false
+	}, rec, slackActionRequest("signing-secret", time.Now(), "U7", "launch:req-1", ""))
+}
+
+func TestGroupCountMismatchRejected(t *testing.T) {
+	bs := newTestBridge()
+	body, _ := json.Marshal(PermissionRequest{
+		ID:   "group-1",
+		Type: "group",
+		App:  "example.com",
+		ExtraData: map[string]interface{}{
+			"protocolCount": 3,
+			"protocols":     []interface{}{"todo list"},
+		},
+	})
+
+	rec := httptest.NewRecorder()
+	bs.handlePermissionRequest(rec, httptest.NewRequest(http.MethodPost, "/request-permission", bytes.NewReader(body)))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	if len(bs.pending) != 0 {
+		t.Fatal("rejected request should not be pending")
+	}
+}
+
+func TestTelegramParseErrorFallsBackToPlainText(t *testing.T) {
+	var calls []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		calls = append(calls, payload)
+		if _, html := payload["parse_mode"]; html {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"ok":false,"error_code":400,"description":"Bad Request: can't parse entities: unsupported start tag"}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	tn := NewTelegramNotifier(newTestBridge().logger, "token", "chat")
+	tn.apiURL = srv.URL
+
+	err := tn.Send(PermissionRequest{ID: "spend-1", Type: "spend", App: "<b>broken", Amount: 50})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("sendMessage calls = %d, want 2", len(calls))
+	}
+	if text, _ := calls[1]["text"].(string); strings.Contains(text, "<code>") {
+		t.Fatalf("plain retry still contains markup: %q", text)
+	}
+}
+
+// failingNotifier fails every Send and counts the attempts.
+type failingNotifier struct {
+	mu       sync.Mutex
+	attempts int
+}
+
+func (f *failingNotifier) Send(PermissionRequest) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempts++
+	return errors.New("telegram down")
+}
+
+func (f *failingNotifier) Start(DecisionFunc, *http.ServeMux, <-chan struct{}) {}
+
+func (f *failingNotifier) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.attempts
+}
+
+func TestUndeliverablePromptDenied(t *testing.T) {
+	notifier := &failingNotifier{}
+	bs := newTestBridge()
+	bs.notifier = notifier
+	bs.sendRetryDelay = time.Millisecond
+
+	body, _ := json.Marshal(PermissionRequest{ID: "spend-1", Type: "spend", App: "example.com", Amount: 500})
+	rec := httptest.NewRecorder()
+	bs.handlePermissionRequest(rec, httptest.NewRequest(http.MethodPost, "/request-permission", bytes.NewReader(body)))
+
+	var resp PermissionResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Approved {
+		t.Fatal("undeliverable request should be denied")
+	}
+	if got := notifier.count(); got != defaultSendAttempts {
+		t.Fatalf("send attempts = %d, want %d", got, defaultSendAttempts)
+	}
+}
+
+func TestUndeliverablePromptQueued(t *testing.T) {
+	notifier := &failingNotifier{}
+	bs := newTestBridge()
+	bs.notifier = notifier
+	bs.sendRetryDelay = time.Millisecond
+	bs.undeliverable = UndeliverableQueue
+
+	done := submitRequest(t, bs, PermissionRequest{ID: "spend-2", Type: "spend", App: "example.com", Amount: 500})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		bs.mu.Lock()
+		_, queued := bs.deadLetters["spend-2"]
+		bs.mu.Unlock()
+		if queued {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("request was not queued as a dead letter")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !bs.isPending("spend-2") {
+		t.Fatal("queued request should stay pending")
+	}
+
+	bs.resolve(PermissionResponse{ID: "spend-2", Approved: true}, SourceRespond)
+	if rec := awaitResponse(t, done); rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestCustomSpendTemplate(t *testing.T) {
+	templates, err := ParsePromptTemplates(map[string]string{
+		"spend": `{{esc .App}} wants {{.Amount}} sats for {{esc .Extra.description}}`,
+	})
+	if err != nil {
+		t.Fatalf("ParsePromptTemplates: %v", err)
+	}
+
+	text, err := templates.render(PermissionRequest{
+		Type:      "spend",
+		App:       "<shop>",
+		Amount:    500,
+		ExtraData: map[string]interface{}{"description": "coffee & cake"},
+	})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if want := "&lt;shop&gt; wants 500 sats for coffee &amp; cake"; text != want {
+		t.Fatalf("text = %q, want %q", text, want)
+	}
+
+	if _, err := ParsePromptTemplates(map[string]string{"spend": "{{.App"}); err == nil {
+		t.Fatal("expected parse error for malformed template")
+	}
+}
+
+func TestPauseAndResume(t *testing.T) {
+	bs := newTestBridge()
+	post := func(path string, handler http.HandlerFunc, body []byte) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body)))
+		return rec
+	}
+
+	// A request already in flight when the bridge is paused still completes.
+	inFlight := submitRequest(t, bs, PermissionRequest{ID: "in-flight", Type: "protocol", App: "example.com"})
+
+	if rec := post("/pause", bs.handlePause, nil); rec.Code != http.StatusOK {
+		t.Fatalf("pause status = %d", rec.Code)
+	}
+	body, _ := json.Marshal(PermissionRequest{ID: "new-1", Type: "protocol", App: "example.com"})
+	if rec := post("/request-permission", bs.handlePermissionRequest, body); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("paused request status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	bs.resolve(PermissionResponse{ID: "in-flight", Approved: true}, SourceRespond)
+	if rec := awaitResponse(t, inFlight); rec.Code != http.StatusOK {
+		t.Fatalf("in-flight status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	if rec := post("/resume", bs.handleResume, nil); rec.Code != http.StatusOK {
+		t.Fatalf("resume status = %d", rec.Code)
+	}
+	resumed := submitRequest(t, bs, PermissionRequest{ID: "new-2", Type: "protocol", App: "example.com"})
+	bs.resolve(PermissionResponse{ID: "new-2", Approved: true}, SourceRespond)
+	if rec := awaitResponse(t, resumed); rec.Code != http.StatusOK {
+		t.Fatalf("resumed request status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+// recordingNotifier captures every request it is asked to send.
+type recordingNotifier struct {
+	mu   sync.Mutex
+	sent []PermissionRequest
+}
+
+func (n *recordingNotifier) Send(req PermissionRequest) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sent = append(n.sent, req)
+	return nil
+}
+
+func (n *recordingNotifier) Start(DecisionFunc, *http.ServeMux, <-chan struct{}) {}
+
+func TestLabelPrefixesPrompt(t *testing.T) {
+	notifier := &recordingNotifier{}
+	bs := newTestBridge()
+	bs.notifier = notifier
+	bs.label = "Treasury & Ops"
+
+	bs.notify(PermissionRequest{ID: "spend-1", Type: "spend", App: "example.com", Amount: 500})
+
+	if len(notifier.sent) != 1 {
+		t.Fatalf("sent %d prompts, want 1", len(notifier.sent))
+	}
+	text := formatPrompt(notifier.sent[0])
+	if want := "[Treasury &amp; Ops] 💸 <b>Spending Authorization</b>"; !strings.HasPrefix(text, want) {
+		t.Fatalf("prompt %q does not start with %q", text, want)
+	}
+}
+
+func TestTelegramSendRetriesTransientFailures(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) <= 2 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	bs := newTestBridge()
+	tn := NewTelegramNotifier(bs.logger, "token", "chat")
+	tn.apiURL = srv.URL
+	bs.notifier = tn
+	bs.sendRetryDelay = time.Millisecond
+
+	done := submitRequest(t, bs, PermissionRequest{ID: "spend-1", Type: "spend", App: "example.com", Amount: 500})
+	deadline := time.Now().Add(2 * time.Second)
+	for calls.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("sendMessage calls = %d, want 3", n)
+	}
+	if !bs.isPending("spend-1") {
+		t.Fatal("request should still be pending after a successful retry")
+	}
+
+	bs.resolve(PermissionResponse{ID: "spend-1", Approved: true}, SourceRespond)
+	rec := awaitResponse(t, done)
+	var resp PermissionResponse
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if !resp.Approved {
+		t.Fatalf("expected approval, got %+v", resp)
+	}
+}
+
+func TestTelegramSendClientErrorIsPermanent(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"ok":false,"description":"Unauthorized"}`))
+	}))
+	defer srv.Close()
+
+	bs := newTestBridge()
+	tn := NewTelegramNotifier(bs.logger, "bad-token", "chat")
+	tn.apiURL = srv.URL
+	bs.notifier = tn
+	bs.sendRetryDelay = time.Millisecond
+
+	if bs.deliver(PermissionRequest{ID: "spend-1", Type: "spend"}) {
+		t.Fatal("delivery should fail")
+	}
+	if calls != 1 {
+		t.Fatalf("sendMessage calls = %d, want 1", calls)
+	}
+}
+
+func TestMetricsCountDecisions(t *testing.T) {
+	bs := newTestBridge()
+
+	done := submitRequest(t, bs, PermissionRequest{ID: "proto-1", Type: "protocol", App: "example.com"})
+	bs.resolve(PermissionResponse{ID: "proto-1", Approved: true}, SourceRespond)
+	awaitResponse(t, done)
+
+	done = submitRequest(t, bs, PermissionRequest{ID: "spend-1", Type: "spend", App: "example.com", Amount: 500})
+	bs.resolve(PermissionResponse{ID: "spend-1"}, SourceRespond)
+	awaitResponse(t, done)
+
+	rec := httptest.NewRecorder()
+	bs.handleMetrics(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`gebunden_bridge_requests_total{type="protocol"} 1`,
+		`gebunden_bridge_requests_total{type="spend"} 1`,
+		`gebunden_bridge_approvals_total{type="protocol"} 1`,
+		`gebunden_bridge_denials_total{type="spend"} 1`,
+		`gebunden_bridge_decision_seconds_bucket{le="+Inf"} 2`,
+		`gebunden_bridge_decision_seconds_count 2`,
+		`gebunden_bridge_pending 0`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q in:\n%s", want, body)
+		}
+	}
+}
+
+func TestMetricsBoundTypeLabels(t *testing.T) {
+	m := newBridgeMetrics()
+	for _, typ := range []string{"spend", "x1", "x2\n\"}", "café"} {
+		m.requested(typ)
+	}
+	var buf bytes.Buffer
+	m.write(&buf, 0)
+	body := buf.String()
+	for _, want := range []string{
+		`gebunden_bridge_requests_total{type="spend"} 1`,
+		`gebunden_bridge_requests_total{type="other"} 3`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q in:\n%s", want, body)
+		}
+	}
+	if n := strings.Count(body, "gebunden_bridge_requests_total{"); n != 2 {
+		t.Errorf("client types made %d request series, want 2:\n%s", n, body)
+	}
+
+	var esc bytes.Buffer
+	writeCounter(&esc, "m", "help", map[string]uint64{"a\\b\"c\nd é": 1})
+	if want := `m{type="a\\b\"c\nd é"} 1`; !strings.Contains(esc.String(), want) {
+		t.Errorf("label escaped as %q, want %q", esc.String(), want)
+	}
+}
+
+func TestTelegramReplyKeywordResolves(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"ok":true,"result":{"message_id":42}}`))
+	}))
+	defer srv.Close()
+
+	bs := newTestBridge()
+	tn := NewTelegramNotifier(bs.logger, "token", "1001")
+	tn.apiURL = srv.URL
+	bs.notifier = tn
+
+	done := submitRequest(t, bs, PermissionRequest{ID: "proto-1", Type: "protocol", App: "example.com"})
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		tn.mu.Lock()
+		_, sent := tn.prompts[42]
+		tn.mu.Unlock()
+		if sent {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("prompt was never sent")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	reply := func(chatID int64, text string) {
+		msg := &telegramMessage{MessageID: 43, Text: text, ReplyTo: &telegramMessage{MessageID: 42}}
+		msg.Chat.ID = chatID
+		tn.handleUpdate(bs.resolve, telegramUpdate{UpdateID: 1, Message: msg})
+	}
+	reply(9999, "yes") // wrong chat
+	reply(1001, "maybe")
+	if !bs.isPending("proto-1") {
+		t.Fatal("request resolved by an ignored reply")
+	}
+
+	reply(1001, "Yes")
+	rec := awaitResponse(t, done)
+	var resp PermissionResponse
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if !resp.Approved {
+		t.Fatalf("expected approval, got %+v", resp)
+	}
+}
+
+func TestTelegramForgetsPromptResolvedElsewhere(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"ok":true,"result":{"message_id":42}}`))
+	}))
+	defer srv.Close()
+
+	bs := newTestBridge()
+	tn := NewTelegramNotifier(bs.logger, "token", "1001")
+	tn.apiURL = srv.URL
+	bs.notifier = tn
+	tracked := func() int {
+		tn.mu.Lock()
+		defer tn.mu.Unlock()
+		return len(tn.prompts)
+	}
+
+	done := submitRequest(t, bs, PermissionRequest{ID: "proto-1", Type: "protocol", App: "example.com"})
+	deadline := time.Now().Add(2 * time.Second)
+	for tracked() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("prompt was never sent")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	rec := httptest.NewRecorder()
+	bs.handleResponse(rec, httptest.NewRequest(http.MethodPost, "/respond", strings.NewReader(`{"id":"proto-1","approved":true}`)))
+	awaitResponse(t, done)
+	if n := tracked(); n != 0 {
+		t.Fatalf("%d prompts still tracked after /respond settled the request", n)
+	}
+
+	// A reply to the settled prompt is no longer matched.
+	var decisions atomic.Int32
+	msg := &telegramMessage{MessageID: 43, Text: "no", ReplyTo: &telegramMessage{MessageID: 42}}
+	msg.Chat.ID = 1001
+	tn.handleUpdate(func(PermissionResponse, string) (int, bool) {
+		decisions.Add(1)
+		return 0, false
+	}, telegramUpdate{UpdateID: 1, Message: msg})
+	if decisions.Load() != 0 {
+		t.Fatal("a reply to a settled prompt was taken as a decision")
+	}
+}
+
+func TestTelegramOffsetPersistsAcrossRestart(t *testing.T) {
+	// The stub retains update 7 the way Telegram does until a getUpdates
+	// call acknowledges it with a higher offset.
+	offsets := make(chan int, 100)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/getUpdates") {
+			w.Write([]byte(`{"ok":true}`))
+			return
+		}
+		var payload struct {
+			Offset int `json:"offset"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		offsets <- payload.Offset
+		if payload.Offset <= 7 {
+			w.Write([]byte(`{"ok":true,"result":[{"update_id":7,"callback_query":{"id":"cb","data":"approve:proto-1"}}]}`))
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+		w.Write([]byte(`{"ok":true,"result":[]}`))
+	}))
+	defer srv.Close()
+
+	offsetFile := filepath.Join(t.TempDir(), "telegram-offset")
+	run := func() int {
+		var decisions atomic.Int32
+		tn := NewTelegramNotifier(newTestBridge().logger, "token", "chat")
+		tn.apiURL = srv.URL
+		tn.OffsetFile = offsetFile
+		stop := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			tn.pollUpdates(func(PermissionResponse, string) (int, bool) {
+				decisions.Add(1)
+				return 0, true
+			}, stop)
+			close(done)
+		}()
+		for offset := range offsets {
+			if offset == 8 {
+				break
+			}
+		}
+		close(stop)
+		<-done
+		for len(offsets) > 0 {
+			<-offsets
+		}
+		return int(decisions.Load())
+	}
+
+	if n := run(); n != 1 {
+		t.Fatalf("first run decisions = %d, want 1", n)
+	}
+	if n := run(); n != 0 {
+		t.Fatalf("restarted poller replayed %d decisions", n)
+	}
+}
+
+func TestTelegramPollRequestsAllowedUpdates(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			AllowedUpdates []string `json:"allowed_updates"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		got = payload.AllowedUpdates
+		w.Write([]byte(`{"ok":true,"result":[]}`))
+	}))
+	defer srv.Close()
+
+	tn := NewTelegramNotifier(newTestBridge().logger, "token", "chat")
+	tn.apiURL = srv.URL
+	decide := func(PermissionResponse, string) (int, bool) { return 0, true }
+
+	if _, err := tn.pollOnce(decide, 0); err != nil {
+		t.Fatalf("pollOnce: %v", err)
+	}
+	if strings.Join(got, ",") != "callback_query" {
+		t.Fatalf("default allowed_updates = %v, want [callback_query]", got)
+	}
+
+	tn.AllowedUpdates = splitList("callback_query, message")
+	if _, err := tn.pollOnce(decide, 0); err != nil {
+		t.Fatalf("pollOnce: %v", err)
+	}
+	if strings.Join(got, ",") != "callback_query,message" {
+		t.Fatalf("configured allowed_updates = %v, want [callback_query message]", got)
+	}
+}
+
+func TestTelegramPollRespectsMinInterval(t *testing.T) {
+	// getUpdates answers at once, as it does when the long poll degenerates.
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{"ok":true,"result":[]}`))
+	}))
+	defer srv.Close()
+
+	tn := NewTelegramNotifier(newTestBridge().logger, "token", "chat")
+	tn.apiURL = srv.URL
+	tn.MinPollInterval = 50 * time.Millisecond
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		tn.pollUpdates(func(PermissionResponse, string) (int, bool) { return 0, true }, stop)
+		close(done)
+	}()
+	const window = 500 * time.Millisecond
+	time.Sleep(window)
+	close(stop)
+	<-done
+
+	n := calls.Load()
+	if max := int32(window/tn.MinPollInterval) + 1; n > max {
+		t.Fatalf("%d getUpdates calls in %v, want at most %d", n, window, max)
+	}
+	if n < 2 {
+		t.Fatalf("%d getUpdates calls in %v, want the poller to keep polling", n, window)
+	}
+}
+
+func TestValidateTLSFiles(t *testing.T) {
+	dir := t.TempDir()
+	cert := filepath.Join(dir, "cert.pem")
+	key := filepath.Join(dir, "key.pem")
+	os.WriteFile(cert, []byte("cert"), 0o600)
+	os.WriteFile(key, []byte("key"), 0o600)
+
+	cases := []struct {
+		cert, key string
+		ok        bool
+	}{
+		{"", "", true},
+		{cert, key, true},
+		{cert, "", false},
+		{"", key, false},
+		{cert, filepath.Join(dir, "missing.pem"), false},
+	}
+	for _, c := range cases {
+		if err := validateTLSFiles(c.cert, c.key); (err == nil) != c.ok {
+			t.Errorf("validateTLSFiles(%q, %q) = %v, want ok=%v", c.cert, c.key, err, c.ok)
+		}
+	}
+}
+
+func TestRememberedCertificateCoversOnlyThatCertificate(t *testing.T) {
+	bs := newTestBridge()
+	cert := func(certType, verifier string, fields ...string) PermissionRequest {
+		return PermissionRequest{Type: "certificate", App: "example.com", ExtraData: map[string]interface{}{
+			"certificateType":   certType,
+			"verifierPublicKey": verifier,
+			"fieldsToReveal":    fields,
+		}}
+	}
+	bs.remembered.put(cert("id", "02aa", "name"), time.Hour)
+
+	cases := []struct {
+		name string
+		req  PermissionRequest
+		want bool
+	}{
+		{"same certificate", cert("id", "02aa", "name"), true},
+		{"other type", cert("email", "02aa", "name"), false},
+		{"other verifier", cert("id", "02bb", "name"), false},
+		{"more fields", cert("id", "02aa", "name", "address"), false},
+		{"counterparty", PermissionRequest{Type: "counterparty", App: "example.com", ExtraData: map[string]interface{}{"counterparty": "02aa"}}, false},
+		{"unknown type", PermissionRequest{Type: "other", App: "example.com"}, false},
+	}
+	for _, c := range cases {
+		if got := bs.remembered.lookup(c.req); got != c.want {
+			t.Errorf("%s: remembered = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestEvaluateMatchesPolicy(t *testing.T) {
+	bs := newTestBridge()
+	bs.autoApproveUnder = 1000
+	bs.rememberFor = time.Hour
+	bs.remembered.put(PermissionRequest{Type: "protocol", App: "example.com", ExtraData: map[string]interface{}{"protocolID": "1-chat"}}, time.Hour)
+
+	cases := []struct {
+		name     string
+		req      PermissionRequest
+		decision string
+		timeout  int
+	}{
+		{"small spend", PermissionRequest{ID: "s1", Type: "spend", Amount: 500}, PolicyAutoApprove, 0},
+		{"large spend", PermissionRequest{ID: "s2", Type: "spend", Amount: 5000}, PolicyPrompt, 180},
+		{"spend without amount", PermissionRequest{ID: "s3", Type: "spend"}, PolicyPrompt, 180},
+		{"remembered protocol", PermissionRequest{ID: "p1", Type: "protocol", App: "example.com", ExtraData: map[string]interface{}{"protocolID": "1-chat"}}, PolicyAutoApprove, 0},
+		{"basket", PermissionRequest{ID: "b1", Type: "basket", App: "example.com"}, PolicyPrompt, 180},
+		{"invalid group", PermissionRequest{ID: "g1", Type: "group", ExtraData: map[string]interface{}{"protocolCount": 2}}, PolicyAutoDeny, 0},
+	}
+	for _, c := range cases {
+		body, _ := json.Marshal(c.req)
+		rec := httptest.NewRecorder()
+		bs.handleEvaluate(rec, httptest.NewRequest(http.MethodPost, "/evaluate", bytes.NewReader(body)))
+		var got policyDecision
+		json.Unmarshal(rec.Body.Bytes(), &got)
+		if got.Decision != c.decision || got.TimeoutSeconds != c.timeout {
+			t.Errorf("%s: got %+v, want decision %s timeout %d", c.name, got, c.decision, c.timeout)
+		}
+	}
+
+	if n := len(bs.pending); n != 0 {
+		t.Fatalf("evaluate left %d pending requests", n)
+	}
+	rec := httptest.NewRecorder()
+	bs.handleMetrics(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if strings.Contains(rec.Body.String(), "gebunden_bridge_requests_total{") {
+		t.Fatalf("evaluate was counted in metrics:\n%s", rec.Body.String())
+	}
+}
+
+func TestQuorumNeedsDistinctApprovers(t *testing.T) {
+	var mu sync.Mutex
+	var edits []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/editMessageText") {
+			var payload struct {
+				Text string `json:"text"`
+			}
+			json.NewDecoder(r.Body).Decode(&payload)
+			mu.Lock()
+			edits = append(edits, payload.Text)
+			mu.Unlock()
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	bs := newTestBridge()
+	bs.quorumApprovals = 2
+	bs.quorumOver = 10000
+	tn := NewTelegramNotifier(bs.logger, "token", "chat")
+	tn.apiURL = srv.URL
+
+	tap := func(data string, user int64) {
+		cq := &telegramCallbackQuery{ID: "cb", Data: data, Message: &telegramMessage{MessageID: 1, Text: "prompt"}}
+		cq.From.ID = user
+		tn.handleCallback(bs.resolve, cq)
+	}
+	lastEdit := func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return edits[len(edits)-1]
+	}
+
+	done := submitRequest(t, bs, PermissionRequest{ID: "spend-1", Type: "spend", App: "example.com", Amount: 50000})
+	tap("approve:spend-1", 1)
+	tap("approve:spend-1", 1) // the same approver twice counts once
+	if !bs.isPending("spend-1") {
+		t.Fatal("request granted before quorum")
+	}
+	if got := lastEdit(); !strings.Contains(got, "1 more approval needed") {
+		t.Fatalf("edited prompt = %q, want remaining count", got)
+	}
+	tap("approve:spend-1", 2)
+	var resp PermissionResponse
+	json.Unmarshal(awaitResponse(t, done).Body.Bytes(), &resp)
+	if !resp.Approved {
+		t.Fatalf("expected approval after quorum, got %+v", resp)
+	}
+	if got := lastEdit(); strings.Contains(got, "more approval") {
+		t.Fatalf("final prompt still shows quorum status: %q", got)
+	}
+
+	done = submitRequest(t, bs, PermissionRequest{ID: "spend-2", Type: "spend", App: "example.com", Amount: 50000})
+	tap("approve:spend-2", 1)
+	tap("deny:spend-2", 2)
+	resp = PermissionResponse{}
+	json.Unmarshal(awaitResponse(t, done).Body.Bytes(), &resp)
+	if resp.Approved {
+		t.Fatalf("a single deny should deny, got %+v", resp)
+	}
+}
+
+// captureSink is an AuditSink keeping every entry in memory.
+type captureSink struct {
+	mu      sync.Mutex
+	entries []AuditEntry
+}
+
+func (c *captureSink) Record(entry AuditEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, entry)
+	return nil
+}
+
+func (c *captureSink) Close() error { return nil }
+
+func TestQuorumOverridesAutoApproveAndRecordsApprovers(t *testing.T) {
+	sink := &captureSink{}
+	_, key, _ := ed25519.GenerateKey(nil)
+	bs := NewBridgeServer(BridgeConfig{Logger: newTestBridge().logger, Audit: sink, Attester: NewAttester(key)})
+	bs.autoApproveUnder = 100000
+	bs.quorumApprovals = 2
+
+	// Every spend needs a quorum, however small.
+	if d := bs.evaluate(PermissionRequest{ID: "spend-0", Type: "spend", App: "example.com", Amount: 500}); d.Decision != PolicyPrompt || d.RequiredApprovals != 2 {
+		t.Fatalf("small spend under a quorum: %+v, want a prompt needing 2 approvals", d)
+	}
+	if d := bs.evaluate(PermissionRequest{ID: "proto-0", Type: "protocol", App: "example.com", RequiredApprovals: 3}); d.Decision != PolicyPrompt || d.RequiredApprovals != 3 {
+		t.Fatalf("request with its own quorum: %+v, want a prompt needing 3 approvals", d)
+	}
+
+	done := submitRequest(t, bs, PermissionRequest{ID: "spend-1", Type: "spend", App: "example.com", Amount: 500})
+	// /respond callers name themselves, so their approvals never count.
+	for _, body := range []string{
+		`{"id":"spend-1","approved":true,"approver":"alice","approvers":["telegram:1"]}`,
+		`{"id":"spend-1","approved":true,"approver":"bob"}`,
+		`{"id":"spend-1","approved":true}`,
+	} {
+		rec := httptest.NewRecorder()
+		bs.handleResponse(rec, httptest.NewRequest(http.MethodPost, "/respond", strings.NewReader(body)))
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("/respond %s: status %d, want %d", body, rec.Code, http.StatusForbidden)
+		}
+	}
+	if remaining, _ := bs.resolve(PermissionResponse{ID: "spend-1", Approved: true, Approver: "carol"}, SourceRespond); remaining != 2 {
+		t.Fatalf("/respond approval left %d approvals needed, want 2", remaining)
+	}
+	bs.resolve(PermissionResponse{ID: "spend-1", Approved: true, Approver: "1001"}, SourceTelegram)
+	bs.resolve(PermissionResponse{ID: "spend-1", Approved: true, Approver: "1001"}, SourceTelegram)
+	if !bs.isPending("spend-1") {
+		t.Fatal("one approver answering twice met the quorum")
+	}
+	bs.resolve(PermissionResponse{ID: "spend-1", Approved: true, Approver: "U02"}, SourceSlack)
+	var resp PermissionResponse
+	json.Unmarshal(awaitResponse(t, done).Body.Bytes(), &resp)
+	if !resp.Approved || resp.Reason == ReasonAutoApproved {
+		t.Fatalf("response = %+v, want approval by the approvers", resp)
+	}
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if len(sink.entries) != 1 {
+		t.Fatalf("audited %d entries, want 1", len(sink.entries))
+	}
+	entry := sink.entries[0]
+	want := []string{"slack:U02", "telegram:1001"}
+	if entry.Response.Approver != "U02" || !reflect.DeepEqual(entry.Response.Approvers, want) {
+		t.Errorf("audited approver %q of %v, want U02 of %v", entry.Response.Approver, entry.Response.Approvers, want)
+	}
+	if att := entry.Attestation; att == nil || !att.Verify() || !reflect.DeepEqual(att.Decision.Approvers, want) {
+		t.Errorf("attestation = %+v, want a valid one naming %v", att, want)
+	}
+}
+
+func TestPromptShowsExpiryAndExpires(t *testing.T) {
+	now := time.Now()
+	req := PermissionRequest{ID: "proto-1", Type: "protocol", App: "example.com", Timestamp: now.Add(-38 * time.Second).Unix()}
+	if got := expiresIn(req, now); got < 141*time.Second || got > 142*time.Second {
+		t.Fatalf("expiresIn = %v, want ~142s", got)
+	}
+	if text := formatPrompt(req); !strings.Contains(text, "Expires:</b> in 14") {
+		t.Fatalf("prompt lacks expiry line:\n%s", text)
+	}
+
+	var mu sync.Mutex
+	var edited string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/editMessageText") {
+			var payload struct {
+				Text string `json:"text"`
+			}
+			json.NewDecoder(r.Body).Decode(&payload)
+			mu.Lock()
+			edited = payload.Text
+			mu.Unlock()
+		}
+		w.Write([]byte(`{"ok":true,"result":{"message_id":7}}`))
+	}))
+	defer srv.Close()
+
+	tn := NewTelegramNotifier(newTestBridge().logger, "token", "chat")
+	tn.apiURL = srv.URL
+	if err := tn.Send(req); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	tn.Expire(req)
+	mu.Lock()
+	defer mu.Unlock()
+	if !strings.Contains(edited, "EXPIRED") || strings.Contains(edited, "Expires") {
+		t.Fatalf("expired prompt = %q", edited)
+	}
+}
+
+func TestRespondIsIdempotent(t *testing.T) {
+	bs := newTestBridge()
+	done := submitRequest(t, bs, PermissionRequest{ID: "spend-1", Type: "spend", App: "example.com", Amount: 500})
+
+	respond := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		bs.handleResponse(rec, httptest.NewRequest(http.MethodPost, "/respond", strings.NewReader(body)))
+		return rec
+	}
+	first := respond(`{"id":"spend-1","approved":true,"reason":"ops"}`)
+	awaitResponse(t, done)
+	retry := respond(`{"id":"spend-1","approved":false}`)
+
+	if first.Code != http.StatusOK || retry.Code != http.StatusOK {
+		t.Fatalf("status = %d then %d, want 200 twice", first.Code, retry.Code)
+	}
+	if first.Body.String() != retry.Body.String() {
+		t.Fatalf("retry body %q differs from original %q", retry.Body.String(), first.Body.String())
+	}
+	if !strings.Contains(retry.Body.String(), `"approved":true`) {
+		t.Fatalf("retry did not return the original approval: %s", retry.Body.String())
+	}
+
+	if rec := respond(`{"id":"never-seen","approved":true}`); rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown id status = %d, want 404", rec.Code)
+	}
+}
+
+func TestNewLoggerFormatAndLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := newLogger(&buf, "json", "warn")
+	if err != nil {
+		t.Fatalf("newLogger: %v", err)
+	}
+	logger.Info("hidden")
+	logger.Warn("shown", "id", "req-1")
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("want one JSON line, got %q: %v", buf.String(), err)
+	}
+	if line["msg"] != "shown" || line["id"] != "req-1" {
+		t.Fatalf("unexpected log line %v", line)
+	}
+
+	if _, err := newLogger(&buf, "xml", "info"); err == nil {
+		t.Fatal("unknown format accepted")
+	}
+	if _, err := newLogger(&buf, "text", "loud"); err == nil {
+		t.Fatal("unknown level accepted")
+	}
+}
+
+func TestRememberedApprovalPersistsInGrantFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grants.json")
+	req := PermissionRequest{ID: "proto-1", Type: "protocol", App: "example.com",
+		ExtraData: map[string]interface{}{"protocolID": "chat"}}
+
+	bs := NewBridgeServer(BridgeConfig{Logger: newTestBridge().logger, RememberFor: time.Hour, Grants: NewFileGrantStore(path)})
+	done := submitRequest(t, bs, req)
+	bs.resolve(PermissionResponse{ID: "proto-1", Approved: true, Remember: true}, SourceRespond)
+	awaitResponse(t, done)
+
+	restarted := NewBridgeServer(BridgeConfig{Logger: newTestBridge().logger, RememberFor: time.Hour, Grants: NewFileGrantStore(path)})
+	if d := restarted.evaluate(req); d.Decision != PolicyAutoApprove {
+		t.Fatalf("after restart evaluate = %+v, want remembered approval", d)
+	}
+	grants, _ := NewFileGrantStore(path).List()
+	if len(grants) != 1 || grants[0].Key != "example.com|protocol|chat" {
+		t.Fatalf("grant file holds %+v, want the wallet's key format", grants)
+	}
+}
+
+func TestFileGrantStoreWritersSharingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "grants.json")
+	// Two stores stand for two processes: only the file lock orders them.
+	stores := []*FileGrantStore{NewFileGrantStore(path), NewFileGrantStore(path)}
+
+	const perStore = 20
+	var wg sync.WaitGroup
+	for i, store := range stores {
+		for j := range perStore {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := store.Put(fmt.Sprintf("app-%d-%d|protocol|chat", i, j), 0); err != nil {
+					t.Errorf("Put: %v", err)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	grants, err := NewFileGrantStore(path).List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(grants) != len(stores)*perStore {
+		t.Fatalf("file holds %d grants, want %d", len(grants), len(stores)*perStore)
+	}
+	if tmps, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(tmps) != 0 {
+		t.Fatalf("temporary files left behind: %v", tmps)
+	}
+}
+
+func TestRateLimitPerApp(t *testing.T) {
+	bs := newTestBridge()
+	bs.limiter = newAppRateLimiter(2, time.Minute)
+	n := &recordingNotifier{}
+	bs.notifier = n
+
+	post := func(id, app string) int {
+		body, _ := json.Marshal(PermissionRequest{ID: id, Type: "protocol", App: app})
+		done := make(chan int, 1)
+		go func() {
+			rec := httptest.NewRecorder()
+			bs.handlePermissionRequest(rec, httptest.NewRequest(http.MethodPost, "/request-permission", bytes.NewReader(body)))
+			done <- rec.Code
+		}()
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if bs.isPending(id) {
+				return http.StatusAccepted
+			}
+			select {
+			case code := <-done:
+				return code
+			case <-time.After(5 * time.Millisecond):
+			}
+		}
+		t.Fatalf("request %s neither pending nor rejected", id)
+		return 0
+	}
+
+	if post("a1", "flood.example") != http.StatusAccepted || post("a2", "flood.example") != http.StatusAccepted {
+		t.Fatal("requests within the limit were rejected")
+	}
+	if code := post("a3", "flood.example"); code != http.StatusTooManyRequests {
+		t.Fatalf("third request = %d, want 429", code)
+	}
+	if post("b1", "other.example") != http.StatusAccepted {
+		t.Fatal("another app was limited by the flooding one")
+	}
+	for _, id := range []string{"a1", "a2", "b1"} {
+		bs.resolve(PermissionResponse{ID: id}, SourceRespond)
+	}
+}
+
+func TestTokenBucketRefills(t *testing.T) {
+	l := newAppRateLimiter(2, time.Minute)
+	now := time.Now()
+	if !l.allow("app", now) || !l.allow("app", now) || l.allow("app", now) {
+		t.Fatal("bucket should allow exactly its burst")
+	}
+	if !l.allow("app", now.Add(30*time.Second)) {
+		t.Fatal("one token should refill after window/limit")
+	}
+	if newAppRateLimiter(0, time.Minute) != nil {
+		t.Fatal("zero limit should disable the limiter")
+	}
+}
+
+func TestDecisionAttestation(t *testing.T) {
+	keyPath := filepath.Join(t.TempDir(), "attest.key")
+	attester, err := LoadAttester(keyPath)
+	if err != nil {
+		t.Fatalf("LoadAttester: %v", err)
+	}
+	if reloaded, err := LoadAttester(keyPath); err != nil || reloaded.PublicKey() != attester.PublicKey() {
+		t.Fatalf("reloading the saved key gave a different signer (err %v)", err)
+	}
+	bs := NewBridgeServer(BridgeConfig{Logger: newTestBridge().logger, Attester: attester})
+
+	done := submitRequest(t, bs, PermissionRequest{ID: "spend-1", Type: "spend", App: "example.com", Amount: 2500, Timestamp: 1700000000})
+	bs.resolve(PermissionResponse{ID: "spend-1", Approved: true, Reason: ReasonUserApproved}, SourceTelegram)
+	awaitResponse(t, done)
+
+	rec := httptest.NewRecorder()
+	bs.handleAttestation(rec, httptest.NewRequest(http.MethodGet, "/attestation?id=spend-1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	var att Attestation
+	if err := json.Unmarshal(rec.Body.Bytes(), &att); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	pub, _ := hex.DecodeString(attester.PublicKey())
+	if !ed25519.Verify(pub, att.Payload, att.Signature) {
+		t.Fatal("signature does not verify over the payload")
+	}
+	var signed AttestedDecision
+	json.Unmarshal(att.Payload, &signed)
+	if signed.ID != "spend-1" || signed.Amount != 2500 || signed.RequestTimestamp != 1700000000 || !signed.Approved {
+		t.Fatalf("signed payload = %+v", signed)
+	}
+	if !att.Verify() {
+		t.Fatal("Verify rejected a genuine attestation")
+	}
+	att.Decision.Amount = 1
+	if att.Verify() {
+		t.Fatal("Verify accepted a decision that differs from the signed payload")
+	}
+}
+
+func TestReadBridgeConfigFallsBackToOpenClawChat(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GEBUNDEN_BOT_TOKEN", "env-token")
+	t.Setenv("GEBUNDEN_CHAT_ID", "")
+	os.MkdirAll(filepath.Join(home, ".openclaw"), 0o700)
+	os.WriteFile(filepath.Join(home, ".openclaw", "openclaw.json"),
+		[]byte(`{"channels":{"telegram":{"botToken":"claw-token","chatId":-1001234567890}}}`), 0o600)
+
+	token, chat := readBridgeConfig()
+	if token != "env-token" {
+		t.Fatalf("token = %q, want the env token to win over config", token)
+	}
+	if chat != "-1001234567890" {
+		t.Fatalf("chat = %q, want the OpenClaw chatId", chat)
+	}
+
+	t.Setenv("GEBUNDEN_CHAT_ID", "env-chat")
+	if _, chat := readBridgeConfig(); chat != "env-chat" {
+		t.Fatalf("chat = %q, want the env chat to win over config", chat)
+	}
+}
+
+func TestResponseReasonsAreStable(t *testing.T) {
+	bs := newTestBridge()
+
+	// Free text sent to /respond is kept as the message, not the reason.
+	done := submitRequest(t, bs, PermissionRequest{ID: "spend-1", Type: "spend", App: "example.com", Amount: 500})
+	rec := httptest.NewRecorder()
+	bs.handleResponse(rec, httptest.NewRequest(http.MethodPost, "/respond", strings.NewReader(`{"id":"spend-1","approved":false,"reason":"looks odd"}`)))
+	var resp PermissionResponse
+	json.Unmarshal(awaitResponse(t, done).Body.Bytes(), &resp)
+	if resp.Reason != ReasonUserDenied || resp.Message != "looks odd" {
+		t.Fatalf("got reason %q message %q, want %q with the caller's text", resp.Reason, resp.Message, ReasonUserDenied)
+	}
+
+	// Button presses report the user's decision and the channel.
+	action, _ := parseCallbackData("approve:spend-2")
+	if r := action.response("user via telegram"); r.Reason != ReasonUserApproved || r.Message != "user via telegram" {
+		t.Fatalf("callback response = %+v", r)
+	}
+
+	// Policy decisions carry their own reasons.
+	bs.paused.Store(true)
+	if d := bs.evaluate(PermissionRequest{ID: "spend-3", Type: "spend", App: "example.com", Amount: 1}); d.Reason != ReasonPaused {
+		t.Fatalf("paused reason = %q, want %q", d.Reason, ReasonPaused)
+	}
+	bs.paused.Store(false)
+	if d := bs.evaluate(PermissionRequest{ID: "group-4", Type: "group", ExtraData: map[string]interface{}{"protocolCount": float64(2)}}); d.Reason != ReasonInvalidRequest || d.Message == "" {
+		t.Fatalf("invalid request decision = %+v", d)
+	}
+}
+
+func TestSyslogSinkDeliversApproval(t *testing.T) {
+	collector, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer collector.Close()
+	sink, err := OpenSyslogSink("udp://" + collector.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("OpenSyslogSink: %v", err)
+	}
+	defer sink.Close()
+	bs := NewBridgeServer(BridgeConfig{Logger: newTestBridge().logger, Audit: AuditSinks{sink}})
+
+	done := submitRequest(t, bs, PermissionRequest{ID: "spend-1", Type: "spend", App: "example.com", Amount: 2500})
+	bs.resolve(PermissionResponse{ID: "spend-1", Approved: true, Reason: ReasonUserApproved}, SourceTelegram)
+	awaitResponse(t, done)
+
+	buf := make([]byte, 64*1024)
+	collector.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := collector.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("no syslog message arrived: %v", err)
+	}
+	// local0.info, an RFC 5424 header, the decision's structured data,
+	// then the entry as JSON after a BOM.
+	format := regexp.MustCompile(`^<134>1 \d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\.\d{6}Z \S+ gebunden-bridge \d+ approved ` +
+		`\[decision@32473 id="spend-1" type="spend" app="example.com" source="telegram" reason="user_approved"\] \x{FEFF}(.+)$`)
+	m := format.FindSubmatch(buf[:n])
+	if m == nil {
+		t.Fatalf("message %q is not in the expected format", buf[:n])
+	}
+	var entry AuditEntry
+	if err := json.Unmarshal(m[1], &entry); err != nil {
+		t.Fatalf("message body: %v", err)
+	}
+	if entry.Request.ID != "spend-1" || !entry.Response.Approved || entry.Source != SourceTelegram {
+		t.Fatalf("entry = %+v", entry)
+	}
+}
+
+func TestFiatRatesKeepLastRateWhileSourceFails(t *testing.T) {
+	var calls atomic.Int32
+	var failing atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		if failing.Load() {
+			http.Error(w, "down", http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"bitcoin-sv":{"usd":50}}`))
+	}))
+	defer srv.Close()
+
+	f := NewFiatRates(slog.New(slog.NewTextHandler(io.Discard, nil)), srv.URL, "USD")
+	if s, ok := f.Format(100_000_000); !ok || s != "≈ $50.00 USD" {
+		t.Fatalf("Format = %q, %v", s, ok)
+	}
+
+	// Once the rate is stale a failed refresh still shows the last rate,
+	// and the source is not asked again until the backoff has passed.
+	failing.Store(true)
+	f.fetched = time.Now().Add(-2 * fiatRateTTL)
+	for i := 0; i < 3; i++ {
+		if s, ok := f.Format(100_000_000); !ok || s != "≈ $50.00 USD" {
+			t.Fatalf("Format with failing source = %q, %v", s, ok)
+		}
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("made %d requests, want 2", n)
+	}
+	if until := time.Until(f.retryAt); until <= 0 || until > fiatRateBackoff {
+		t.Errorf("retry in %v, want within %v", until, fiatRateBackoff)
+	}
+}
+
+func TestFindRatePrefersCurrencyOverRate(t *testing.T) {
+	var body interface{}
+	json.Unmarshal([]byte(`{"a":{"rate":1},"b":{"usd":2},"rate":3,"USD":"4"}`), &body)
+	for i := 0; i < 20; i++ {
+		if rate, ok := findRate(body, "USD"); !ok || rate != 4 {
+			t.Fatalf("findRate = %v, %v, want 4", rate, ok)
+		}
+	}
+	delete(body.(map[string]interface{}), "USD")
+	delete(body.(map[string]interface{}), "rate")
+	for i := 0; i < 20; i++ {
+		if rate, ok := findRate(body, "USD"); !ok || rate != 1 {
+			t.Fatalf("nested findRate = %v, %v, want 1 from the first key", rate, ok)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metric names exposed on GET /metrics in the Prometheus text format:
+//
+//	gebunden_bridge_requests_total{type}     permission requests accepted
+//	gebunden_bridge_approvals_total{type}    requests approved (including auto and remembered)
+//	gebunden_bridge_denials_total{type}      requests denied (including undeliverable and shutdown)
+//	gebunden_bridge_timeouts_total{type}     requests that expired without a decision
+//	gebunden_bridge_decision_seconds         histogram of time from request to decision
+//	gebunden_bridge_pending                  requests currently waiting for a decision
+const (
+	metricRequests  = "gebunden_bridge_requests_total"
+	metricApprovals = "gebunden_bridge_approvals_total"
+	metricDenials   = "gebunden_bridge_denials_total"
+	metricTimeouts  = "gebunden_bridge_timeouts_total"
+	metricDecision  = "gebunden_bridge_decision_seconds"
+	metricPending   = "gebunden_bridge_pending"
+)
+
+// decisionBuckets are the histogram upper bounds in seconds, spanning an
+// instant tap up to permissionTimeout.
+var decisionBuckets = []float64{1, 5, 10, 30, 60, 120, 180}
+
+// bridgeMetrics holds the counters behind /metrics. It is safe for concurrent
+// use.
+type bridgeMetrics struct {
+	mu        sync.Mutex
+	requests  map[string]uint64
+	approvals map[string]uint64
+	denials   map[string]uint64
+	timeouts  map[string]uint64
+	buckets   []uint64 // cumulative counts, parallel to decisionBuckets
+	sum       float64
+	count     uint64
+}
+
+func newBridgeMetrics() *bridgeMetrics {
+	return &bridgeMetrics{
+		requests:  make(map[string]uint64),
+		approvals: make(map[string]uint64),
+		denials:   make(map[string]uint64),
+		timeouts:  make(map[string]uint64),
+		buckets:   make([]uint64, len(decisionBuckets)),
+	}
+}
+
+// metricTypes are the request types counted under their own label. Types
+// come from the client, so any other is counted as "other" rather than
+// letting callers create label series at will.
+var metricTypes = map[string]bool{
+	"spend":        true,
+	"protocol":     true,
+	"basket":       true,
+	"certificate":  true,
+	"group":        true,
+	"counterparty": true,
+}
+
+// metricType is the type label typ is counted under.
+func metricType(typ string) string {
+	if metricTypes[typ] {
+		return typ
+	}
+	return "other"
+}
+
+func (m *bridgeMetrics) requested(typ string) {
+	m.mu.Lock()
+	m.requests[metricType(typ)]++
+	m.mu.Unlock()
+}
+
+func (m *bridgeMetrics) decided(typ string, approved bool) {
+	m.mu.Lock()
+	if approved {
+		m.approvals[metricType(typ)]++
+	} else {
+		m.denials[metricType(typ)]++
+	}
+	m.mu.Unlock()
+}
+
+func (m *bridgeMetrics) timedOut(typ string) {
+	m.mu.Lock()
+	m.timeouts[metricType(typ)]++
+	m.mu.Unlock()
+}
+
+// observeDecision records how long a prompted request waited for its answer.
+func (m *bridgeMetrics) observeDecision(d time.Duration) {
+	secs := d.Seconds()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, le := range decisionBuckets {
+		if secs <= le {
+			m.buckets[i]++
+		}
+	}
+	m.sum += secs
+	m.count++
+}
+
+// write renders the metrics in the Prometheus text exposition format.
+func (m *bridgeMetrics) write(w io.Writer, pending int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	writeCounter(w, metricRequests, "Permission requests accepted, by type.", m.requests)
+	writeCounter(w, metricApprovals, "Permission requests approved, by type.", m.approvals)
+	writeCounter(w, metricDenials, "Permission requests denied, by type.", m.denials)
+	writeCounter(w, metricTimeouts, "Permission requests that timed out, by type.", m.timeouts)
+
+	fmt.Fprintf(w, "# HELP %s Time from request to decision for prompted requests.\n", metricDecision)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", metricDecision)
+	for i, le := range decisionBuckets {
+		fmt.Fprintf(w, "%s_bucket{le=\"%g\"} %d\n", metricDecision, le, m.buckets[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", metricDecision, m.count)
+	fmt.Fprintf(w, "%s_sum %g\n", metricDecision, m.sum)
+	fmt.Fprintf(w, "%s_count %d\n", metricDecision, m.count)
+
+	fmt.Fprintf(w, "# HELP %s Requests currently waiting for a decision.\n", metricPending)
+	fmt.Fprintf(w, "# TYPE %s gauge\n", metricPending)
+	fmt.Fprintf(w, "%s %d\n", metricPending, pending)
+}
+
+func writeCounter(w io.Writer, name, help string, values map[string]uint64) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s counter\n", name)
+	types := make([]string, 0, len(values))
+	for typ := range values {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	for _, typ := range types {
+		fmt.Fprintf(w, "%s{type=\"%s\"} %d\n", name, labelEscaper.Replace(typ), values[typ])
+	}
+}
+
+// labelEscaper escapes a label value as the text exposition format requires:
+// backslash, double quote and line feed, leaving other characters as UTF-8.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// ---------------------------------------------------------------------------
+// GET /metrics
+// ---------------------------------------------------------------------------
+
+func (bs *BridgeServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	bs.mu.Lock()
+	pending := len(bs.pending)
+	bs.mu.Unlock()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	bs.metrics.write(w, pending)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ---------------------------------------------------------------------------
+// POST /pause, POST /resume — stop accepting new requests during maintenance
+// ---------------------------------------------------------------------------
+
+// handlePause makes the bridge reject new permission requests with 503.
+// Requests already waiting for a decision are unaffected.
+func (bs *BridgeServer) handlePause(w http.ResponseWriter, r *http.Request) {
+	bs.setPaused(w, r, true)
+}
+
+// handleResume makes the bridge accept new permission requests again.
+func (bs *BridgeServer) handleResume(w http.ResponseWriter, r *http.Request) {
+	bs.setPaused(w, r, false)
+}
+
+func (bs *BridgeServer) setPaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !bs.authorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if bs.paused.Swap(paused) != paused {
+		bs.logger.Info("Bridge pause state changed", "paused", paused)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"paused": paused})
+}
+
+// ---------------------------------------------------------------------------
+// GET /health, GET /stats
+// ---------------------------------------------------------------------------
+
+func (bs *BridgeServer) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"ok": true, "paused": bs.paused.Load()})
+}
+
+func (bs *BridgeServer) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	bs.mu.Lock()
+	stats := map[string]interface{}{
+		"paused":      bs.paused.Load(),
+		"pending":     len(bs.pending),
+		"deadLetters": len(bs.deadLetters),
+	}
+	bs.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Policy outcomes for a permission request.
+const (
+	PolicyPrompt      = "prompt"
+	PolicyAutoApprove = "auto-approve"
+	PolicyAutoDeny    = "auto-deny"
+)
+
+// policyDecision is what the bridge would do with a request.
+type policyDecision struct {
+	Decision string `json:"decision"`
+	Reason   string `json:"reason,omitempty"`
+	Message  string `json:"message,omitempty"`
+	// TimeoutSeconds is how long a prompted request waits for an answer.
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+	// RequiredApprovals is how many distinct approvers a prompt needs.
+	RequiredApprovals int `json:"requiredApprovals,omitempty"`
+
+	source string // audit source for decisions made without prompting
+}
+
+// policy decides how a valid request is handled: answered immediately by the
+// auto-approve threshold (spends of a known, positive amount only) or a
+// remembered decision, or prompted. Requests needing a quorum are always
+// prompted.
+func (bs *BridgeServer) policy(req PermissionRequest) policyDecision {
+	if req.RequiredApprovals > 1 {
+		return bs.prompt(req)
+	}
+	if req.Type == "spend" && req.Amount > 0 && req.Amount < bs.autoApproveUnder {
+		return policyDecision{Decision: PolicyAutoApprove, Reason: ReasonAutoApproved, Message: "under auto-approve threshold", source: SourceAuto}
+	}
+	if bs.remembered.lookup(req) {
+		return policyDecision{Decision: PolicyAutoApprove, Reason: ReasonRemembered, Message: "remembered decision", source: SourceRemembered}
+	}
+	return bs.prompt(req)
+}
+
+// prompt is the decision to ask the approvers about req.
+func (bs *BridgeServer) prompt(req PermissionRequest) policyDecision {
+	return policyDecision{
+		Decision:          PolicyPrompt,
+		TimeoutSeconds:    int(permissionTimeout.Seconds()),
+		RequiredApprovals: req.RequiredApprovals,
+	}
+}
+
+// evaluate reports what handlePermissionRequest would do with req, including
+// rejections for a paused bridge or an invalid request.
+func (bs *BridgeServer) evaluate(req PermissionRequest) policyDecision {
+	if bs.paused.Load() {
+		return policyDecision{Decision: PolicyAutoDeny, Reason: ReasonPaused, Message: "bridge paused"}
+	}
+	if err := validateRequest(req); err != nil {
+		return policyDecision{Decision: PolicyAutoDeny, Reason: ReasonInvalidRequest, Message: err.Error()}
+	}
+	bs.applyQuorum(&req)
+	return bs.policy(req)
+}
+
+// ---------------------------------------------------------------------------
+// POST /evaluate — dry-run a permission request
+// ---------------------------------------------------------------------------
+
+// handleEvaluate returns the policy decision for a sample request without
+// prompting, auditing or counting it.
+func (bs *BridgeServer) handleEvaluate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !bs.authorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var req PermissionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"bad request"}`, http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(bs.evaluate(req))
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Default per-app prompt rate: a burst of defaultRateLimit prompts, refilled
+// evenly over defaultRateWindow.
+const (
+	defaultRateLimit  = 10
+	defaultRateWindow = time.Minute
+)
+
+// maxIdleBuckets bounds how many per-app buckets are kept before full ones
+// are dropped.
+const maxIdleBuckets = 1024
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// appRateLimiter is a per-app token bucket: each app may make up to limit
+// requests at once, regaining one every window/limit. It is safe for
+// concurrent use.
+type appRateLimiter struct {
+	mu      sync.Mutex
+	limit   float64
+	window  time.Duration
+	buckets map[string]*tokenBucket
+}
+
+// newAppRateLimiter returns a limiter allowing limit requests per window per
+// app, or nil (which allows everything) if limit is not positive.
+func newAppRateLimiter(limit int, window time.Duration) *appRateLimiter {
+	if limit <= 0 || window <= 0 {
+		return nil
+	}
+	return &appRateLimiter{
+		limit:   float64(limit),
+		window:  window,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// allow takes a token from app's bucket and reports whether one was left.
+func (l *appRateLimiter) allow(app string, now time.Time) bool {
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[app]
+	if !ok {
+		if len(l.buckets) >= maxIdleBuckets {
+			l.prune(now)
+		}
+		b = &tokenBucket{tokens: l.limit, last: now}
+		l.buckets[app] = b
+	}
+	b.tokens = l.refill(b, now)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func (l *appRateLimiter) refill(b *tokenBucket, now time.Time) float64 {
+	tokens := b.tokens + now.Sub(b.last).Seconds()*l.limit/l.window.Seconds()
+	if tokens > l.limit {
+		tokens = l.limit
+	}
+	return tokens
+}
+
+// prune drops buckets that have refilled completely; they behave exactly
+// like fresh ones.
+func (l *appRateLimiter) prune(now time.Time) {
+	for app, b := range l.buckets {
+		if l.refill(b, now) >= l.limit {
+			delete(l.buckets, app)
+		}
+	}
+}
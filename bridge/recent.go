@@ -0,0 +1,52 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// recentDecisionTTL is how long a settled request's decision is kept so that
+// a repeated /respond for it gets the original answer.
+const recentDecisionTTL = 10 * time.Minute
+
+type recentDecision struct {
+	resp    PermissionResponse
+	settled time.Time
+}
+
+// recentDecisions remembers how recently settled requests were decided. It is
+// safe for concurrent use.
+type recentDecisions struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]recentDecision
+}
+
+func newRecentDecisions(ttl time.Duration) *recentDecisions {
+	return &recentDecisions{ttl: ttl, entries: make(map[string]recentDecision)}
+}
+
+// put records resp as the final decision for its request, dropping expired
+// entries along the way.
+func (r *recentDecisions) put(resp PermissionResponse) {
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, d := range r.entries {
+		if now.Sub(d.settled) > r.ttl {
+			delete(r.entries, id)
+		}
+	}
+	r.entries[resp.ID] = recentDecision{resp: resp, settled: now}
+}
+
+// get returns the decision recorded for id within the TTL, if any.
+func (r *recentDecisions) get(id string) (PermissionResponse, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d, ok := r.entries[id]
+	if !ok || time.Since(d.settled) > r.ttl {
+		return PermissionResponse{}, false
+	}
+	return d.resp, true
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SourceRemembered marks requests answered from a remembered decision.
+const SourceRemembered = "remembered"
+
+// rememberScopes lists, for each type whose decisions may be remembered, the
+// ExtraData fields that identify what a request asks for. Spends and grouped
+// requests always prompt, since their amounts and contents vary from one
+// request to the next, as do types the bridge cannot tell apart.
+var rememberScopes = map[string][]string{
+	"protocol":     {"protocolID"},
+	"basket":       {"basket"},
+	"certificate":  {"certificateType", "verifierPublicKey", "fieldsToReveal"},
+	"counterparty": {"counterparty"},
+}
+
+// remembersDecision reports whether decisions for permType may be remembered.
+func remembersDecision(permType string) bool {
+	_, ok := rememberScopes[permType]
+	return ok
+}
+
+// rememberKey identifies the requests a remembered decision applies to: the
+// same app asking for the same type of access to the same thing, e.g. the
+// same protocol, or the same certificate fields shown to the same verifier.
+func rememberKey(req PermissionRequest) string {
+	parts := []string{req.App, req.Type}
+	for _, field := range rememberScopes[req.Type] {
+		parts = append(parts, fmt.Sprint(req.ExtraData[field]))
+	}
+	return strings.Join(parts, "|")
+}
+
+// shortDuration formats d without trailing zero units, e.g. "1h" or "1h30m".
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// decisionCache answers requests from remembered approvals kept in a
+// GrantStore.
+type decisionCache struct {
+	store  GrantStore
+	logger *slog.Logger
+}
+
+func newDecisionCache(store GrantStore, logger *slog.Logger) *decisionCache {
+	return &decisionCache{store: store, logger: logger}
+}
+
+func (c *decisionCache) put(req PermissionRequest, ttl time.Duration) {
+	if err := c.store.Put(rememberKey(req), ttl); err != nil {
+		c.logger.Error("Failed to remember approval", "id", req.ID, "error", err)
+	}
+}
+
+// lookup reports whether an unexpired approval is remembered for req.
+func (c *decisionCache) lookup(req PermissionRequest) bool {
+	if !remembersDecision(req.Type) {
+		return false
+	}
+	_, ok, err := c.store.Get(rememberKey(req))
+	if err != nil {
+		c.logger.Error("Failed to read remembered approvals", "id", req.ID, "error", err)
+		return false
+	}
+	return ok
+}
+
+// clear forgets every remembered approval and returns how many there were.
+func (c *decisionCache) clear() (int, error) {
+	grants, err := c.store.List()
+	if err != nil {
+		return 0, err
+	}
+	for _, g := range grants {
+		if err := c.store.Revoke(g.Key); err != nil {
+			return 0, err
+		}
+	}
+	return len(grants), nil
+}
+
+// ---------------------------------------------------------------------------
+// DELETE /remembered — forget every remembered decision
+// ---------------------------------------------------------------------------
+
+func (bs *BridgeServer) handleRemembered(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !bs.authorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	n, err := bs.remembered.clear()
+	if err != nil {
+		bs.logger.Error("Failed to clear remembered approvals", "error", err)
+		http.Error(w, "failed to clear remembered approvals", http.StatusInternalServerError)
+		return
+	}
+	bs.logger.Info("Cleared remembered decisions", "count", n)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"cleared": n})
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// slackActionsPath is where Slack posts interactive button presses. Configure
+// it as the Request URL under "Interactivity & Shortcuts" in the Slack app.
+const slackActionsPath = "/slack/actions"
+
+// slackMaxClockSkew bounds the age of a signed interaction request, as
+// recommended by Slack to prevent replay.
+const slackMaxClockSkew = 5 * time.Minute
+
+// SlackNotifier posts prompts through a Slack incoming webhook as messages with
+// interactive buttons. Button presses arrive on slackActionsPath and are
+// authenticated with the app's signing secret.
+type SlackNotifier struct {
+	logger        *slog.Logger
+	webhookURL    string
+	signingSecret string
+
+	// RememberFor, when non-zero, offers an "Always allow" button that
+	// remembers the approval for this long. It must match the bridge's
+	// BridgeConfig.RememberFor.
+	RememberFor time.Duration
+}
+
+// NewSlackNotifier creates a notifier for the given incoming webhook URL and
+// app signing secret.
+func NewSlackNotifier(logger *slog.Logger, webhookURL, signingSecret string) *SlackNotifier {
+	return &SlackNotifier{
+		logger:        logger,
+		webhookURL:    webhookURL,
+		signingSecret: signingSecret,
+	}
+}
+
+// Start registers the interactivity handler on mux.
+func (sn *SlackNotifier) Start(decide DecisionFunc, mux *http.ServeMux, _ <-chan struct{}) {
+	mux.HandleFunc(slackActionsPath, func(w http.ResponseWriter, r *http.Request) {
+		sn.handleAction(decide, w, r)
+	})
+}
+
+// Send posts the prompt for req to the webhook's channel.
+func (sn *SlackNotifier) Send(req PermissionRequest) error {
+	text := formatSlackPrompt(req)
+
+	var buttons []map[string]interface{}
+	for _, row := range promptActions(req, sn.RememberFor) {
+		for _, a := range row {
+			b := map[string]interface{}{
+				"type":      "button",
+				"text":      map[string]interface{}{"type": "plain_text", "text": a.Label},
+				"action_id": a.Data,
+				"value":     a.Data,
+			}
+			if a.Style != "" {
+				b["style"] = a.Style
+			}
+			buttons = append(buttons, b)
+		}
+	}
+
+	payload := map[string]interface{}{
+		"text": text,
+		"blocks": []map[string]interface{}{
+			{
+				"type": "section",
+				"text": map[string]interface{}{"type": "mrkdwn", "text": text},
+			},
+			{
+				"type":     "actions",
+				"block_id": "decision",
+				"elements": buttons,
+			},
+		},
+	}
+	payloadJSON, _ := json.Marshal(payload)
+
+	resp, err := http.Post(sn.webhookURL, "application/json", bytes.NewBuffer(payloadJSON))
+	if err != nil {
+		return fmt.Errorf("slack send failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		err := fmt.Errorf("slack webhook error: status %d: %s", resp.StatusCode, body)
+		if isPermanentStatus(resp.StatusCode) {
+			return permanent(err)
+		}
+		return err
+	}
+	sn.logger.Info("Prompt sent to Slack", "id", req.ID, "type", req.Type)
+	return nil
+}
+
+// formatSlackPrompt renders the prompt for req as Slack mrkdwn.
+func formatSlackPrompt(req PermissionRequest) string {
+	p := buildPrompt(req)
+	var b strings.Builder
+	if p.Label != "" {
+		b.WriteString("[" + h(p.Label) + "] ")
+	}
+	b.WriteString(fmt.Sprintf("%s *%s*\n\n", p.Icon, h(p.Title)))
+	for _, f := range p.Fields {
+		value := h(f.Value)
+		if f.Code {
+			value = "`" + value + "`"
+		}
+		if f.Bullet {
+			b.WriteString(fmt.Sprintf("• %s: %s\n", h(f.Label), value))
+		} else {
+			b.WriteString(fmt.Sprintf("*%s:* %s\n", h(f.Label), value))
+		}
+	}
+	return b.String()
+}
+
+// ---------------------------------------------------------------------------
+// POST /slack/actions — interactive button presses
+// ---------------------------------------------------------------------------
+
+type slackActionPayload struct {
+	Type string `json:"type"`
+	User struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+	Actions []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+	ResponseURL string `json:"response_url"`
+	Message     struct {
+		Text string `json:"text"`
+	} `json:"message"`
+}
+
+func (sn *SlackNotifier) handleAction(decide DecisionFunc, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if !sn.verifySignature(r.Header, body, time.Now()) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	var payload slackActionPayload
+	if err := json.Unmarshal([]byte(form.Get("payload")), &payload); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+
+	if payload.Type != "block_actions" || len(payload.Actions) == 0 {
+		return
+	}
+	action, ok := parseCallbackData(payload.Actions[0].Value)
+	if !ok {
+		return
+	}
+
+	sn.logger.Info("Slack action", "approved", action.Approved, "duration", action.Duration,
+		"remember", action.Remember, "reqID", action.ReqID, "user", payload.User.ID)
+	resp := action.response("user via slack")
+	resp.Approver = payload.User.ID
+	remaining, _ := decide(resp, SourceSlack)
+
+	if payload.ResponseURL == "" {
+		return
+	}
+	if remaining > 0 {
+		// Keep the prompt and its buttons for the remaining approvers.
+		sn.postReply(payload.ResponseURL, quorumStatus(remaining))
+		return
+	}
+	sn.replaceMessage(payload.ResponseURL, payload.Message.Text+"\n\n"+decisionLabel(action, sn.RememberFor))
+}
+
+// verifySignature checks Slack's v0 request signature over the raw body.
+func (sn *SlackNotifier) verifySignature(header http.Header, body []byte, now time.Time) bool {
+	if sn.signingSecret == "" {
+		return false
+	}
+	ts := header.Get("X-Slack-Request-Timestamp")
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return false
+	}
+	if skew := now.Sub(time.Unix(sec, 0)); skew > slackMaxClockSkew || skew < -slackMaxClockSkew {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(sn.signingSecret))
+	fmt.Fprintf(mac, "v0:%s:%s", ts, body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature")))
+}
+
+// replaceMessage swaps the prompt (and its buttons) for text via response_url.
+func (sn *SlackNotifier) replaceMessage(responseURL, text string) {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"replace_original": true,
+		"text":             text,
+	})
+	http.Post(responseURL, "application/json", bytes.NewBuffer(payload))
+}
+
+// postReply posts text to the prompt's channel without touching the prompt.
+func (sn *SlackNotifier) postReply(responseURL, text string) {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"replace_original": false,
+		"response_type":    "in_channel",
+		"text":             text,
+	})
+	http.Post(responseURL, "application/json", bytes.NewBuffer(payload))
+}
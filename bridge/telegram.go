@@ -0,0 +1,487 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const telegramAPIURL = "https://api.telegram.org"
+
+// defaultAllowedUpdates limits polling to button presses. Add "message" to
+// accept yes/no text replies to prompts.
+var defaultAllowedUpdates = []string{"callback_query"}
+
+// defaultMinPollInterval is the least time between two getUpdates calls
+// unless MinPollInterval says otherwise.
+const defaultMinPollInterval = time.Second
+
+// TelegramNotifier delivers prompts to a Telegram chat as messages with inline
+// buttons and long-polls the Bot API for the approver's button presses.
+type TelegramNotifier struct {
+	logger *slog.Logger
+	token  string
+	chat   string
+	apiURL string
+
+	// RememberFor, when non-zero, offers an "Always allow" button that
+	// remembers the approval for this long. It must match the bridge's
+	// BridgeConfig.RememberFor.
+	RememberFor time.Duration
+	// Templates overrides the built-in prompt text per request type.
+	Templates PromptTemplates
+	// AllowedUpdates is the set of update types requested from getUpdates.
+	// Nil selects defaultAllowedUpdates.
+	AllowedUpdates []string
+	// OffsetFile, when set, persists the getUpdates offset across restarts
+	// so updates processed before a restart are not replayed.
+	OffsetFile string
+	// MinPollInterval is the least time between the starts of two
+	// getUpdates calls, so that a poll answered at once, such as when a
+	// flapping network or proxy cuts the long poll short, cannot spin the
+	// loop into Telegram's rate limits. Zero selects defaultMinPollInterval.
+	MinPollInterval time.Duration
+
+	mu      sync.Mutex
+	prompts map[int]sentPrompt // by message ID, for replies and expiry
+}
+
+// sentPrompt is a prompt message awaiting a decision.
+type sentPrompt struct {
+	reqID string
+	text  string // plain text without the expiry line, for later edits
+}
+
+// NewTelegramNotifier creates a notifier for the given bot token and chat ID.
+func NewTelegramNotifier(logger *slog.Logger, token, chat string) *TelegramNotifier {
+	return &TelegramNotifier{
+		logger:  logger,
+		token:   token,
+		chat:    chat,
+		apiURL:  telegramAPIURL,
+		prompts: make(map[int]sentPrompt),
+	}
+}
+
+func (tn *TelegramNotifier) baseURL() string {
+	return fmt.Sprintf("%s/bot%s", tn.apiURL, tn.token)
+}
+
+// Start begins long-polling for button presses until stop is closed.
+func (tn *TelegramNotifier) Start(decide DecisionFunc, _ *http.ServeMux, stop <-chan struct{}) {
+	go tn.pollUpdates(decide, stop)
+}
+
+// ---------------------------------------------------------------------------
+// Telegram: send prompt with inline buttons
+// ---------------------------------------------------------------------------
+
+// Send posts the prompt for req to the configured chat.
+func (tn *TelegramNotifier) Send(req PermissionRequest) error {
+	if tn.chat == "" {
+		return fmt.Errorf("telegram chat ID not configured")
+	}
+
+	text, err := tn.Templates.render(req)
+	if err != nil {
+		tn.logger.Warn("Prompt template failed, using built-in prompt", "id", req.ID, "error", err)
+		text = formatPrompt(req)
+	}
+	var keyboard [][]map[string]interface{}
+	for _, actions := range promptActions(req, tn.RememberFor) {
+		row := make([]map[string]interface{}, 0, len(actions))
+		for _, a := range actions {
+			row = append(row, map[string]interface{}{"text": a.Label, "callback_data": a.Data})
+		}
+		keyboard = append(keyboard, row)
+	}
+
+	payload := map[string]interface{}{
+		"chat_id":      tn.chat,
+		"text":         text,
+		"parse_mode":   "HTML",
+		"reply_markup": map[string]interface{}{"inline_keyboard": keyboard},
+	}
+	status, body, err := tn.sendMessage(payload)
+	if err != nil {
+		return err
+	}
+
+	// Markup that slipped past escaping makes Telegram reject the whole
+	// message; retry once as plain text so the prompt still gets through.
+	if isTelegramParseError(status, body) {
+		tn.logger.Warn("Telegram rejected HTML prompt, retrying as plain text",
+			"id", req.ID, "body", string(body))
+		delete(payload, "parse_mode")
+		payload["text"] = formatPlainPrompt(req)
+		status, body, err = tn.sendMessage(payload)
+		if err != nil {
+			return err
+		}
+	}
+
+	if status != http.StatusOK {
+		err := fmt.Errorf("telegram API error: status %d: %s", status, body)
+		if isPermanentStatus(status) {
+			return permanent(err)
+		}
+		return err
+	}
+
+	var sent struct {
+		Result struct {
+			MessageID int `json:"message_id"`
+		} `json:"result"`
+	}
+	if json.Unmarshal(body, &sent) == nil && sent.Result.MessageID != 0 {
+		tn.mu.Lock()
+		plain := req
+		plain.Timestamp = 0
+		tn.prompts[sent.Result.MessageID] = sentPrompt{reqID: req.ID, text: formatPlainPrompt(plain)}
+		tn.mu.Unlock()
+	}
+	tn.logger.Info("Prompt sent to Telegram", "id", req.ID, "type", req.Type)
+	return nil
+}
+
+func (tn *TelegramNotifier) sendMessage(payload map[string]interface{}) (int, []byte, error) {
+	payloadJSON, _ := json.Marshal(payload)
+	resp, err := http.Post(tn.baseURL()+"/sendMessage", "application/json", bytes.NewBuffer(payloadJSON))
+	if err != nil {
+		return 0, nil, fmt.Errorf("telegram send failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, body, nil
+}
+
+// isTelegramParseError reports whether a sendMessage response is Telegram
+// refusing the message's parse_mode markup.
+func isTelegramParseError(status int, body []byte) bool {
+	if status != http.StatusBadRequest {
+		return false
+	}
+	var result struct {
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return false
+	}
+	return strings.Contains(strings.ToLower(result.Description), "can't parse entities")
+}
+
+// ---------------------------------------------------------------------------
+// Telegram: long-poll for callback_query (button clicks) and text replies
+// ---------------------------------------------------------------------------
+
+type telegramUpdate struct {
+	UpdateID      int                    `json:"update_id"`
+	CallbackQuery *telegramCallbackQuery `json:"callback_query"`
+	Message       *telegramMessage       `json:"message"`
+}
+
+type telegramCallbackQuery struct {
+	ID      string           `json:"id"`
+	From    telegramUser     `json:"from"`
+	Data    string           `json:"data"`
+	Message *telegramMessage `json:"message"`
+}
+
+type telegramUser struct {
+	ID int64 `json:"id"`
+}
+
+type telegramMessage struct {
+	MessageID int `json:"message_id"`
+	Chat      struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+	From        *telegramUser    `json:"from"`
+	Text        string           `json:"text"`
+	ReplyTo     *telegramMessage `json:"reply_to_message"`
+	ReplyMarkup json.RawMessage  `json:"reply_markup,omitempty"`
+}
+
+func (tn *TelegramNotifier) pollUpdates(decide DecisionFunc, stop <-chan struct{}) {
+	offset := tn.loadOffset()
+	minInterval := tn.minPollInterval()
+
+	var last time.Time
+	for {
+		wait := time.NewTimer(time.Until(last.Add(minInterval)))
+		select {
+		case <-stop:
+			wait.Stop()
+			return
+		case <-wait.C:
+		}
+
+		last = time.Now()
+		next, err := tn.pollOnce(decide, offset)
+		if err != nil {
+			tn.logger.Error("Telegram poll error", "error", err)
+			time.Sleep(5 * time.Second)
+			continue
+		}
+		if next != offset {
+			offset = next
+			tn.saveOffset(offset)
+		}
+	}
+}
+
+// pollOnce fetches and handles one batch of updates starting at offset and
+// returns the offset for the next call.
+func (tn *TelegramNotifier) pollOnce(decide DecisionFunc, offset int) (int, error) {
+	payload := map[string]interface{}{
+		"offset":          offset,
+		"timeout":         30,
+		"allowed_updates": tn.allowedUpdates(),
+	}
+	body, _ := json.Marshal(payload)
+	resp, err := http.Post(tn.baseURL()+"/getUpdates", "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return offset, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK     bool             `json:"ok"`
+		Result []telegramUpdate `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return offset, fmt.Errorf("decode getUpdates response: %w", err)
+	}
+
+	for _, u := range result.Result {
+		offset = u.UpdateID + 1
+		tn.handleUpdate(decide, u)
+	}
+	return offset, nil
+}
+
+func (tn *TelegramNotifier) minPollInterval() time.Duration {
+	if tn.MinPollInterval == 0 {
+		return defaultMinPollInterval
+	}
+	return tn.MinPollInterval
+}
+
+func (tn *TelegramNotifier) allowedUpdates() []string {
+	if tn.AllowedUpdates == nil {
+		return defaultAllowedUpdates
+	}
+	return tn.AllowedUpdates
+}
+
+// loadOffset returns the persisted poll offset, or 0 if there is none.
+func (tn *TelegramNotifier) loadOffset() int {
+	if tn.OffsetFile == "" {
+		return 0
+	}
+	data, err := os.ReadFile(tn.OffsetFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			tn.logger.Warn("Failed to read Telegram offset", "path", tn.OffsetFile, "error", err)
+		}
+		return 0
+	}
+	offset, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		tn.logger.Warn("Ignoring malformed Telegram offset", "path", tn.OffsetFile, "error", err)
+		return 0
+	}
+	return offset
+}
+
+// saveOffset persists offset atomically via a temp file and rename.
+func (tn *TelegramNotifier) saveOffset(offset int) {
+	if tn.OffsetFile == "" {
+		return
+	}
+	tmp := tn.OffsetFile + ".tmp"
+	err := os.MkdirAll(filepath.Dir(tn.OffsetFile), 0o700)
+	if err == nil {
+		err = os.WriteFile(tmp, []byte(strconv.Itoa(offset)), 0o600)
+	}
+	if err == nil {
+		err = os.Rename(tmp, tn.OffsetFile)
+	}
+	if err != nil {
+		tn.logger.Warn("Failed to persist Telegram offset", "path", tn.OffsetFile, "error", err)
+	}
+}
+
+func (tn *TelegramNotifier) handleUpdate(decide DecisionFunc, u telegramUpdate) {
+	switch {
+	case u.CallbackQuery != nil:
+		tn.handleCallback(decide, u.CallbackQuery)
+	case u.Message != nil:
+		tn.handleReply(decide, u.Message)
+	}
+}
+
+// handleCallback reports the decision behind an inline-button press and
+// acknowledges it in the chat.
+func (tn *TelegramNotifier) handleCallback(decide DecisionFunc, cq *telegramCallbackQuery) {
+	if cq.Data == "" {
+		return
+	}
+	action, ok := parseCallbackData(cq.Data)
+	if !ok {
+		return
+	}
+
+	tn.logger.Info("Telegram callback", "approved", action.Approved, "duration", action.Duration,
+		"remember", action.Remember, "reqID", action.ReqID, "user", cq.From.ID)
+	resp := action.response("user via telegram")
+	resp.Approver = strconv.FormatInt(cq.From.ID, 10)
+	remaining, _ := decide(resp, SourceTelegram)
+
+	baseURL := tn.baseURL()
+	switch {
+	case remaining > 0:
+		tn.answerCallback(baseURL, cq.ID, "✅ Approval recorded")
+	case action.Approved:
+		tn.answerCallback(baseURL, cq.ID, "✅ Approved")
+	default:
+		tn.answerCallback(baseURL, cq.ID, "❌ Denied")
+	}
+
+	if cq.Message != nil {
+		tn.reportDecision(baseURL, cq.Message, action, remaining)
+	}
+}
+
+// handleReply treats a "yes"/"no" text reply to a prompt in the configured
+// chat as pressing Approve or Deny on it.
+func (tn *TelegramNotifier) handleReply(decide DecisionFunc, msg *telegramMessage) {
+	if msg.ReplyTo == nil || strconv.FormatInt(msg.Chat.ID, 10) != tn.chat {
+		return
+	}
+	approved, ok := parseReplyKeyword(msg.Text)
+	if !ok {
+		return
+	}
+	tn.mu.Lock()
+	sent, known := tn.prompts[msg.ReplyTo.MessageID]
+	tn.mu.Unlock()
+	if !known {
+		return
+	}
+
+	action := callbackAction{ReqID: sent.reqID, Approved: approved}
+	tn.logger.Info("Telegram reply", "approved", approved, "reqID", sent.reqID)
+	resp := action.response("user via telegram reply")
+	if msg.From != nil {
+		resp.Approver = strconv.FormatInt(msg.From.ID, 10)
+	}
+	remaining, _ := decide(resp, SourceTelegram)
+
+	prompt := *msg.ReplyTo
+	prompt.Chat = msg.Chat
+	tn.reportDecision(tn.baseURL(), &prompt, action, remaining)
+}
+
+// reportDecision edits the prompt to show the decision. While a request still
+// needs more approvals the buttons stay and a status line counts them down.
+func (tn *TelegramNotifier) reportDecision(baseURL string, prompt *telegramMessage, action callbackAction, remaining int) {
+	if remaining > 0 {
+		tn.editMessage(baseURL, prompt.Chat.ID, prompt.MessageID,
+			withStatus(prompt.Text, quorumStatus(remaining)), prompt.ReplyMarkup)
+		return
+	}
+	tn.forgetPrompt(prompt.MessageID)
+	tn.editMessage(baseURL, prompt.Chat.ID, prompt.MessageID,
+		withStatus(prompt.Text, decisionLabel(action, tn.RememberFor)), nil)
+}
+
+// withStatus appends status to a prompt's text, replacing any earlier quorum
+// status line.
+func withStatus(text, status string) string {
+	if i := strings.Index(text, "\n\n⏳"); i >= 0 {
+		text = text[:i]
+	}
+	return text + "\n\n" + status
+}
+
+// Expire edits the prompt for req, if it is still showing, to say it expired
+// and removes its buttons.
+func (tn *TelegramNotifier) Expire(req PermissionRequest) {
+	tn.mu.Lock()
+	messageID, sent, found := 0, sentPrompt{}, false
+	for id, p := range tn.prompts {
+		if p.reqID == req.ID {
+			messageID, sent, found = id, p, true
+			delete(tn.prompts, id)
+			break
+		}
+	}
+	tn.mu.Unlock()
+	if !found {
+		return
+	}
+	tn.editMessage(tn.baseURL(), tn.chat, messageID,
+		sent.text+"\n\n⌛ EXPIRED — no decision was made in time", nil)
+}
+
+// Resolved forgets the prompt for the request with id, however it was
+// settled, so later replies to it are no longer matched.
+func (tn *TelegramNotifier) Resolved(id string) {
+	tn.mu.Lock()
+	defer tn.mu.Unlock()
+	for messageID, p := range tn.prompts {
+		if p.reqID == id {
+			delete(tn.prompts, messageID)
+		}
+	}
+}
+
+func (tn *TelegramNotifier) forgetPrompt(messageID int) {
+	tn.mu.Lock()
+	delete(tn.prompts, messageID)
+	tn.mu.Unlock()
+}
+
+// parseReplyKeyword maps a reply's text to a decision.
+func parseReplyKeyword(text string) (approved, ok bool) {
+	switch strings.ToLower(strings.TrimSpace(text)) {
+	case "yes", "y", "approve", "approved", "ok":
+		return true, true
+	case "no", "n", "deny", "denied", "reject":
+		return false, true
+	}
+	return false, false
+}
+
+func (tn *TelegramNotifier) answerCallback(baseURL, callbackID, text string) {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"callback_query_id": callbackID,
+		"text":              text,
+	})
+	http.Post(baseURL+"/answerCallbackQuery", "application/json", bytes.NewBuffer(payload))
+}
+
+// editMessage replaces a message's text. A nil markup removes its buttons.
+// chatID is a numeric ID or the configured chat string.
+func (tn *TelegramNotifier) editMessage(baseURL string, chatID interface{}, messageID int, newText string, markup json.RawMessage) {
+	fields := map[string]interface{}{
+		"chat_id":    chatID,
+		"message_id": messageID,
+		"text":       newText,
+	}
+	if markup != nil {
+		fields["reply_markup"] = markup
+	}
+	payload, _ := json.Marshal(fields)
+	http.Post(baseURL+"/editMessageText", "application/json", bytes.NewBuffer(payload))
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// promptData is what a custom prompt template is rendered against.
+type promptData struct {
+	ID      string
+	Type    string
+	App     string
+	Origin  string
+	Message string
+	Amount  int64
+	Asset   string
+	// Fiat is the approximate fiat value of Amount, when available.
+	Fiat string
+	// ExpiresIn is the number of seconds left to decide.
+	ExpiresIn int
+	// Extra is the request's ExtraData, e.g. {{.Extra.protocolID}}.
+	Extra map[string]interface{}
+}
+
+// templateFuncs are available to every prompt template. Values interpolated
+// into Telegram HTML should go through esc.
+var templateFuncs = template.FuncMap{
+	"esc": func(v interface{}) string { return h(fmt.Sprint(v)) },
+}
+
+// PromptTemplates overrides the built-in Telegram prompt text per request
+// type. Types without a template keep the built-in wording.
+type PromptTemplates map[string]*template.Template
+
+// ParsePromptTemplates parses per-type template sources, failing on the
+// first one that does not parse.
+func ParsePromptTemplates(sources map[string]string) (PromptTemplates, error) {
+	templates := make(PromptTemplates, len(sources))
+	for permType, src := range sources {
+		t, err := template.New(permType).Funcs(templateFuncs).Option("missingkey=zero").Parse(src)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %q prompt template: %w", permType, err)
+		}
+		templates[permType] = t
+	}
+	return templates, nil
+}
+
+// LoadPromptTemplates reads a JSON object mapping request types to template
+// sources, e.g. {"spend": "{{esc .App}} wants {{.Amount}} sats"}.
+func LoadPromptTemplates(path string) (PromptTemplates, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt templates: %w", err)
+	}
+	var sources map[string]string
+	if err := json.Unmarshal(data, &sources); err != nil {
+		return nil, fmt.Errorf("failed to parse prompt templates %s: %w", path, err)
+	}
+	return ParsePromptTemplates(sources)
+}
+
+// render returns the Telegram HTML prompt for req, using the template for
+// its type if there is one and the built-in formatPrompt otherwise.
+func (pt PromptTemplates) render(req PermissionRequest) (string, error) {
+	t, ok := pt[req.Type]
+	if !ok {
+		return formatPrompt(req), nil
+	}
+	var b strings.Builder
+	if req.Label != "" {
+		b.WriteString("[" + h(req.Label) + "] ")
+	}
+	err := t.Execute(&b, promptData{
+		ID:        req.ID,
+		Type:      req.Type,
+		App:       req.App,
+		Origin:    req.Origin,
+		Message:   req.Message,
+		Amount:    req.Amount,
+		Asset:     req.Asset,
+		Fiat:      req.Fiat,
+		ExpiresIn: int(expiresIn(req, time.Now()).Seconds()),
+		Extra:     req.ExtraData,
+	})
+	if err != nil {
+		return "", fmt.Errorf("render %q prompt template: %w", req.Type, err)
+	}
+	return b.String(), nil
+}
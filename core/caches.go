@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bsv-blockchain/go-sdk/overlay/lookup"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/defs"
+)
+
+// lookupCacheTTL is how long an overlay answer is served from the cache,
+// matching the toolbox's cache of discovered certificates.
+const lookupCacheTTL = 2 * time.Minute
+
+// cachesFile is what FlushCaches writes and the next wallet on the same
+// network loads.
+type cachesFile struct {
+	Lookups []lookupCacheEntry `json:"lookups"`
+}
+
+// FlushCaches writes the wallet's overlay lookup answers still within
+// their TTL to ~/.gebunden/caches-<network>.json, from which the next
+// wallet started on the network serves them until they expire, so that a
+// restart does not query the overlay again for identities discovered just
+// before it. Answers that cannot be written as JSON, such as formula
+// answers, are left out.
+//
+// The toolbox keeps its own discovery and trust settings caches private,
+// so the answers are recorded as they come back from the overlay and the
+// toolbox rebuilds its caches from them. Trust settings are read anew from
+// the wallet settings, and grants in a grants file are written as they are
+// given, so neither needs flushing; in-memory grants last only as long as
+// the process by design.
+func (ws *WalletService) FlushCaches(ctx context.Context) error {
+	ws.mu.RLock()
+	lookups := ws.lookups
+	chain := ws.chain
+	ws.mu.RUnlock()
+
+	if lookups == nil {
+		return errWalletNotInitialized
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	path, err := cachesPath(chain)
+	if err != nil {
+		return err
+	}
+	entries, skipped := lookups.snapshot()
+	data, err := json.Marshal(cachesFile{Lookups: entries})
+	if err != nil {
+		return fmt.Errorf("failed to encode caches: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write caches: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write caches: %w", err)
+	}
+	ws.logger.Info("Caches flushed", "path", path, "lookups", len(entries), "skipped", skipped)
+	return nil
+}
+
+// cachesPath is where FlushCaches writes the caches of a wallet on chain.
+func cachesPath(chain defs.BSVNetwork) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	dataDir := filepath.Join(homeDir, ".gebunden")
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create data directory: %w", err)
+	}
+	return filepath.Join(dataDir, fmt.Sprintf("caches-%s.json", chain)), nil
+}
+
+// lookupCacheEntry is one overlay host's answer to a lookup question.
+type lookupCacheEntry struct {
+	Host     string                 `json:"host"`
+	Question *lookup.LookupQuestion `json:"question"`
+	Answer   *lookup.LookupAnswer   `json:"answer"`
+	Fetched  time.Time              `json:"fetched"`
+}
+
+func lookupCacheKey(host string, question *lookup.LookupQuestion) string {
+	return host + "\x00" + question.Service + "\x00" + string(question.Query)
+}
+
+// lookupCache records the overlay's answers so they can be flushed, and
+// serves the answers loaded from a previous flush while they are fresh.
+// Answers recorded by this process are not served from here: the toolbox
+// caches them itself.
+type lookupCache struct {
+	mu  sync.Mutex
+	now func() time.Time
+	ttl time.Duration
+	// recorded holds the latest answer per host and question; warm holds
+	// the answers loaded at start.
+	recorded map[string]lookupCacheEntry
+	warm     map[string]lookupCacheEntry
+}
+
+func newLookupCache(now func() time.Time, ttl time.Duration) *lookupCache {
+	return &lookupCache{
+		now:      now,
+		ttl:      ttl,
+		recorded: make(map[string]lookupCacheEntry),
+		warm:     make(map[string]lookupCacheEntry),
+	}
+}
+
+func (c *lookupCache) fresh(e lookupCacheEntry) bool {
+	return c.now().Sub(e.Fetched) < c.ttl
+}
+
+// load reads the answers of a previous flush from path; a missing file
+// loads nothing.
+func (c *lookupCache) load(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var f cachesFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return 0, fmt.Errorf("invalid caches file %s: %w", path, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, e := range f.Lookups {
+		if e.Question == nil || e.Answer == nil || !c.fresh(e) {
+			continue
+		}
+		c.warm[lookupCacheKey(e.Host, e.Question)] = e
+	}
+	return len(c.warm), nil
+}
+
+// answer returns the loaded answer of host to question, if still fresh.
+func (c *lookupCache) answer(host string, question *lookup.LookupQuestion) (*lookup.LookupAnswer, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.warm[lookupCacheKey(host, question)]
+	if !ok || !c.fresh(e) {
+		return nil, false
+	}
+	return e.Answer, true
+}
+
+func (c *lookupCache) record(host string, question *lookup.LookupQuestion, answer *lookup.LookupAnswer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := lookupCacheKey(host, question)
+	c.recorded[key] = lookupCacheEntry{Host: host, Question: question, Answer: answer, Fetched: c.now()}
+	delete(c.warm, key)
+}
+
+// stats counts the answers recorded and those still loaded.
+func (c *lookupCache) stats() (recorded, warm int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.recorded), len(c.warm)
+}
+
+// clear drops every answer, recorded or loaded.
+func (c *lookupCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.recorded)
+	clear(c.warm)
+}
+
+// snapshot returns the fresh answers that can be written as JSON, and how
+// many fresh ones could not.
+func (c *lookupCache) snapshot() (entries []lookupCacheEntry, skipped int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries = []lookupCacheEntry{}
+	for _, m := range []map[string]lookupCacheEntry{c.warm, c.recorded} {
+		for _, e := range m {
+			if !c.fresh(e) {
+				continue
+			}
+			// Formulas carry functions, which do not survive encoding.
+			if len(e.Answer.Formulas) > 0 {
+				skipped++
+				continue
+			}
+			if _, err := json.Marshal(e); err != nil {
+				skipped++
+				continue
+			}
+			entries = append(entries, e)
+		}
+	}
+	return entries, skipped
+}
+
+// cachingFacilitator answers lookups from the cache's loaded answers and
+// records the answers of the overlay hosts it asks.
+type cachingFacilitator struct {
+	base  lookup.Facilitator
+	cache *lookupCache
+}
+
+func (f *cachingFacilitator) Lookup(ctx context.Context, url string, question *lookup.LookupQuestion) (*lookup.LookupAnswer, error) {
+	if answer, ok := f.cache.answer(url, question); ok {
+		return answer, nil
+	}
+	answer, err := f.base.Lookup(ctx, url, question)
+	if err != nil {
+		return nil, err
+	}
+	f.cache.record(url, question, answer)
+	return answer, nil
+}
+
+// cachingResolver is a copy of r whose lookups go through cache.
+func cachingResolver(r *lookup.LookupResolver, cache *lookupCache) *lookup.LookupResolver {
+	cached := *r
+	base := r.Facilitator
+	if base == nil {
+		base = &lookup.HTTPSOverlayLookupFacilitator{Client: http.DefaultClient}
+	}
+	cached.Facilitator = &cachingFacilitator{base: base, cache: cache}
+	return &cached
+}
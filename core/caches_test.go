@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-sdk/overlay"
+	"github.com/bsv-blockchain/go-sdk/overlay/lookup"
+)
+
+func TestFlushCachesWarmsRestart(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	var lookups atomic.Int32
+	overlayHost := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"type":"output-list","outputs":[]}`))
+	}))
+	defer overlayHost.Close()
+	resolver := lookup.NewLookupResolver(&lookup.LookupResolver{
+		NetworkPreset: overlay.NetworkTestnet,
+		HostOverrides: map[string][]string{"ls_identity": {overlayHost.URL}},
+	})
+
+	key := newKey(t)
+	subject := newKey(t).PubKey().ToDERHex()
+	discover := func(ws *WalletService) {
+		t.Helper()
+		if _, err := ws.CallWalletMethod("discoverByIdentityKey", `{"identityKey":"`+subject+`"}`, "example.com"); err != nil {
+			t.Fatalf("discoverByIdentityKey: %v", err)
+		}
+	}
+
+	ws := NewWalletService(WithLookupResolver(resolver))
+	if err := ws.InitializeWallet(key.Hex(), "test"); err != nil {
+		t.Fatalf("InitializeWallet: %v", err)
+	}
+	discover(ws)
+	if err := ws.FlushCaches(context.Background()); err != nil {
+		t.Fatalf("FlushCaches: %v", err)
+	}
+	ws.ShutdownWallet()
+	path := filepath.Join(home, ".gebunden", "caches-test.json")
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("caches file: %v", err)
+	}
+
+	restarted := NewWalletService(WithLookupResolver(resolver))
+	if err := restarted.InitializeWallet(key.Hex(), "test"); err != nil {
+		t.Fatalf("InitializeWallet after restart: %v", err)
+	}
+	defer restarted.ShutdownWallet()
+	discover(restarted)
+	if n := lookups.Load(); n != 1 {
+		t.Fatalf("%d overlay lookups, want the discovery after restart served from the flushed cache", n)
+	}
+
+	// Flushed answers go stale like any other.
+	later := func() time.Time { return time.Now().Add(lookupCacheTTL) }
+	if n, err := newLookupCache(later, lookupCacheTTL).load(path); err != nil || n != 0 {
+		t.Fatalf("loaded %d stale answers, %v; want none", n, err)
+	}
+}
+
+func TestLookupCacheSkipsFormulas(t *testing.T) {
+	c := newLookupCache(time.Now, time.Minute)
+	question := &lookup.LookupQuestion{Service: "ls_identity", Query: []byte(`{}`)}
+	c.record("https://a.example", question, &lookup.LookupAnswer{Type: lookup.AnswerTypeOutputList})
+	c.record("https://b.example", question, &lookup.LookupAnswer{
+		Type:     lookup.AnswerTypeFormula,
+		Formulas: []lookup.LookupFormula{{}},
+	})
+	entries, skipped := c.snapshot()
+	if len(entries) != 1 || entries[0].Host != "https://a.example" || skipped != 1 {
+		t.Fatalf("snapshot = %+v, %d skipped; want the output list kept and the formula skipped", entries, skipped)
+	}
+}
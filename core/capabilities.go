@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/defs"
+)
+
+// walletMethods lists the BRC-100 methods CallWalletMethod dispatches, in
+// the order the README documents them. Keep it in step with the switch.
+var walletMethods = []string{
+	"createAction", "createActions", "signAction", "abortAction", "listActions", "internalizeAction",
+	"createReceipt", "verifyReceipt",
+	"labelAction", "unlabelAction", "payURI", "createDataAction",
+	"listOutputs", "relinquishOutput", "nextReceivingScript",
+	"acquireCertificate", "listCertificates", "proveCertificate", "relinquishCertificate",
+	"encrypt", "decrypt", "createHmac", "verifyHmac", "createSignature", "verifySignature",
+	"createSignedEnvelope", "verifySignedEnvelope", "verifyExternalSignature",
+	"getPublicKey", "revealCounterpartyKeyLinkage", "revealSpecificKeyLinkage",
+	"discoverByIdentityKey", "discoverByAttributes",
+	"resolvePaymail", "sendToPaymail",
+	"getHeight", "getHeaderForHeight", "getNetwork", "getVersion",
+	"isAuthenticated", "waitForAuthentication",
+	"capabilities", "getBalance", "stats",
+}
+
+var knownWalletMethods = func() map[string]bool {
+	m := make(map[string]bool, len(walletMethods))
+	for _, name := range walletMethods {
+		m[name] = true
+	}
+	return m
+}()
+
+// Capabilities describes what this wallet build supports so clients can
+// feature-detect instead of probing methods.
+type Capabilities struct {
+	Version  string             `json:"version"`
+	Network  string             `json:"network"`
+	Methods  []string           `json:"methods"`
+	Features CapabilityFeatures `json:"features"`
+}
+
+// CapabilityFeatures flags optional behaviour.
+type CapabilityFeatures struct {
+	// PrivilegedKeyManager is true when a PrivilegedKeyManager is
+	// configured; without one, calls with privileged set fail with
+	// ErrPrivilegedNotSupported.
+	PrivilegedKeyManager bool `json:"privilegedKeyManager"`
+	// Discovery is true when identity discovery by key and attributes is
+	// available.
+	Discovery bool `json:"discovery"`
+	// Certificates is true when certificates can be acquired and proven.
+	Certificates bool `json:"certificates"`
+	// PermissionPrompts is true when sensitive calls are confirmed by the
+	// user through a permission gate.
+	PermissionPrompts bool `json:"permissionPrompts"`
+}
+
+// Capabilities returns the capability descriptor for originator.
+func (ws *WalletService) Capabilities(_ context.Context, originator string) (*Capabilities, error) {
+	if originator == "" {
+		return nil, fmt.Errorf("invalid originator: must not be empty")
+	}
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	return &Capabilities{
+		Version: defs.Version,
+		Network: string(ws.chain),
+		Methods: append([]string(nil), walletMethods...),
+		Features: CapabilityFeatures{
+			PrivilegedKeyManager: ws.privilegedKeys != nil,
+			Discovery:            knownWalletMethods["discoverByIdentityKey"] && knownWalletMethods["discoverByAttributes"],
+			Certificates:         knownWalletMethods["acquireCertificate"] && knownWalletMethods["proveCertificate"],
+			PermissionPrompts:    ws.permissionGate != nil,
+		},
+	}, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// dispatchedMethods returns the method names CallWalletMethod has a case for.
+func dispatchedMethods(t *testing.T) []string {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "wallet_service.go", nil, 0)
+	if err != nil {
+		t.Fatalf("parse wallet_service.go: %v", err)
+	}
+	var methods []string
+	ast.Inspect(file, func(n ast.Node) bool {
+		fn, ok := n.(*ast.FuncDecl)
+		if !ok || fn.Name.Name != "CallWalletMethod" {
+			return true
+		}
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			if cc, ok := n.(*ast.CaseClause); ok {
+				for _, e := range cc.List {
+					if lit, ok := e.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+						name, _ := strconv.Unquote(lit.Value)
+						methods = append(methods, name)
+					}
+				}
+			}
+			return true
+		})
+		return false
+	})
+	sort.Strings(methods)
+	return methods
+}
+
+func TestCapabilitiesListImplementedMethods(t *testing.T) {
+	ws := NewWalletService()
+	caps, err := ws.Capabilities(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("Capabilities: %v", err)
+	}
+
+	listed := append([]string(nil), caps.Methods...)
+	sort.Strings(listed)
+	if got, want := strings.Join(listed, ","), strings.Join(dispatchedMethods(t), ","); got != want {
+		t.Fatalf("capabilities methods\n  %s\ndo not match the dispatcher\n  %s", got, want)
+	}
+
+	if caps.Features.PrivilegedKeyManager {
+		t.Fatal("privileged key manager is not implemented and must not be advertised")
+	}
+	if !caps.Features.Discovery || !caps.Features.Certificates {
+		t.Fatalf("features = %+v, want discovery and certificates", caps.Features)
+	}
+	if caps.Features.PermissionPrompts {
+		t.Fatal("no permission gate is set, so prompts must not be advertised")
+	}
+	if caps.Version == "" || caps.Network == "" {
+		t.Fatalf("version %q network %q, want both set", caps.Version, caps.Network)
+	}
+
+	if _, err := ws.CallWalletMethod("privilegedKeyManager", "{}", "example.com"); err == nil || !strings.Contains(err.Error(), "unknown wallet method") {
+		t.Fatalf("unlisted method error = %v, want unknown wallet method", err)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// defaultCertifierTimeout bounds each request to a certifier unless
+// WithCertifierTimeout says otherwise.
+const defaultCertifierTimeout = 30 * time.Second
+
+// ErrCertifierTimeout is returned when a certifier does not answer within
+// the certifier timeout.
+var ErrCertifierTimeout = errors.New("certifier timed out")
+
+// WithCertifierTimeout bounds each request acquireCertificate makes to a
+// certifier, body included, to d. Only the certifier's part of the call is
+// timed: checking the certificate and storing it are not. Zero leaves the
+// requests unbounded. The default is 30s.
+func WithCertifierTimeout(d time.Duration) WalletServiceOption {
+	return func(ws *WalletService) {
+		ws.certifierTimeout = d
+	}
+}
+
+// certifierTransport bounds every request it carries to timeout. The
+// wallet's auth client, which only talks to certifiers, goes through it.
+type certifierTransport struct {
+	base    http.RoundTripper
+	timeout time.Duration
+}
+
+func (t *certifierTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.timeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		err = t.timeoutError(ctx, req, err)
+		cancel()
+		return nil, err
+	}
+	resp.Body = &certifierBody{ReadCloser: resp.Body, ctx: ctx, cancel: cancel, req: req, t: t}
+	return resp, nil
+}
+
+// timeoutError reports err as ErrCertifierTimeout when it came from ctx
+// running out, rather than from the caller giving up.
+func (t *certifierTransport) timeoutError(ctx context.Context, req *http.Request, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) && req.Context().Err() == nil {
+		return fmt.Errorf("%w after %v: %s %s: %w", ErrCertifierTimeout, t.timeout, req.Method, req.URL.Redacted(), err)
+	}
+	return err
+}
+
+// certifierBody keeps a response's timeout running until its body is
+// read and closed.
+type certifierBody struct {
+	io.ReadCloser
+	ctx    context.Context
+	cancel context.CancelFunc
+	req    *http.Request
+	t      *certifierTransport
+}
+
+func (b *certifierBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF {
+		err = b.t.timeoutError(b.ctx, b.req, err)
+	}
+	return n, err
+}
+
+func (b *certifierBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
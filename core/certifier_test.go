@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCertifierTransportTimesOut(t *testing.T) {
+	stalled := make(chan struct{})
+	certifier := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow-body" {
+			w.Write([]byte("partial"))
+			w.(http.Flusher).Flush()
+		}
+		if r.URL.Path != "/fast" {
+			<-stalled
+		}
+	}))
+	defer certifier.Close()
+	defer close(stalled)
+	client := &http.Client{Transport: &certifierTransport{base: http.DefaultTransport, timeout: 100 * time.Millisecond}}
+
+	resp, err := client.Get(certifier.URL + "/fast")
+	if err != nil {
+		t.Fatalf("fast certifier: %v", err)
+	}
+	resp.Body.Close()
+
+	if _, err := client.Get(certifier.URL + "/slow"); !errors.Is(err, ErrCertifierTimeout) {
+		t.Fatalf("slow certifier: err = %v, want ErrCertifierTimeout", err)
+	}
+
+	resp, err = client.Get(certifier.URL + "/slow-body")
+	if err != nil {
+		t.Fatalf("slow body: %v", err)
+	}
+	defer resp.Body.Close()
+	if _, err := io.ReadAll(resp.Body); !errors.Is(err, ErrCertifierTimeout) {
+		t.Fatalf("slow body: err = %v, want ErrCertifierTimeout", err)
+	}
+
+	// A caller giving up is not the certifier timing out.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, certifier.URL+"/slow", nil)
+	if _, err := client.Do(req); err == nil || errors.Is(err, ErrCertifierTimeout) {
+		t.Fatalf("cancelled call: err = %v, want the caller's deadline", err)
+	}
+}
+
+func TestAcquireCertificateCertifierTimeout(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	stalled := make(chan struct{})
+	certifier := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-stalled
+	}))
+	defer certifier.Close()
+	defer close(stalled)
+
+	ws := NewWalletService(WithCertifierTimeout(200 * time.Millisecond))
+	if err := ws.InitializeWallet(newKey(t).Hex(), "test"); err != nil {
+		t.Fatalf("InitializeWallet: %v", err)
+	}
+	defer ws.ShutdownWallet()
+
+	args := `{"type":"` + base64.StdEncoding.EncodeToString(make([]byte, 32)) + `",` +
+		`"certifier":"` + newKey(t).PubKey().ToDERHex() + `",` +
+		`"acquisitionProtocol":"issuance","fields":{"name":"alice"},` +
+		`"certifierUrl":"` + certifier.URL + `"}`
+	start := time.Now()
+	_, err := ws.CallWalletMethod("acquireCertificate", args, "example.com")
+	if err == nil || !strings.Contains(err.Error(), "certifier timed out") {
+		t.Fatalf("err = %v, want the certifier to time out", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("acquireCertificate returned after %v", elapsed)
+	}
+}
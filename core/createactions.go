@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	sdktx "github.com/bsv-blockchain/go-sdk/transaction"
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/wallet"
+)
+
+// createActionsWorkers bounds how many actions of a batch are built at
+// once. Funding and storage writes serialize in the database, so more
+// workers add contention rather than throughput.
+const createActionsWorkers = 4
+
+// maxCreateActionsBatch is the most actions one createActions call takes.
+const maxCreateActionsBatch = 1000
+
+// CreateActionsArgs are the args of the createActions call.
+type CreateActionsArgs struct {
+	Actions []SDKCreateActionArgs `json:"actions"`
+}
+
+// CreateActionsItem is the outcome of one action in a createActions
+// batch: its result, or the error that stopped it.
+type CreateActionsItem struct {
+	Result *sdk.CreateActionResult `json:"result,omitempty"`
+	Error  string                  `json:"error,omitempty"`
+}
+
+// CreateActionsResult is the result of the createActions call, with one
+// item per requested action, in order.
+type CreateActionsResult struct {
+	Results []CreateActionsItem `json:"results"`
+}
+
+// CreateActionsError reports the actions of a batch that failed. The
+// others were created.
+type CreateActionsError struct {
+	Errs []error // one per action, nil where the action was created
+}
+
+func (e *CreateActionsError) Error() string {
+	var failed []string
+	for i, err := range e.Errs {
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("action %d: %v", i, err))
+		}
+	}
+	return fmt.Sprintf("%d of %d actions failed: %s", len(failed), len(e.Errs), strings.Join(failed, "; "))
+}
+
+func (e *CreateActionsError) Unwrap() []error {
+	var errs []error
+	for _, err := range e.Errs {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// CreateActions creates many actions concurrently. The results are in the
+// order of args, nil where an action failed; a failure does not stop the
+// rest of the batch, and is reported in the returned *CreateActionsError.
+// Actions not yet started when ctx is done fail with its error.
+func (ws *WalletService) CreateActions(ctx context.Context, args []sdk.CreateActionArgs, originator string) ([]*sdk.CreateActionResult, error) {
+	ws.mu.RLock()
+	w := ws.wallet
+	ws.mu.RUnlock()
+
+	if w == nil {
+		return nil, errWalletNotInitialized
+	}
+	ctx, cancel := ws.withOperationTimeout(ctx)
+	defer cancel()
+	return ws.createActions(ctx, w, args, originator)
+}
+
+func (ws *WalletService) createActions(ctx context.Context, w *wallet.Wallet, args []sdk.CreateActionArgs, originator string) ([]*sdk.CreateActionResult, error) {
+	if len(args) > maxCreateActionsBatch {
+		return nil, fmt.Errorf("too many actions: %d, limit is %d", len(args), maxCreateActionsBatch)
+	}
+	results := make([]*sdk.CreateActionResult, len(args))
+	errs := make([]error, len(args))
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(createActionsWorkers, len(args)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+				if err := checkTransactionSize(args[i]); err != nil {
+					errs[i] = err
+					continue
+				}
+				sats, err := spendSatoshis(args[i])
+				if err != nil {
+					errs[i] = err
+					continue
+				}
+				if results[i], errs[i] = w.CreateAction(ctx, args[i], originator); errs[i] == nil {
+					ws.actionCreated(results[i], sats)
+				}
+			}
+		}()
+	}
+	for i := range args {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return results, &CreateActionsError{Errs: errs}
+	}
+	return results, nil
+}
+
+// createAction creates the action args describe once the user approves
+// what it spends. Oversized transactions are rejected before prompting.
+func (ws *WalletService) createAction(ctx context.Context, w *wallet.Wallet, gate PermissionGate, method string, args SDKCreateActionArgs, origin string) (*sdk.CreateActionResult, error) {
+	if err := checkTransactionSize(args); err != nil {
+		return nil, err
+	}
+	totalSats, err := spendSatoshis(args)
+	if err != nil {
+		return nil, err
+	}
+	if totalSats > 0 {
+		extra := map[string]interface{}{
+			"description": args.Description,
+			"outputCount": len(args.Outputs),
+			"inputCount":  len(args.Inputs),
+		}
+		if len(args.Labels) > 0 {
+			extra["labels"] = args.Labels
+		}
+		if err := checkPermission(gate, method, origin, "spend", extra, totalSats,
+			fmt.Sprintf("Create transaction: %s (%d sats)", args.Description, totalSats)); err != nil {
+			return nil, err
+		}
+	}
+	created, err := w.CreateAction(ctx, args, origin)
+	if err != nil {
+		return nil, err
+	}
+	ws.actionCreated(created, totalSats)
+	return created, nil
+}
+
+// maxSatoshis is the total coin supply. No output, input or spend can be
+// worth more, so larger amounts are rejected rather than summed.
+const maxSatoshis = 21_000_000 * 100_000_000
+
+// ErrAmountOutOfRange reports an amount above the total coin supply.
+var ErrAmountOutOfRange = errors.New("amount exceeds the total coin supply")
+
+// spendSatoshis is what createAction args take out of the wallet: their
+// outputs, less the inputs the caller funds from inputBEEF. Every amount
+// and running total is checked against maxSatoshis, so the result cannot
+// wrap.
+func spendSatoshis(args SDKCreateActionArgs) (int64, error) {
+	var outputSats int64
+	for _, o := range args.Outputs {
+		if o.Satoshis > maxSatoshis || outputSats+int64(o.Satoshis) > maxSatoshis {
+			return 0, ErrAmountOutOfRange
+		}
+		outputSats += int64(o.Satoshis)
+	}
+	var inputSats int64
+	if args.InputBEEF != nil {
+		if beef, e := sdktx.NewBeefFromBytes(args.InputBEEF); e == nil {
+			for i := range args.Inputs {
+				op := args.Inputs[i].Outpoint
+				beefTx := beef.Transactions[op.Txid]
+				if beefTx == nil || beefTx.Transaction == nil {
+					continue
+				}
+				if int(op.Index) >= len(beefTx.Transaction.Outputs) {
+					continue
+				}
+				sats := beefTx.Transaction.Outputs[op.Index].Satoshis
+				if sats > maxSatoshis || inputSats+int64(sats) > maxSatoshis {
+					return 0, ErrAmountOutOfRange
+				}
+				inputSats += int64(sats)
+			}
+		}
+	}
+	return outputSats - inputSats, nil
+}
+
+// batchSpendSatoshis is what a createActions batch takes out of the
+// wallet: the sum of each action's spend. An action funded by its own
+// inputs counts as zero rather than offsetting the others.
+func batchSpendSatoshis(actions []SDKCreateActionArgs) (int64, error) {
+	var totalSats int64
+	for i, a := range actions {
+		sats, err := spendSatoshis(a)
+		if err != nil {
+			return 0, fmt.Errorf("action %d: %w", i, err)
+		}
+		if totalSats+max(sats, 0) > maxSatoshis {
+			return 0, fmt.Errorf("batch total: %w", ErrAmountOutOfRange)
+		}
+		totalSats += max(sats, 0)
+	}
+	return totalSats, nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+)
+
+func TestCreateActionsReportsPerActionErrors(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	gate := &countingGate{}
+	ws := NewWalletService()
+	ws.SetPermissionGate(gate)
+	if err := ws.InitializeWallet(newKey(t).Hex(), "test"); err != nil {
+		t.Fatalf("InitializeWallet: %v", err)
+	}
+	defer ws.ShutdownWallet()
+
+	payment := sdk.CreateActionArgs{
+		Description: "batch payment",
+		Outputs:     []sdk.CreateActionOutput{{LockingScript: make([]byte, 25), Satoshis: 1000, OutputDescription: "payment"}},
+	}
+	oversized := sdk.CreateActionArgs{
+		Description: "oversized data push",
+		Outputs:     []sdk.CreateActionOutput{{LockingScript: make([]byte, 11_000_000), OutputDescription: "data"}},
+	}
+	batch := []sdk.CreateActionArgs{payment, oversized, payment}
+
+	// The wallet is empty, so every action fails, each for its own reason.
+	results, err := ws.CreateActions(context.Background(), batch, "example.com")
+	var batchErr *CreateActionsError
+	if !errors.As(err, &batchErr) || len(results) != 3 || len(batchErr.Errs) != 3 {
+		t.Fatalf("CreateActions = %v, %v; want 3 results and a *CreateActionsError", results, err)
+	}
+	if !errors.Is(batchErr.Errs[1], ErrTransactionTooLarge) || errors.Is(batchErr.Errs[0], ErrTransactionTooLarge) || batchErr.Errs[2] == nil {
+		t.Fatalf("per-action errors = %v", batchErr.Errs)
+	}
+	if !errors.Is(err, ErrTransactionTooLarge) {
+		t.Fatal("batch error does not wrap the per-action errors")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ws.CreateActions(ctx, batch, "example.com"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled batch: err = %v, want context.Canceled", err)
+	}
+
+	if _, err := ws.CreateActions(context.Background(), make([]sdk.CreateActionArgs, maxCreateActionsBatch+1), "example.com"); err == nil || errors.As(err, &batchErr) {
+		t.Fatalf("oversized batch: err = %v, want it rejected whole", err)
+	}
+
+	argsJSON, _ := json.Marshal(CreateActionsArgs{Actions: []sdk.CreateActionArgs{payment, payment}})
+	out, err := ws.CallWalletMethod("createActions", string(argsJSON), "example.com")
+	if err != nil {
+		t.Fatalf("createActions: %v", err)
+	}
+	var result CreateActionsResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Results) != 2 || result.Results[0].Error == "" || result.Results[1].Error == "" {
+		t.Fatalf("createActions result = %s, want an error for each action", out)
+	}
+	if gate.calls != 1 {
+		t.Fatalf("batch prompted %d times, want once", gate.calls)
+	}
+}
+
+func TestCreateActionsRejectsOverflowingBatchBeforePrompting(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	gate := &countingGate{}
+	ws := NewWalletService()
+	ws.SetPermissionGate(gate)
+	if err := ws.InitializeWallet(newKey(t).Hex(), "test"); err != nil {
+		t.Fatalf("InitializeWallet: %v", err)
+	}
+	defer ws.ShutdownWallet()
+
+	payment := sdk.CreateActionArgs{
+		Description: "batch payment",
+		Outputs:     []sdk.CreateActionOutput{{LockingScript: make([]byte, 25), Satoshis: 1000, OutputDescription: "payment"}},
+	}
+	// Summed as int64, this action plus the payment wraps negative.
+	huge := sdk.CreateActionArgs{
+		Description: "huge payment",
+		Outputs:     []sdk.CreateActionOutput{{LockingScript: make([]byte, 25), Satoshis: 1<<63 - 500, OutputDescription: "payment"}},
+	}
+	// Each within the supply, together above it.
+	half := sdk.CreateActionArgs{
+		Description: "half the supply",
+		Outputs:     []sdk.CreateActionOutput{{LockingScript: make([]byte, 25), Satoshis: maxSatoshis/2 + 1, OutputDescription: "payment"}},
+	}
+	// Two outputs whose uint64 sum wraps to a small amount.
+	wrapping := sdk.CreateActionArgs{
+		Description: "wrapping outputs",
+		Outputs: []sdk.CreateActionOutput{
+			{LockingScript: make([]byte, 25), Satoshis: 1 << 63, OutputDescription: "payment"},
+			{LockingScript: make([]byte, 25), Satoshis: 1 << 63, OutputDescription: "payment"},
+		},
+	}
+
+	for name, batch := range map[string][]sdk.CreateActionArgs{
+		"wrapping total":   {huge, payment},
+		"above supply":     {half, half},
+		"wrapping outputs": {wrapping, payment},
+	} {
+		argsJSON, _ := json.Marshal(CreateActionsArgs{Actions: batch})
+		if _, err := ws.CallWalletMethod("createActions", string(argsJSON), "example.com"); !errors.Is(err, ErrAmountOutOfRange) {
+			t.Errorf("%s: err = %v, want ErrAmountOutOfRange", name, err)
+		}
+	}
+	argsJSON, _ := json.Marshal(wrapping)
+	if _, err := ws.CallWalletMethod("createAction", string(argsJSON), "example.com"); !errors.Is(err, ErrAmountOutOfRange) {
+		t.Errorf("createAction: err = %v, want ErrAmountOutOfRange", err)
+	}
+	if gate.calls != 0 {
+		t.Fatalf("out of range batches prompted %d times, want none", gate.calls)
+	}
+
+	// A batch within the supply reaches the gate for its full total.
+	argsJSON, _ = json.Marshal(CreateActionsArgs{Actions: []sdk.CreateActionArgs{half, payment}})
+	if _, err := ws.CallWalletMethod("createActions", string(argsJSON), "example.com"); err != nil {
+		t.Fatalf("createActions: %v", err)
+	}
+	if gate.calls != 1 {
+		t.Fatalf("batch prompted %d times, want once", gate.calls)
+	}
+}
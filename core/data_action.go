@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bsv-blockchain/go-sdk/script"
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+)
+
+// maxDataScriptSize is the largest data output script, in bytes, that
+// nodes relay under their default policy (maxscriptsizepolicy).
+const maxDataScriptSize = 500_000
+
+// ErrDataTooLarge is returned for data that does not fit in one output
+// under maxDataScriptSize.
+var ErrDataTooLarge = errors.New("data output too large")
+
+// CreateDataActionArgs are the args of the createDataAction call. Data
+// holds the payloads, base64-encoded in JSON.
+type CreateDataActionArgs struct {
+	ProtocolPrefix string   `json:"protocolPrefix,omitempty"`
+	Data           [][]byte `json:"data"`
+}
+
+// WithDefaultDataProtocol sets the protocol prefix, such as a B:// or
+// MAP address, pushed ahead of the payloads of data actions that do not
+// name one themselves.
+func WithDefaultDataProtocol(prefix string) WalletServiceOption {
+	return func(ws *WalletService) {
+		ws.dataProtocol = prefix
+	}
+}
+
+// CreateDataAction creates an action with one OP_FALSE OP_RETURN output
+// pushing protocolPrefix, or the default data protocol when it is empty,
+// followed by each payload. The wallet funds it as it does any action.
+func (ws *WalletService) CreateDataAction(ctx context.Context, protocolPrefix string, payloads [][]byte, originator string) (*sdk.CreateActionResult, error) {
+	ws.mu.RLock()
+	w := ws.wallet
+	ws.mu.RUnlock()
+
+	if w == nil {
+		return nil, errWalletNotInitialized
+	}
+	ctx, cancel := ws.withOperationTimeout(ctx)
+	defer cancel()
+	return ws.createDataAction(ctx, w, protocolPrefix, payloads, originator)
+}
+
+func (ws *WalletService) createDataAction(ctx context.Context, w actionCreator, protocolPrefix string, payloads [][]byte, originator string) (*sdk.CreateActionResult, error) {
+	args, err := ws.dataActionArgs(protocolPrefix, payloads)
+	if err != nil {
+		return nil, err
+	}
+	result, err := w.CreateAction(ctx, args, originator)
+	if err != nil {
+		return nil, err
+	}
+	ws.actionCreated(result, 0)
+	return result, nil
+}
+
+// dataActionArgs are the createAction args of a data action.
+func (ws *WalletService) dataActionArgs(protocolPrefix string, payloads [][]byte) (sdk.CreateActionArgs, error) {
+	if protocolPrefix == "" {
+		protocolPrefix = ws.dataProtocol
+	}
+	lockingScript, err := dataLockingScript(protocolPrefix, payloads)
+	if err != nil {
+		return sdk.CreateActionArgs{}, err
+	}
+	description := "Data"
+	if protocolPrefix != "" {
+		description = "Data for " + protocolPrefix
+	}
+	return sdk.CreateActionArgs{
+		Description: description,
+		Outputs: []sdk.CreateActionOutput{{
+			LockingScript:     lockingScript.Bytes(),
+			OutputDescription: description,
+		}},
+	}, nil
+}
+
+// dataLockingScript frames payloads as OP_FALSE OP_RETURN [prefix]
+// <payload>..., one push each.
+func dataLockingScript(prefix string, payloads [][]byte) (*script.Script, error) {
+	if len(payloads) == 0 {
+		return nil, errors.New("no data to write")
+	}
+	s := &script.Script{}
+	if err := s.AppendOpcodes(script.OpFALSE, script.OpRETURN); err != nil {
+		return nil, err
+	}
+	if prefix != "" {
+		if err := s.AppendPushDataString(prefix); err != nil {
+			return nil, err
+		}
+	}
+	for _, payload := range payloads {
+		if err := s.AppendPushData(payload); err != nil {
+			return nil, err
+		}
+		if len(*s) > maxDataScriptSize {
+			return nil, fmt.Errorf("%w: more than %d bytes", ErrDataTooLarge, maxDataScriptSize)
+		}
+	}
+	return s, nil
+}
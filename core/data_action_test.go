@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bsv-blockchain/go-sdk/script"
+)
+
+func TestCreateDataActionFramesPushes(t *testing.T) {
+	ws := NewWalletService(WithDefaultDataProtocol("19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut"))
+	w := &fakeActionWallet{}
+	payloads := [][]byte{[]byte("hello"), bytes.Repeat([]byte{0xab}, 300)}
+
+	if _, err := ws.createDataAction(context.Background(), w, "", payloads, "example.com"); err != nil {
+		t.Fatalf("createDataAction: %v", err)
+	}
+	if len(w.creates) != 1 || len(w.creates[0].Outputs) != 1 {
+		t.Fatalf("createAction calls = %+v, want one with one output", w.creates)
+	}
+	out := w.creates[0].Outputs[0]
+	if out.Satoshis != 0 {
+		t.Fatalf("data output carries %d satoshis", out.Satoshis)
+	}
+	chunks, err := script.DecodeScript(out.LockingScript, script.DecodeOptionsParseOpReturn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]byte{[]byte("19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut"), payloads[0], payloads[1]}
+	if len(chunks) != 2+len(want) || chunks[0].Op != script.OpFALSE || chunks[1].Op != script.OpRETURN {
+		t.Fatalf("script %x does not start OP_FALSE OP_RETURN with %d pushes", out.LockingScript, len(want))
+	}
+	for i, push := range want {
+		if !bytes.Equal(chunks[2+i].Data, push) {
+			t.Errorf("push %d = %x, want %x", i, chunks[2+i].Data, push)
+		}
+	}
+	if chunks[4].Op != script.OpPUSHDATA2 {
+		t.Errorf("300-byte push uses opcode %#x, want OP_PUSHDATA2", chunks[4].Op)
+	}
+
+	// A prefix given by the caller replaces the default.
+	if _, err := ws.createDataAction(context.Background(), w, "1PuQa7K62MiKCtssSLKy1kh56WWU7MtUR5", payloads[:1], "example.com"); err != nil {
+		t.Fatalf("createDataAction: %v", err)
+	}
+	if chunks, _ := script.DecodeScript(w.creates[1].Outputs[0].LockingScript, script.DecodeOptionsParseOpReturn); string(chunks[2].Data) != "1PuQa7K62MiKCtssSLKy1kh56WWU7MtUR5" {
+		t.Fatalf("prefix = %q, want the caller's", chunks[2].Data)
+	}
+}
+
+func TestCreateDataActionSizeLimit(t *testing.T) {
+	ws := NewWalletService()
+	w := &fakeActionWallet{}
+
+	big := make([]byte, maxDataScriptSize)
+	if _, err := ws.createDataAction(context.Background(), w, "", [][]byte{big}, "example.com"); !errors.Is(err, ErrDataTooLarge) {
+		t.Fatalf("err = %v, want ErrDataTooLarge", err)
+	}
+	if _, err := ws.createDataAction(context.Background(), w, "", nil, "example.com"); err == nil {
+		t.Fatal("expected an action without data to be rejected")
+	}
+	if len(w.creates) != 0 {
+		t.Fatal("a rejected data action reached createAction")
+	}
+}
+
+func TestCreateDataActionDispatch(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	gate := &countingGate{}
+	ws := NewWalletService(WithDefaultDataProtocol("19HxigV4QyBv3tHpQVcUEQyq1pzZVdoAut"))
+	ws.SetPermissionGate(gate)
+	if err := ws.InitializeWallet(newKey(t).Hex(), "test"); err != nil {
+		t.Fatalf("InitializeWallet: %v", err)
+	}
+	defer ws.ShutdownWallet()
+
+	big, _ := json.Marshal(CreateDataActionArgs{Data: [][]byte{make([]byte, maxDataScriptSize)}})
+	if _, err := ws.CallWalletMethod("createDataAction", string(big), "example.com"); !errors.Is(err, ErrDataTooLarge) {
+		t.Fatalf("oversized data: err = %v, want ErrDataTooLarge", err)
+	}
+	// The wallet is empty, so the action reaches it and cannot be funded.
+	_, err := ws.CallWalletMethod("createDataAction", `{"data":["aGVsbG8="]}`, "example.com")
+	if err == nil || strings.Contains(err.Error(), "unknown wallet method") || strings.Contains(err.Error(), "invalid args") {
+		t.Fatalf("createDataAction: err = %v, want a funding error", err)
+	}
+	if gate.calls != 0 {
+		t.Fatalf("data action prompted %d times, want none for an output without satoshis", gate.calls)
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/defs"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/wdk"
+)
+
+const (
+	// recentErrorsKept is how many failed wallet calls the dump shows.
+	recentErrorsKept = 20
+	// recentErrorMaxLen truncates the message of each.
+	recentErrorMaxLen = 300
+)
+
+// DebugDump is the diagnostic bundle served at /debug/dump for support
+// sessions. It holds no key material, passphrases, tokens or credentials.
+type DebugDump struct {
+	Version string `json:"version"`
+	Network string `json:"network"`
+	// Ready is empty when the wallet is ready, else why it is not.
+	Ready string `json:"ready"`
+	// PendingSignActions counts actions created but not yet signed.
+	PendingSignActions int64         `json:"pendingSignActions"`
+	Caches             DebugCaches   `json:"caches"`
+	RecentErrors       []RecentError `json:"recentErrors"`
+	Config             DebugConfig   `json:"config"`
+}
+
+// DebugCaches describes the discovery caches.
+type DebugCaches struct {
+	// OverlayAnswers are those recorded for the next FlushCaches, and
+	// FlushedAnswers those loaded from the last one.
+	OverlayAnswers int `json:"overlayAnswers"`
+	FlushedAnswers int `json:"flushedAnswers"`
+	// DiscoverCacheAge is how long the toolbox's discovery cache has been
+	// filling since it was last cleared.
+	DiscoverCacheAge string `json:"discoverCacheAge"`
+}
+
+// DebugConfig is the effective configuration, secrets left out: a proxy
+// URL is shown without its password, and storage encryption and the
+// privileged key manager only as whether they are set.
+type DebugConfig struct {
+	FeeModel                 defs.FeeModel `json:"feeModel"`
+	MinFeeRate               int64         `json:"minFeeRate"`
+	OperationTimeout         string        `json:"operationTimeout"`
+	SlowOpThreshold          string        `json:"slowOpThreshold"`
+	CertifierTimeout         string        `json:"certifierTimeout"`
+	DiscoverCacheMaxAge      string        `json:"discoverCacheMaxAge"`
+	MaxUnfailRetries         int           `json:"maxUnfailRetries"`
+	DataProtocol             string        `json:"dataProtocol,omitempty"`
+	HTTPProxy                string        `json:"httpProxy,omitempty"`
+	CustomLookupResolver     bool          `json:"customLookupResolver"`
+	FallbackStorages         int           `json:"fallbackStorages"`
+	StorageEncryption        bool          `json:"storageEncryption"`
+	PrivilegedKeyManager     bool          `json:"privilegedKeyManager"`
+	PermissionGate           bool          `json:"permissionGate"`
+	WarmUpTimeout            string        `json:"warmUpTimeout,omitempty"`
+	MaxInFlightPerOriginator int           `json:"maxInFlightPerOriginator"`
+}
+
+// RecentError is a wallet call the HTTP server answered with an error.
+type RecentError struct {
+	Time    time.Time `json:"time"`
+	Method  string    `json:"method"`
+	Origin  string    `json:"origin"`
+	Message string    `json:"message"`
+}
+
+// errorRing keeps the last recentErrorsKept errors.
+type errorRing struct {
+	mu   sync.Mutex
+	errs []RecentError
+	next int
+}
+
+func (r *errorRing) add(e RecentError) {
+	if len(e.Message) > recentErrorMaxLen {
+		e.Message = e.Message[:recentErrorMaxLen] + "…"
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.errs) < recentErrorsKept {
+		r.errs = append(r.errs, e)
+		return
+	}
+	r.errs[r.next] = e
+	r.next = (r.next + 1) % recentErrorsKept
+}
+
+// list returns the errors kept, oldest first.
+func (r *errorRing) list() []RecentError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	errs := make([]RecentError, 0, len(r.errs))
+	errs = append(errs, r.errs[r.next:]...)
+	return append(errs, r.errs[:r.next]...)
+}
+
+// debugDump collects the wallet's part of the dump.
+func (ws *WalletService) debugDump(ctx context.Context) *DebugDump {
+	ws.mu.RLock()
+	store, lookups := ws.storage, ws.lookups
+	d := &DebugDump{
+		Version: defs.Version,
+		Network: string(ws.chain),
+		Config: DebugConfig{
+			FeeModel:             ws.effectiveFeeModel(),
+			MinFeeRate:           ws.minFeeRate,
+			OperationTimeout:     ws.opTimeout.String(),
+			SlowOpThreshold:      ws.slowOpThreshold.String(),
+			CertifierTimeout:     ws.certifierTimeout.String(),
+			DiscoverCacheMaxAge:  ws.discoverMaxAge.String(),
+			MaxUnfailRetries:     ws.maxUnfailRetries,
+			DataProtocol:         ws.dataProtocol,
+			CustomLookupResolver: ws.lookupResolver != nil,
+			FallbackStorages:     len(ws.fallbackStorage),
+			StorageEncryption:    ws.storageEncryption != nil,
+			PrivilegedKeyManager: ws.privilegedKeys != nil,
+			PermissionGate:       ws.permissionGate != nil,
+		},
+	}
+	if ws.httpProxy != nil {
+		d.Config.HTTPProxy = ws.httpProxy.Redacted()
+	}
+	if !ws.discoverCacheSince.IsZero() {
+		d.Caches.DiscoverCacheAge = ws.now().Sub(ws.discoverCacheSince).Round(time.Second).String()
+	}
+	ws.mu.RUnlock()
+
+	if ws.warmUpTimeout > 0 {
+		d.Config.WarmUpTimeout = ws.warmUpTimeout.String()
+	}
+	if err := ws.Ready(ctx); err != nil {
+		d.Ready = err.Error()
+	}
+	if lookups != nil {
+		d.Caches.OverlayAnswers, d.Caches.FlushedAnswers = lookups.stats()
+	}
+	if store != nil {
+		n, err := store.TransactionEntity().Read().Status().Equals(wdk.TxStatusUnsigned).Count(ctx)
+		if err != nil {
+			ws.logger.Warn("Failed to count actions waiting for signAction", "error", err)
+		}
+		d.PendingSignActions = n
+	}
+	return d
+}
+
+// SetDebugToken enables GET /debug/dump for requests bearing token. With
+// no token, the default, the endpoint is not served.
+func (s *HTTPServer) SetDebugToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.debugToken = token
+}
+
+// handleDebugDump serves the diagnostic bundle to holders of the debug
+// token.
+func (s *HTTPServer) handleDebugDump(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	token, ws, limiter := s.debugToken, s.walletSvc, s.limiter
+	s.mu.RUnlock()
+
+	if token == "" {
+		http.NotFound(w, r)
+		return
+	}
+	bearer := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+		s.writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	if ws == nil {
+		s.writeError(w, http.StatusServiceUnavailable, "Wallet not initialized")
+		return
+	}
+
+	dump := ws.debugDump(r.Context())
+	dump.RecentErrors = s.recentErrors.list()
+	if limiter != nil {
+		dump.Config.MaxInFlightPerOriginator = limiter.max
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dump)
+}
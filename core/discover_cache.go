@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/wallet"
+)
+
+// WithDiscoverCacheMaxAge bounds how old a cached discovery result may be
+// when it is served, whatever the toolbox's own cache TTL, so that a
+// long-running wallet notices changes in certifier trust. The cache
+// cannot be cleared entry by entry, so once its oldest possible entry is
+// older than d the whole cache is dropped on the next discovery.
+func WithDiscoverCacheMaxAge(d time.Duration) WalletServiceOption {
+	return func(ws *WalletService) {
+		ws.discoverMaxAge = d
+	}
+}
+
+// InvalidateDiscoverCache drops the certificates the wallet has cached from
+// discoverByIdentityKey and discoverByAttributes, so the next discovery
+// queries the overlay again.
+//
+// The toolbox keeps that cache private to its wallet, so the wallet is
+// replaced by a fresh one over the same storage and services. Calls
+// already running finish on the old wallet. For the same reason there is
+// no clearing the entries of a single identity: the whole cache goes.
+func (ws *WalletService) InvalidateDiscoverCache() error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if ws.wallet == nil {
+		return errWalletNotInitialized
+	}
+	w, err := ws.newWallet()
+	if err != nil {
+		return fmt.Errorf("failed to clear discovery cache: %w", err)
+	}
+	ws.wallet = w
+	ws.lookups.clear()
+	ws.discoverCacheSince = ws.now()
+	ws.logger.Info("Discovery cache cleared")
+	return nil
+}
+
+// discoverWallet returns the wallet to run a discovery on: w, or, when w's
+// cache may hold entries past the max age, a replacement with an empty
+// cache over the same storage and services.
+func (ws *WalletService) discoverWallet(w *wallet.Wallet) *wallet.Wallet {
+	if ws.discoverMaxAge <= 0 {
+		return w
+	}
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	// A wallet reloaded since w was taken has a cache of its own.
+	if ws.wallet != w || ws.now().Sub(ws.discoverCacheSince) < ws.discoverMaxAge {
+		return w
+	}
+	fresh, err := ws.newWallet()
+	if err != nil {
+		ws.logger.Warn("Failed to clear discovery cache past its max age", "error", err)
+		return w
+	}
+	ws.wallet = fresh
+	ws.lookups.clear()
+	ws.discoverCacheSince = ws.now()
+	ws.logger.Debug("Discovery cache past its max age, cleared", "maxAge", ws.discoverMaxAge)
+	return fresh
+}
+
+// lookupCacheTTL is how long flushed overlay answers stay fresh: the
+// toolbox's TTL, or the max age if shorter.
+func (ws *WalletService) lookupCacheTTL() time.Duration {
+	if ws.discoverMaxAge > 0 && ws.discoverMaxAge < lookupCacheTTL {
+		return ws.discoverMaxAge
+	}
+	return lookupCacheTTL
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-sdk/overlay"
+	"github.com/bsv-blockchain/go-sdk/overlay/lookup"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/defs"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/wallet"
+)
+
+func TestInvalidateDiscoverCache(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	// An identity overlay that finds nothing and counts its queries.
+	var lookups atomic.Int32
+	overlayHost := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"type":"output-list","outputs":[]}`))
+	}))
+	defer overlayHost.Close()
+
+	rootKey := newKey(t)
+	ws := NewWalletService()
+	pointDiscoveryAt(t, ws, rootKey, overlayHost.URL)
+	defer ws.ShutdownWallet()
+
+	subject := newKey(t).PubKey().ToDERHex()
+	discover := func() {
+		t.Helper()
+		if _, err := ws.CallWalletMethod("discoverByIdentityKey", `{"identityKey":"`+subject+`"}`, "example.com"); err != nil {
+			t.Fatalf("discoverByIdentityKey: %v", err)
+		}
+	}
+
+	discover()
+	discover()
+	if n := lookups.Load(); n != 1 {
+		t.Fatalf("%d overlay lookups for two discoveries, want 1 with the second cached", n)
+	}
+
+	if err := ws.InvalidateDiscoverCache(); err != nil {
+		t.Fatalf("InvalidateDiscoverCache: %v", err)
+	}
+	discover()
+	if n := lookups.Load(); n != 2 {
+		t.Fatalf("%d overlay lookups, want the discovery after invalidation to query again", n)
+	}
+
+	// The replacement wallet keeps the identity.
+	if got, want := identityKeyOf(t, ws), `{"publicKey":"`+rootKey.PubKey().ToDERHex()+`"}`; got != want {
+		t.Fatalf("identity after invalidation = %s, want %s", got, want)
+	}
+}
+
+func TestDiscoverCacheMaxAge(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	var lookups atomic.Int32
+	overlayHost := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"type":"output-list","outputs":[]}`))
+	}))
+	defer overlayHost.Close()
+
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	ws := NewWalletService(WithDiscoverCacheMaxAge(time.Hour))
+	ws.now = func() time.Time { return clock }
+	pointDiscoveryAt(t, ws, newKey(t), overlayHost.URL)
+	defer ws.ShutdownWallet()
+
+	subject := newKey(t).PubKey().ToDERHex()
+	discover := func(want int32) {
+		t.Helper()
+		if _, err := ws.CallWalletMethod("discoverByIdentityKey", `{"identityKey":"`+subject+`"}`, "example.com"); err != nil {
+			t.Fatalf("discoverByIdentityKey: %v", err)
+		}
+		if n := lookups.Load(); n != want {
+			t.Fatalf("%d overlay lookups at %v, want %d", n, clock, want)
+		}
+	}
+
+	discover(1)
+	clock = clock.Add(59 * time.Minute)
+	discover(1)
+	clock = clock.Add(time.Minute)
+	discover(2)
+	discover(2)
+}
+
+// pointDiscoveryAt initializes ws with rootKey and has its discovery, in
+// the wallet and the ones that replace it, go to the overlay at url.
+func pointDiscoveryAt(t *testing.T, ws *WalletService, rootKey *ec.PrivateKey, url string) {
+	t.Helper()
+	if err := ws.InitializeWallet(rootKey.Hex(), "test"); err != nil {
+		t.Fatalf("InitializeWallet: %v", err)
+	}
+
+	ws.mu.Lock()
+	store, services := ws.storage, ws.services
+	ws.newWallet = func() (*wallet.Wallet, error) {
+		return wallet.New(defs.NetworkTestnet, rootKey.Hex(), store,
+			wallet.WithServices(services),
+			wallet.WithLookupResolver(lookup.NewLookupResolver(&lookup.LookupResolver{
+				NetworkPreset: overlay.NetworkTestnet,
+				HostOverrides: map[string][]string{"ls_identity": {url}},
+			})),
+		)
+	}
+	w, err := ws.newWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws.wallet = w
+	ws.mu.Unlock()
+}
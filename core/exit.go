@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+)
+
+// Exit codes of the headless daemon, for supervisors to tell why it
+// stopped. Bad flags exit with 1 before the daemon starts.
+const (
+	exitClean          = 0
+	exitFailure        = 1
+	exitKeyLoad        = 3
+	exitStorage        = 4
+	exitStartupTimeout = 5
+	exitHTTPServer     = 6
+)
+
+// Shutdown reasons, logged as reason= alongside the exit code.
+const (
+	reasonSignal         = "signal"
+	reasonKeyLoad        = "key-load"
+	reasonStorage        = "storage"
+	reasonStartupTimeout = "startup-timeout"
+	reasonWarmUp         = "warm-up"
+	reasonHTTPServer     = "http-server"
+	reasonFatal          = "fatal"
+)
+
+// exitError is why runHeadless stopped early, and the code to exit with.
+type exitError struct {
+	reason string
+	code   int
+	err    error
+}
+
+func (e *exitError) Error() string { return e.reason + ": " + e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// shutdownCode logs why the daemon is stopping, as an event=shutdown line
+// with the reason and exit code, and returns the code. A nil err is a
+// shutdown on sig.
+func shutdownCode(logger *slog.Logger, err error, sig os.Signal) int {
+	if err == nil {
+		logger.Info("Shutdown", "event", "shutdown", "reason", reasonSignal, "signal", sig, "code", exitClean)
+		return exitClean
+	}
+	reason, code := reasonFatal, exitFailure
+	var exit *exitError
+	if errors.As(err, &exit) {
+		reason, code = exit.reason, exit.code
+	}
+	logger.Error("Shutdown", "event", "shutdown", "reason", reason, "code", code, "error", err)
+	return code
+}
+
+// initFailure is the exitError for a wallet that failed to initialize.
+func initFailure(err error) *exitError {
+	var storage *storageError
+	if errors.As(err, &storage) {
+		return &exitError{reason: reasonStorage, code: exitStorage, err: err}
+	}
+	return &exitError{reason: reasonFatal, code: exitFailure, err: err}
+}
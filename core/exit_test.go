@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/wallet"
+)
+
+func TestKeyLoadFailureExitCode(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GEBUNDEN_PRIVATE_KEY", "")
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+
+	missing := filepath.Join(t.TempDir(), "wallet-identity.json")
+	sig, err := runHeadless(logger, false, missing, nil, "failover", "", defaultHTTPListen, defaultHTTPPort, 0, "", 0)
+	if err == nil {
+		t.Fatal("expected a missing key file to stop the daemon")
+	}
+	if code := shutdownCode(logger, err, sig); code != exitKeyLoad {
+		t.Fatalf("exit code %d, want %d", code, exitKeyLoad)
+	}
+	if !strings.Contains(logs.String(), "event=shutdown reason=key-load code=3") {
+		t.Fatalf("no shutdown line for the key-load failure in:\n%s", logs.String())
+	}
+}
+
+func TestShutdownCodes(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	storageFailure := fmt.Errorf("failed to migrate storage: %w", &storageError{errors.New("disk I/O error")})
+	for name, tc := range map[string]struct {
+		err  error
+		want int
+	}{
+		"signal":          {nil, exitClean},
+		"storage":         {initFailure(fmt.Errorf("failed to initialize wallet: %w", storageFailure)), exitStorage},
+		"other init":      {initFailure(errors.New("invalid network")), exitFailure},
+		"startup timeout": {&exitError{reason: reasonStartupTimeout, code: exitStartupTimeout, err: errors.New("slow")}, exitStartupTimeout},
+		"unclassified":    {errors.New("boom"), exitFailure},
+	} {
+		if got := shutdownCode(logger, tc.err, syscall.SIGTERM); got != tc.want {
+			t.Errorf("%s: exit code %d, want %d", name, got, tc.want)
+		}
+	}
+}
+
+func TestSignalDuringWarmUpShutsDownCleanly(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GEBUNDEN_PRIVATE_KEY", newKey(t).Hex())
+	t.Setenv("GEBUNDEN_NETWORK", "test")
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+
+	// An overlay that never answers, under a warm-up that never gives up.
+	started := make(chan struct{})
+	var once sync.Once
+	deadOverlay := func(ws *WalletService) {
+		ws.warmUpRetry = 10 * time.Millisecond
+		ws.warmUpChecks = []warmUpCheck{{"discovery", func(context.Context, *wallet.Wallet) error {
+			once.Do(func() { close(started) })
+			return errors.New("overlay not reachable")
+		}}}
+	}
+	type result struct {
+		sig os.Signal
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		sig, err := runHeadless(logger, false, "", nil, "failover", "", defaultHTTPListen, 0, 0, "", 0, WithWarmUp(0), deadOverlay)
+		done <- result{sig, err}
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(10 * time.Second):
+		t.Fatal("warm-up never started")
+	}
+	syscall.Kill(os.Getpid(), syscall.SIGTERM)
+	var r result
+	select {
+	case r = <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("SIGTERM during warm-up did not stop the daemon")
+	}
+	if r.sig != syscall.SIGTERM || r.err != nil {
+		t.Fatalf("runHeadless = %v, %v, want SIGTERM and no error", r.sig, r.err)
+	}
+	if code := shutdownCode(logger, r.err, r.sig); code != exitClean {
+		t.Fatalf("exit code %d, want %d", code, exitClean)
+	}
+	if !strings.Contains(logs.String(), "Goodbye") || !strings.Contains(logs.String(), "event=shutdown") {
+		t.Fatalf("no clean shutdown in:\n%s", logs.String())
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/defs"
+)
+
+// defaultFeeModel is the fee rate the wallet pays unless configured otherwise.
+var defaultFeeModel = defs.FeeModel{Type: defs.SatPerKB, Value: 100}
+
+// WithMinFeeRate sets a floor, in satoshis per kB, under the fee rate
+// CreateAction pays. Whatever the fee model says, fees are computed at no
+// less than this rate.
+func WithMinFeeRate(satPerKB int64) WalletServiceOption {
+	return func(ws *WalletService) {
+		ws.minFeeRate = satPerKB
+	}
+}
+
+// effectiveFeeModel returns the fee model raised to the configured floor,
+// logging a warning when the floor takes effect.
+func (ws *WalletService) effectiveFeeModel() defs.FeeModel {
+	model := ws.feeModel
+	if model.Type == "" {
+		model = defaultFeeModel
+	}
+	if ws.minFeeRate > 0 && model.Value < ws.minFeeRate {
+		ws.logger.Warn("Fee rate below the configured floor, using the floor",
+			"feeRate", model.Value, "minFeeRate", ws.minFeeRate, "unit", string(model.Type))
+		model.Value = ws.minFeeRate
+	}
+	return model
+}
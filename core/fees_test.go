@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/defs"
+)
+
+func TestMinFeeRateFloorsFeeModel(t *testing.T) {
+	var logs bytes.Buffer
+	ws := NewWalletService(WithMinFeeRate(50))
+	ws.logger = slog.New(slog.NewTextHandler(&logs, nil))
+	ws.feeModel = defs.FeeModel{Type: defs.SatPerKB, Value: 1}
+
+	model := ws.effectiveFeeModel()
+	if model.Value != 50 {
+		t.Fatalf("fee rate = %d sat/kB, want the 50 sat/kB floor", model.Value)
+	}
+	// A 250-byte transaction would pay 1 sat at the model's rate; the
+	// funder rounds size*rate/1000 up, so the floor makes it 13.
+	if fee := (250*model.Value + 999) / 1000; fee != 13 {
+		t.Fatalf("fee for 250 bytes = %d, want 13", fee)
+	}
+	if !strings.Contains(logs.String(), "below the configured floor") {
+		t.Fatalf("expected a warning when the floor applies, got %q", logs.String())
+	}
+
+	logs.Reset()
+	ws.feeModel = defs.FeeModel{Type: defs.SatPerKB, Value: 100}
+	if model := ws.effectiveFeeModel(); model.Value != 100 {
+		t.Fatalf("fee rate = %d sat/kB, want the model's 100 when above the floor", model.Value)
+	}
+	if logs.Len() != 0 {
+		t.Fatalf("unexpected warning when above the floor: %q", logs.String())
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/wdk"
+)
+
+// runGenkey implements `gebunden genkey`: create a new wallet identity file
+// and print its identity key.
+func runGenkey(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("genkey", flag.ContinueOnError)
+	out := fs.String("out", "", "Write the identity file here (default ~/.gebunden/wallet-identity.json)")
+	network := fs.String("network", "mainnet", "Network the key is for: mainnet, testnet or regtest")
+	encrypt := fs.Bool("encrypt", false, "Encrypt the key with a passphrase (from GEBUNDEN_KEY_PASSPHRASE or a prompt)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// The name is written as given; loadPrivateKey accepts the same ones.
+	if _, err := normalizeNetwork(*network); err != nil {
+		return fmt.Errorf("-network: %w", err)
+	}
+
+	path := *out
+	if path == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to get home directory: %w", err)
+		}
+		path = filepath.Join(homeDir, ".gebunden", "wallet-identity.json")
+	}
+
+	var passphrase []byte
+	if *encrypt {
+		p, err := readPassphrase("New passphrase: ")
+		if err != nil {
+			return err
+		}
+		defer clear(p)
+		if len(p) == 0 {
+			return errors.New("passphrase must not be empty")
+		}
+		passphrase = p
+	}
+
+	identityKey, err := generateIdentity(path, *network, *encrypt, passphrase)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, identityKey)
+	return nil
+}
+
+// generateIdentity writes a new identity file for a fresh key to path,
+// refusing to replace an existing file, and returns the identity key.
+func generateIdentity(path, network string, encrypt bool, passphrase []byte) (string, error) {
+	key, err := ec.NewPrivateKey()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	rootKeyHex := key.Hex()
+	identityKey, err := wdk.IdentityKey(rootKeyHex)
+	if err != nil {
+		return "", fmt.Errorf("failed to derive identity key: %w", err)
+	}
+
+	identity := walletIdentity{RootKeyHex: rootKeyHex, IdentityKey: identityKey, Network: network}
+	if encrypt {
+		identity, err = encryptIdentity(rootKeyHex, identityKey, network, passphrase)
+		if err != nil {
+			return "", err
+		}
+	}
+	data, err := json.MarshalIndent(identity, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal identity: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", fmt.Errorf("failed to create identity directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		return "", fmt.Errorf("%s already exists; refusing to overwrite it", path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		os.Remove(path)
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return identityKey, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/wdk"
+)
+
+func TestGenkeyWritesLoadableIdentity(t *testing.T) {
+	t.Setenv("GEBUNDEN_PRIVATE_KEY", "")
+	path := filepath.Join(t.TempDir(), "keys", "wallet-identity.json")
+
+	var stdout bytes.Buffer
+	if err := runGenkey([]string{"-out", path, "-network", "testnet"}, &stdout); err != nil {
+		t.Fatalf("genkey: %v", err)
+	}
+	printed := strings.TrimSpace(stdout.String())
+
+	rootKey, network, _, err := loadPrivateKey(path)
+	if err != nil {
+		t.Fatalf("loadPrivateKey: %v", err)
+	}
+	if network != "test" {
+		t.Fatalf("network = %q, want test", network)
+	}
+	if identityKey, _ := wdk.IdentityKey(rootKey); identityKey != printed {
+		t.Fatalf("printed identity %q does not match the saved key's %q", printed, identityKey)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("identity file mode = %v (err %v), want 0600", info.Mode().Perm(), err)
+	}
+
+	before, _ := os.ReadFile(path)
+	if err := runGenkey([]string{"-out", path}, &stdout); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("second genkey err = %v, want a refusal to overwrite", err)
+	}
+	if after, _ := os.ReadFile(path); !bytes.Equal(before, after) {
+		t.Fatal("existing identity file was modified")
+	}
+
+	if err := runGenkey([]string{"-out", path + ".2", "-network", "stn"}, &stdout); err == nil {
+		t.Fatal("expected an unknown network to be rejected")
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Grant is a standing approval for every request matching Key.
+type Grant struct {
+	Key string `json:"key"`
+	// Expires is when the grant lapses; nil means it never does.
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+func (g Grant) expired(now time.Time) bool {
+	return g.Expires != nil && now.After(*g.Expires)
+}
+
+// GrantStore holds grants keyed by grantKey. The bridge keeps its remembered
+// approvals in a store of the same shape and file format, so pointing both
+// at one file shares grants between them.
+type GrantStore interface {
+	// Put stores a grant for key lasting ttl; a zero ttl never expires.
+	Put(key string, ttl time.Duration) error
+	// Get returns the unexpired grant for key, if any.
+	Get(key string) (Grant, bool, error)
+	// List returns every unexpired grant, sorted by key.
+	List() ([]Grant, error)
+	// Revoke removes the grant for key, if any.
+	Revoke(key string) error
+}
+
+func newGrant(key string, ttl time.Duration) Grant {
+	g := Grant{Key: key}
+	if ttl > 0 {
+		expires := time.Now().Add(ttl)
+		g.Expires = &expires
+	}
+	return g
+}
+
+// liveGrants returns the unexpired grants in m, sorted by key.
+func liveGrants(m map[string]Grant) []Grant {
+	now := time.Now()
+	grants := make([]Grant, 0, len(m))
+	for _, g := range m {
+		if !g.expired(now) {
+			grants = append(grants, g)
+		}
+	}
+	sort.Slice(grants, func(i, j int) bool { return grants[i].Key < grants[j].Key })
+	return grants
+}
+
+// ---------------------------------------------------------------------------
+// In-memory store
+// ---------------------------------------------------------------------------
+
+// MemoryGrantStore keeps grants for the life of the process.
+type MemoryGrantStore struct {
+	mu     sync.Mutex
+	grants map[string]Grant
+}
+
+// NewMemoryGrantStore creates an empty in-memory grant store.
+func NewMemoryGrantStore() *MemoryGrantStore {
+	return &MemoryGrantStore{grants: make(map[string]Grant)}
+}
+
+func (s *MemoryGrantStore) Put(key string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.grants[key] = newGrant(key, ttl)
+	return nil
+}
+
+func (s *MemoryGrantStore) Get(key string) (Grant, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, ok := s.grants[key]
+	if !ok {
+		return Grant{}, false, nil
+	}
+	if g.expired(time.Now()) {
+		delete(s.grants, key)
+		return Grant{}, false, nil
+	}
+	return g, true, nil
+}
+
+func (s *MemoryGrantStore) List() ([]Grant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return liveGrants(s.grants), nil
+}
+
+func (s *MemoryGrantStore) Revoke(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.grants, key)
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// File store
+// ---------------------------------------------------------------------------
+
+// FileGrantStore keeps grants in a JSON file so they survive restarts. Every
+// call rereads the file, so grants written by another process sharing it
+// (such as the bridge) are seen immediately. Writes replace the file
+// atomically, and hold an advisory lock on a ".lock" file beside it from
+// reading the grants to replacing them, so concurrent writers in other
+// processes do not lose each other's grants.
+type FileGrantStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileGrantStore returns a store backed by the file at path, which is
+// created on the first write.
+func NewFileGrantStore(path string) *FileGrantStore {
+	return &FileGrantStore{path: path}
+}
+
+func (s *FileGrantStore) load() (map[string]Grant, error) {
+	grants := make(map[string]Grant)
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return grants, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read grants: %w", err)
+	}
+	var list []Grant
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse grants %s: %w", s.path, err)
+	}
+	for _, g := range list {
+		grants[g.Key] = g
+	}
+	return grants, nil
+}
+
+// lock takes the store's lock, in this process and on its lock file, and
+// returns the function releasing it.
+func (s *FileGrantStore) lock() (func(), error) {
+	s.mu.Lock()
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("failed to create grants directory: %w", err)
+	}
+	f, err := os.OpenFile(s.path+".lock", os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("failed to open grants lock: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		s.mu.Unlock()
+		return nil, fmt.Errorf("failed to lock grants: %w", err)
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+		s.mu.Unlock()
+	}, nil
+}
+
+// update applies fn to the grants under the lock and saves them if fn
+// reports a change.
+func (s *FileGrantStore) update(fn func(map[string]Grant) bool) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	grants, err := s.load()
+	if err != nil {
+		return err
+	}
+	if !fn(grants) {
+		return nil
+	}
+	return s.save(grants)
+}
+
+// save writes the unexpired grants in m, dropping expired ones, to a
+// temporary file it then renames over the grants file.
+func (s *FileGrantStore) save(m map[string]Grant) error {
+	data, err := json.MarshalIndent(liveGrants(m), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal grants: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write grants: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write grants: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write grants: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace grants: %w", err)
+	}
+	return nil
+}
+
+func (s *FileGrantStore) Put(key string, ttl time.Duration) error {
+	return s.update(func(grants map[string]Grant) bool {
+		grants[key] = newGrant(key, ttl)
+		return true
+	})
+}
+
+// Get reads without the lock: writers replace the file whole, so it sees
+// the grants as they were either before or after a write.
+func (s *FileGrantStore) Get(key string) (Grant, bool, error) {
+	grants, err := s.load()
+	if err != nil {
+		return Grant{}, false, err
+	}
+	g, ok := grants[key]
+	if !ok || g.expired(time.Now()) {
+		return Grant{}, false, nil
+	}
+	return g, true, nil
+}
+
+func (s *FileGrantStore) List() ([]Grant, error) {
+	grants, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return liveGrants(grants), nil
+}
+
+func (s *FileGrantStore) Revoke(key string) error {
+	return s.update(func(grants map[string]Grant) bool {
+		if _, ok := grants[key]; !ok {
+			return false
+		}
+		delete(grants, key)
+		return true
+	})
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileGrantStoreSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grants.json")
+
+	store := NewFileGrantStore(path)
+	if err := store.Put("app|protocol|chat", 0); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := store.Put("app|basket|tokens", time.Hour); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := store.Revoke("app|basket|tokens"); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+
+	restarted := NewFileGrantStore(path)
+	if _, ok, err := restarted.Get("app|protocol|chat"); err != nil || !ok {
+		t.Fatalf("grant lost across restart: ok=%v err=%v", ok, err)
+	}
+	grants, err := restarted.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(grants) != 1 || grants[0].Key != "app|protocol|chat" || grants[0].Expires != nil {
+		t.Fatalf("List = %+v, want the permanent protocol grant only", grants)
+	}
+}
+
+func TestFileGrantStoreWritersSharingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "grants.json")
+	// Two stores stand for two processes: only the file lock orders them.
+	stores := []*FileGrantStore{NewFileGrantStore(path), NewFileGrantStore(path)}
+
+	const perStore = 20
+	var wg sync.WaitGroup
+	for i, store := range stores {
+		for j := range perStore {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := store.Put(fmt.Sprintf("app-%d-%d|protocol|chat", i, j), 0); err != nil {
+					t.Errorf("Put: %v", err)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	grants, err := NewFileGrantStore(path).List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(grants) != len(stores)*perStore {
+		t.Fatalf("file holds %d grants, want %d", len(grants), len(stores)*perStore)
+	}
+	if tmps, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(tmps) != 0 {
+		t.Fatalf("temporary files left behind: %v", tmps)
+	}
+}
+
+func TestGrantStoresExpireGrants(t *testing.T) {
+	stores := map[string]GrantStore{
+		"memory": NewMemoryGrantStore(),
+		"file":   NewFileGrantStore(filepath.Join(t.TempDir(), "grants.json")),
+	}
+	for name, store := range stores {
+		if err := store.Put("app|protocol|chat", 10*time.Millisecond); err != nil {
+			t.Fatalf("%s: Put: %v", name, err)
+		}
+		if _, ok, _ := store.Get("app|protocol|chat"); !ok {
+			t.Fatalf("%s: fresh grant missing", name)
+		}
+		time.Sleep(20 * time.Millisecond)
+		if _, ok, _ := store.Get("app|protocol|chat"); ok {
+			t.Fatalf("%s: expired grant still returned", name)
+		}
+		if grants, _ := store.List(); len(grants) != 0 {
+			t.Fatalf("%s: List returned expired grants %+v", name, grants)
+		}
+	}
+}
+
+func TestGateUsesSharedGrantStore(t *testing.T) {
+	store := NewFileGrantStore(filepath.Join(t.TempDir(), "grants.json"))
+	// A grant written by another process sharing the file, e.g. the bridge.
+	store.Put("example.com|protocol|chat", time.Hour)
+
+	gate := NewBridgePermissionGate(nil, BridgeFailover, false)
+	gate.SetGrantStore(store)
+	approved, err := gate.RequestPermission(PermissionRequest{
+		ID: "req", Type: "protocol", App: "example.com",
+		ExtraData: map[string]interface{}{"protocolID": "chat"},
+	})
+	if err != nil || !approved {
+		t.Fatalf("got approved=%v err=%v, want approval from the stored grant", approved, err)
+	}
+}
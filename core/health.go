@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// readyTimeout bounds the storage ping behind /readyz.
+const readyTimeout = 2 * time.Second
+
+var errWalletNotInitialized = errors.New("wallet not initialized")
+
+// Ready reports whether the wallet can serve calls: it has been
+// initialized, has warmed up if asked to, and its storage answers a
+// settings read.
+func (ws *WalletService) Ready(ctx context.Context) error {
+	ws.mu.RLock()
+	store := ws.storage
+	ws.mu.RUnlock()
+
+	if store == nil {
+		return errWalletNotInitialized
+	}
+	if ws.warmingUp.Load() {
+		return errWarmingUp
+	}
+	ctx, cancel := context.WithTimeout(ctx, readyTimeout)
+	defer cancel()
+	if _, err := store.MakeAvailable(ctx); err != nil {
+		return fmt.Errorf("storage unavailable: %w", err)
+	}
+	return nil
+}
+
+// handleLivez answers 200 for as long as the server is up.
+func (s *HTTPServer) handleLivez(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, `{"status":"ok"}`)
+}
+
+// handleReadyz answers 200 once the wallet is ready for calls and 503
+// until then.
+func (s *HTTPServer) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	ws := s.walletSvc
+	s.mu.RUnlock()
+
+	err := errWalletNotInitialized
+	if ws != nil {
+		err = ws.Ready(r.Context())
+	}
+	if err != nil {
+		s.writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, `{"status":"ok"}`)
+}
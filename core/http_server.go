@@ -9,6 +9,7 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -21,6 +22,11 @@ type HTTPServer struct {
 	httpServer   *http.Server
 	walletSvc    *WalletService
 	mu           sync.RWMutex
+	// limiter caps each originator's requests in flight; nil means no cap.
+	limiter *originatorLimiter
+	// debugToken guards /debug/dump, which is off while it is empty.
+	debugToken   string
+	recentErrors errorRing
 }
 
 // NewHTTPServer creates a new HTTP server
@@ -37,10 +43,20 @@ func (s *HTTPServer) SetWalletService(ws *WalletService) {
 	s.walletSvc = ws
 }
 
-// Start starts both HTTPS (2121) and HTTP (3321) servers
-func (s *HTTPServer) Start(ctx context.Context) error {
+// Default bind address for the HTTP server.
+const (
+	defaultHTTPListen = "127.0.0.1"
+	defaultHTTPPort   = 3321
+)
+
+// Start starts the HTTPS server (2121) and the HTTP server on listen:port,
+// returning an error if the HTTP port cannot be bound.
+func (s *HTTPServer) Start(ctx context.Context, listen string, port int) error {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", s.handleRequest)
+	mux.HandleFunc("GET /livez", s.handleLivez)
+	mux.HandleFunc("GET /readyz", s.handleReadyz)
+	mux.HandleFunc("GET /debug/dump", s.handleDebugDump)
 
 	handler := s.corsMiddleware(mux)
 
@@ -83,15 +99,19 @@ func (s *HTTPServer) Start(ctx context.Context) error {
 		}
 	}
 
-	// Start HTTP server on port 3321
+	// Start HTTP server
 	s.httpServer = &http.Server{
-		Addr:    "127.0.0.1:3321",
+		Addr:    net.JoinHostPort(listen, strconv.Itoa(port)),
 		Handler: handler,
 	}
+	ln, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return fmt.Errorf("HTTP server failed to listen on %s: %w", s.httpServer.Addr, err)
+	}
 
 	go func() {
-		s.logger.Info("HTTP server listening", "addr", "http://127.0.0.1:3321")
-		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		s.logger.Info("HTTP server listening", "addr", "http://"+s.httpServer.Addr)
+		if err := s.httpServer.Serve(ln); err != nil && err != http.ErrServerClosed {
 			s.logger.Error("HTTP server error", "error", err)
 		}
 	}()
@@ -157,6 +177,18 @@ func (s *HTTPServer) handleRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.mu.RLock()
+	limiter := s.limiter
+	s.mu.RUnlock()
+	if limiter != nil {
+		if !limiter.acquire(origin) {
+			s.logger.Warn("Too many requests in flight, shedding", "origin", origin)
+			s.writeError(w, http.StatusTooManyRequests, "Too many requests in flight for this originator")
+			return
+		}
+		defer limiter.release(origin)
+	}
+
 	// Read body
 	body, err := io.ReadAll(io.LimitReader(r.Body, 50<<20)) // 50MB limit
 	if err != nil {
@@ -176,11 +208,16 @@ func (s *HTTPServer) handleRequest(w http.ResponseWriter, r *http.Request) {
 		s.writeError(w, http.StatusServiceUnavailable, "Wallet not initialized")
 		return
 	}
+	if ws.warmingUp.Load() {
+		s.writeError(w, http.StatusServiceUnavailable, "Wallet warming up")
+		return
+	}
 
 	// Call wallet method
 	result, err := ws.CallWalletMethod(method, string(body), origin)
 	if err != nil {
 		s.logger.Error("Wallet method error", "method", method, "error", err)
+		s.recentErrors.add(RecentError{Time: time.Now(), Method: method, Origin: origin, Message: err.Error()})
 		s.writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
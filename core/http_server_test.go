@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// freePort returns a loopback port that is currently unused.
+func freePort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestHTTPServerListensOnConfiguredPort(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	port := freePort(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	srv := NewHTTPServer(logger)
+	go srv.Start(ctx, "127.0.0.1", port)
+	defer srv.Stop()
+
+	url := "http://127.0.0.1:" + strconv.Itoa(port) + "/getVersion"
+	var resp *http.Response
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		req, _ := http.NewRequest(http.MethodPost, url, nil)
+		req.Header.Set("Origin", "http://example.com")
+		var err error
+		if resp, err = http.DefaultClient.Do(req); err == nil {
+			break
+		}
+	}
+	if resp == nil {
+		t.Fatalf("server did not answer on port %d", port)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503 with no wallet set", resp.StatusCode)
+	}
+
+	// A second server on the same port fails to start rather than
+	// running without an HTTP listener.
+	if err := NewHTTPServer(logger).Start(ctx, "127.0.0.1", port); err == nil {
+		t.Fatal("expected a bind error for a port already in use")
+	}
+}
+
+func TestLivezAndReadyz(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	port := freePort(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	srv := NewHTTPServer(logger)
+	go srv.Start(ctx, "127.0.0.1", port)
+	defer srv.Stop()
+
+	base := "http://127.0.0.1:" + strconv.Itoa(port)
+	status := func(path string) int {
+		t.Helper()
+		resp, err := http.Get(base + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(20 * time.Millisecond) {
+		if resp, err := http.Get(base + "/livez"); err == nil {
+			resp.Body.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("server did not answer on port %d", port)
+		}
+	}
+
+	if got := status("/livez"); got != http.StatusOK {
+		t.Fatalf("/livez = %d, want 200", got)
+	}
+	if got := status("/readyz"); got != http.StatusServiceUnavailable {
+		t.Fatalf("/readyz with no wallet service = %d, want 503", got)
+	}
+
+	ws := NewWalletService()
+	ws.logger = logger
+	srv.SetWalletService(ws)
+	if got := status("/readyz"); got != http.StatusServiceUnavailable {
+		t.Fatalf("/readyz before InitializeWallet = %d, want 503", got)
+	}
+
+	if err := ws.InitializeWallet(newKey(t).Hex(), "test"); err != nil {
+		t.Fatalf("InitializeWallet: %v", err)
+	}
+	if got := status("/readyz"); got != http.StatusOK {
+		t.Fatalf("/readyz after InitializeWallet = %d, want 200", got)
+	}
+
+	ws.ShutdownWallet()
+	if got := status("/readyz"); got != http.StatusServiceUnavailable {
+		t.Fatalf("/readyz after shutdown = %d, want 503", got)
+	}
+	if got := status("/livez"); got != http.StatusOK {
+		t.Fatalf("/livez after shutdown = %d, want 200", got)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+)
+
+// internalizer is the part of the wallet internalizeAction calls.
+type internalizer interface {
+	InternalizeAction(ctx context.Context, args sdk.InternalizeActionArgs, originator string) (*sdk.InternalizeActionResult, error)
+}
+
+// WithInternalizeRetry has internalizeAction tried up to attempts times
+// when storage fails transiently, as isTransientStorageError judges,
+// waiting backoff before the first retry and twice as long before each
+// one after. Invalid transactions and other lasting failures are returned
+// at once. A failed attempt stores nothing, and taking in a transaction
+// the wallet already holds succeeds, so retrying is safe.
+func WithInternalizeRetry(attempts int, backoff time.Duration) WalletServiceOption {
+	return func(ws *WalletService) {
+		ws.internalizeAttempts = attempts
+		ws.internalizeBackoff = backoff
+	}
+}
+
+func (ws *WalletService) internalizeAction(ctx context.Context, w internalizer, args sdk.InternalizeActionArgs, originator string) (*sdk.InternalizeActionResult, error) {
+	backoff := ws.internalizeBackoff
+	for attempt := 1; ; attempt++ {
+		result, err := w.InternalizeAction(ctx, args, originator)
+		if err == nil || attempt >= ws.internalizeAttempts || !isTransientStorageError(ctx, err) {
+			return result, err
+		}
+		ws.logger.Warn("Internalizing action failed on storage, retrying", "attempt", attempt, "in", backoff, "error", err)
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+)
+
+// flakyInternalizer fails with the next of errs until they run out.
+type flakyInternalizer struct {
+	errs  []error
+	calls int
+}
+
+func (f *flakyInternalizer) InternalizeAction(context.Context, sdk.InternalizeActionArgs, string) (*sdk.InternalizeActionResult, error) {
+	f.calls++
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return nil, err
+	}
+	return &sdk.InternalizeActionResult{Accepted: true}, nil
+}
+
+func TestInternalizeRetriesTransientStorageErrors(t *testing.T) {
+	ctx := context.Background()
+	ws := NewWalletService(WithInternalizeRetry(3, time.Millisecond))
+
+	locked := errors.New("failed to store transaction: database is locked")
+	w := &flakyInternalizer{errs: []error{locked, fmt.Errorf("storage: %w", syscall.ECONNREFUSED)}}
+	result, err := ws.internalizeAction(ctx, w, sdk.InternalizeActionArgs{}, "example.com")
+	if err != nil || !result.Accepted {
+		t.Fatalf("internalizeAction = %+v, %v; want it accepted on the third try", result, err)
+	}
+	if w.calls != 3 {
+		t.Fatalf("%d tries, want 3", w.calls)
+	}
+
+	// Invalid transactions are not retried.
+	invalid := errors.New("invalid tx: missing merkle path")
+	w = &flakyInternalizer{errs: []error{invalid}}
+	if _, err := ws.internalizeAction(ctx, w, sdk.InternalizeActionArgs{}, "example.com"); !errors.Is(err, invalid) || w.calls != 1 {
+		t.Fatalf("invalid transaction: err = %v after %d tries, want it returned after 1", err, w.calls)
+	}
+
+	// Nor are storage errors past the last attempt.
+	w = &flakyInternalizer{errs: []error{locked, locked, locked, locked}}
+	if _, err := ws.internalizeAction(ctx, w, sdk.InternalizeActionArgs{}, "example.com"); !errors.Is(err, locked) || w.calls != 3 {
+		t.Fatalf("storage down: err = %v after %d tries, want it returned after 3", err, w.calls)
+	}
+
+	// Without the option there is one try.
+	w = &flakyInternalizer{errs: []error{locked}}
+	if _, err := NewWalletService().internalizeAction(ctx, w, sdk.InternalizeActionArgs{}, "example.com"); err == nil || w.calls != 1 {
+		t.Fatalf("no retry configured: err = %v after %d tries", err, w.calls)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+// Default scrypt cost for new encrypted identity files (N=2^15, r=8, p=1),
+// about 32 MiB and a fraction of a second to derive.
+const (
+	defaultScryptN = 1 << 15
+	defaultScryptR = 8
+	defaultScryptP = 1
+)
+
+// Bounds on the scrypt cost read from an identity file, so a crafted file
+// cannot make loading it allocate gigabytes or spin for minutes. The memory
+// bound is on 128*N*r bytes, 256 MiB allowing eight times the default.
+const (
+	maxScryptMemory = 256 << 20
+	maxScryptP      = 16
+)
+
+// scryptParams are the KDF settings stored alongside an encrypted key.
+type scryptParams struct {
+	N int `json:"n"`
+	R int `json:"r"`
+	P int `json:"p"`
+}
+
+// validate rejects settings outside the bounds above.
+func (p scryptParams) validate() error {
+	if p.N <= 1 || p.N&(p.N-1) != 0 {
+		return fmt.Errorf("invalid scrypt N %d in encrypted key file: must be a power of two greater than 1", p.N)
+	}
+	if p.R < 1 || p.P < 1 || p.P > maxScryptP {
+		return fmt.Errorf("invalid scrypt r=%d p=%d in encrypted key file", p.R, p.P)
+	}
+	if p.R > maxScryptMemory/128 || p.N > maxScryptMemory/128/p.R {
+		return fmt.Errorf("scrypt N=%d r=%d in encrypted key file needs more than %d MiB", p.N, p.R, maxScryptMemory>>20)
+	}
+	return nil
+}
+
+// errWrongPassphrase is returned when an encrypted identity does not
+// decrypt, which almost always means the passphrase was wrong.
+var errWrongPassphrase = errors.New("failed to decrypt key: wrong passphrase or corrupted file")
+
+// encryptIdentity returns an identity file holding rootKeyHex encrypted
+// under passphrase with scrypt and AES-256-GCM.
+func encryptIdentity(rootKeyHex, identityKey, network string, passphrase []byte) (walletIdentity, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return walletIdentity{}, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	params := scryptParams{N: defaultScryptN, R: defaultScryptR, P: defaultScryptP}
+	gcm, err := identityCipher(passphrase, salt, params)
+	if err != nil {
+		return walletIdentity{}, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return walletIdentity{}, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return walletIdentity{
+		IdentityKey: identityKey,
+		Network:     network,
+		Encrypted:   true,
+		KDF:         "scrypt",
+		KDFParams:   &params,
+		Salt:        hex.EncodeToString(salt),
+		Nonce:       hex.EncodeToString(nonce),
+		Ciphertext:  hex.EncodeToString(gcm.Seal(nil, nonce, []byte(rootKeyHex), nil)),
+	}, nil
+}
+
+// decryptIdentity returns the root key hex held by an encrypted identity.
+func decryptIdentity(identity walletIdentity, passphrase []byte) (string, error) {
+	if identity.KDF != "scrypt" || identity.KDFParams == nil {
+		return "", fmt.Errorf("unsupported key derivation %q", identity.KDF)
+	}
+	salt, err := hex.DecodeString(identity.Salt)
+	if err != nil || len(salt) == 0 {
+		return "", fmt.Errorf("invalid salt in encrypted key file")
+	}
+	nonce, err := hex.DecodeString(identity.Nonce)
+	if err != nil {
+		return "", fmt.Errorf("invalid nonce in encrypted key file")
+	}
+	ciphertext, err := hex.DecodeString(identity.Ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("invalid ciphertext in encrypted key file")
+	}
+	gcm, err := identityCipher(passphrase, salt, *identity.KDFParams)
+	if err != nil {
+		return "", err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return "", fmt.Errorf("invalid nonce in encrypted key file")
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errWrongPassphrase
+	}
+	return string(plaintext), nil
+}
+
+func identityCipher(passphrase, salt []byte, params scryptParams) (cipher.AEAD, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+	key, err := scrypt.Key(passphrase, salt, params.N, params.R, params.P, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	defer clear(key)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// readPassphrase returns GEBUNDEN_KEY_PASSPHRASE if set, otherwise prompts
+// for one on the terminal without echoing it. The caller clears the result
+// once done. A passphrase from the environment also stays in the process
+// environment, which cannot be wiped.
+func readPassphrase(prompt string) ([]byte, error) {
+	if p, ok := os.LookupEnv("GEBUNDEN_KEY_PASSPHRASE"); ok {
+		return []byte(p), nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, fmt.Errorf("key file is encrypted: set GEBUNDEN_KEY_PASSPHRASE or run from a terminal")
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	p, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		clear(p)
+		return nil, fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return bytes.TrimRight(p, "\r\n"), nil
+}
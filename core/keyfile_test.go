@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScryptParamsBounded(t *testing.T) {
+	cases := []struct {
+		params scryptParams
+		ok     bool
+	}{
+		{scryptParams{N: defaultScryptN, R: defaultScryptR, P: defaultScryptP}, true},
+		{scryptParams{N: 1 << 18, R: 8, P: 1}, true},
+		{scryptParams{N: 1000, R: 8, P: 1}, false},
+		{scryptParams{N: 1, R: 8, P: 1}, false},
+		{scryptParams{N: 1 << 15, R: 0, P: 1}, false},
+		{scryptParams{N: 1 << 15, R: 8, P: 0}, false},
+		{scryptParams{N: 1 << 15, R: 8, P: 1 << 20}, false},
+		{scryptParams{N: 1 << 22, R: 8, P: 1}, false},
+		{scryptParams{N: 2, R: 1 << 30, P: 1}, false},
+		{scryptParams{N: 1 << 62, R: 8, P: 1}, false},
+	}
+	for _, c := range cases {
+		if err := c.params.validate(); (err == nil) != c.ok {
+			t.Errorf("validate(%+v) = %v, want ok=%v", c.params, err, c.ok)
+		}
+	}
+
+	// A crafted file is refused before anything is derived.
+	identity, err := encryptIdentity(newKey(t).Hex(), "02ab", "testnet", []byte("correct horse"))
+	if err != nil {
+		t.Fatalf("encryptIdentity: %v", err)
+	}
+	identity.KDFParams = &scryptParams{N: 1 << 30, R: 8, P: 1}
+	if _, err := decryptIdentity(identity, []byte("correct horse")); err == nil || errors.Is(err, errWrongPassphrase) {
+		t.Fatalf("decryptIdentity with N=2^30: err = %v, want the parameters refused", err)
+	}
+}
+
+// writeIdentity writes identity as a key file in a temp dir and returns its path.
+func writeIdentity(t *testing.T, identity walletIdentity) string {
+	t.Helper()
+	data, err := json.Marshal(identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "wallet-identity.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestEncryptedIdentityRoundTrip(t *testing.T) {
+	t.Setenv("GEBUNDEN_PRIVATE_KEY", "")
+	rootKey := newKey(t).Hex()
+
+	identity, err := encryptIdentity(rootKey, "02ab", "testnet", []byte("correct horse"))
+	if err != nil {
+		t.Fatalf("encryptIdentity: %v", err)
+	}
+	if identity.RootKeyHex != "" || !identity.Encrypted {
+		t.Fatal("encrypted identity must not carry the plaintext key")
+	}
+	path := writeIdentity(t, identity)
+
+	t.Setenv("GEBUNDEN_KEY_PASSPHRASE", "correct horse")
+	got, network, _, err := loadPrivateKey(path)
+	if err != nil {
+		t.Fatalf("loadPrivateKey: %v", err)
+	}
+	if got != rootKey || network != "test" {
+		t.Fatalf("loaded %s on %s, want %s on test", got, network, rootKey)
+	}
+
+	t.Setenv("GEBUNDEN_KEY_PASSPHRASE", "wrong")
+	if _, _, _, err := loadPrivateKey(path); !errors.Is(err, errWrongPassphrase) {
+		t.Fatalf("wrong passphrase: err = %v, want errWrongPassphrase", err)
+	}
+}
+
+func TestPlaintextIdentityStillLoads(t *testing.T) {
+	t.Setenv("GEBUNDEN_PRIVATE_KEY", "")
+	rootKey := newKey(t).Hex()
+	path := writeIdentity(t, walletIdentity{RootKeyHex: rootKey, Network: "mainnet"})
+
+	got, network, _, err := loadPrivateKey(path)
+	if err != nil {
+		t.Fatalf("loadPrivateKey: %v", err)
+	}
+	if got != rootKey || network != "main" {
+		t.Fatalf("loaded %s on %s, want %s on main", got, network, rootKey)
+	}
+}
+
+func TestLoadPrivateKeyRejectsUnknownNetwork(t *testing.T) {
+	t.Setenv("GEBUNDEN_PRIVATE_KEY", "")
+	path := writeIdentity(t, walletIdentity{RootKeyHex: newKey(t).Hex(), Network: "mainet"})
+	if _, _, _, err := loadPrivateKey(path); err == nil || !strings.Contains(err.Error(), `"mainet"`) || !strings.Contains(err.Error(), "mainnet, testnet or regtest") {
+		t.Fatalf("err = %v, want one naming the bad network and the allowed ones", err)
+	}
+
+	t.Setenv("GEBUNDEN_PRIVATE_KEY", newKey(t).Hex())
+	t.Setenv("GEBUNDEN_NETWORK", "tesnet")
+	if _, _, _, err := loadPrivateKey(""); err == nil || !strings.Contains(err.Error(), "GEBUNDEN_NETWORK") || !strings.Contains(err.Error(), `"tesnet"`) {
+		t.Fatalf("err = %v, want one naming GEBUNDEN_NETWORK and the bad value", err)
+	}
+	t.Setenv("GEBUNDEN_NETWORK", "testnet")
+	if _, network, _, err := loadPrivateKey(""); err != nil || network != "test" {
+		t.Fatalf("got %q, %v; want test", network, err)
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/defs"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/entity"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/storage"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/wallet"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/wdk"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/wdk/primitives"
+)
+
+// Labels listActions treats as query operators rather than labels: the
+// failed-actions spec-op makes it list failed actions by status, and
+// "unfail" alongside it queues them to be retried. Neither is ever stored
+// on an action, so they cannot be added or removed.
+const failedActionsLabel = "97d4eb1e49215e3374cc2c1939a7c43a55e95c7427bf2d45ed63e3b4e0c88153"
+
+var reservedLabels = map[string]bool{
+	failedActionsLabel:         true,
+	string(wdk.TxStatusUnfail): true,
+}
+
+// transactionLabelsTable joins actions to their labels.
+const transactionLabelsTable = defs.DefaultTablePrefix + "transaction_labels"
+
+// LabelActionArgs are the args of the labelAction and unlabelAction calls.
+type LabelActionArgs struct {
+	TxID   string   `json:"txid"`
+	Labels []string `json:"labels"`
+}
+
+// LabelActionResult is the result of the labelAction call.
+type LabelActionResult struct {
+	Labeled bool `json:"labeled"`
+}
+
+// UnlabelActionResult is the result of the unlabelAction call.
+type UnlabelActionResult struct {
+	Unlabeled bool `json:"unlabeled"`
+}
+
+// LabelAction adds labels to the existing action txid, after it was
+// created, so that ListActions can filter on them. Labels the action
+// already has are left as they are.
+func (ws *WalletService) LabelAction(ctx context.Context, txid string, labels []string, originator string) error {
+	ws.mu.RLock()
+	w, store := ws.wallet, ws.storage
+	ws.mu.RUnlock()
+
+	if w == nil {
+		return errWalletNotInitialized
+	}
+	ctx, cancel := ws.withOperationTimeout(ctx)
+	defer cancel()
+	return labelAction(ctx, w, store, txid, labels, originator)
+}
+
+// UnlabelAction removes labels from the existing action txid. Labels the
+// action does not have are ignored.
+func (ws *WalletService) UnlabelAction(ctx context.Context, txid string, labels []string, originator string) error {
+	ws.mu.RLock()
+	w, store := ws.wallet, ws.storage
+	ws.mu.RUnlock()
+
+	if w == nil {
+		return errWalletNotInitialized
+	}
+	ctx, cancel := ws.withOperationTimeout(ctx)
+	defer cancel()
+	return unlabelAction(ctx, w, store, txid, labels, originator)
+}
+
+func labelAction(ctx context.Context, w *wallet.Wallet, store *storage.Provider, txid string, labels []string, originator string) error {
+	userID, actionID, err := findLabelledAction(ctx, w, store, txid, labels, originator)
+	if err != nil {
+		return err
+	}
+
+	// Labels removed earlier are still there, soft-deleted; bring them
+	// back, as the association below leaves existing rows alone.
+	err = store.Database.DB.WithContext(ctx).Table(transactionLabelsTable).
+		Where("transaction_id = ? AND label_user_id = ? AND label_name IN ? AND deleted_at IS NOT NULL", actionID, userID, labels).
+		Updates(map[string]any{"deleted_at": nil, "updated_at": time.Now()}).Error
+	if err != nil {
+		return fmt.Errorf("failed to label action %s: %w", txid, err)
+	}
+	// The storage API adds labels only while creating or internalizing,
+	// so this goes to the repository those use.
+	if err := store.Database.CreateRepositories().AddLabels(ctx, userID, actionID, labels...); err != nil {
+		return fmt.Errorf("failed to label action %s: %w", txid, err)
+	}
+	return nil
+}
+
+func unlabelAction(ctx context.Context, w *wallet.Wallet, store *storage.Provider, txid string, labels []string, originator string) error {
+	userID, actionID, err := findLabelledAction(ctx, w, store, txid, labels, originator)
+	if err != nil {
+		return err
+	}
+
+	// Soft-delete, as the toolbox does, so the removal reaches storages
+	// this one syncs with.
+	now := time.Now()
+	err = store.Database.DB.WithContext(ctx).Table(transactionLabelsTable).
+		Where("transaction_id = ? AND label_user_id = ? AND label_name IN ? AND deleted_at IS NULL", actionID, userID, labels).
+		Updates(map[string]any{"deleted_at": now, "updated_at": now}).Error
+	if err != nil {
+		return fmt.Errorf("failed to unlabel action %s: %w", txid, err)
+	}
+	return nil
+}
+
+// findLabelledAction checks the args of a label change and returns the
+// user and storage ID of the action txid.
+func findLabelledAction(ctx context.Context, w *wallet.Wallet, store *storage.Provider, txid string, labels []string, originator string) (userID int, actionID uint, err error) {
+	if err := validateTxid(txid); err != nil {
+		return 0, 0, err
+	}
+	if len(labels) == 0 {
+		return 0, 0, fmt.Errorf("no labels given")
+	}
+	for i, label := range labels {
+		if err := primitives.StringUnder300(label).Validate(); err != nil {
+			return 0, 0, fmt.Errorf("label %d must be %w", i, err)
+		}
+		if reservedLabels[label] {
+			return 0, 0, fmt.Errorf("label %q is reserved for listing failed actions and is never stored on an action", label)
+		}
+	}
+
+	tx, err := findAction(ctx, w, store, txid, originator)
+	if err != nil {
+		return 0, 0, err
+	}
+	return tx.UserID, tx.ID, nil
+}
+
+func validateTxid(txid string) error {
+	if b, err := hex.DecodeString(txid); err != nil || len(b) != 32 {
+		return fmt.Errorf("invalid txid %q: must be 64 hex digits", txid)
+	}
+	return nil
+}
+
+// findAction returns the wallet user's action txid as stored.
+func findAction(ctx context.Context, w *wallet.Wallet, store *storage.Provider, txid string, originator string) (*entity.Transaction, error) {
+	// The identity key also validates the originator.
+	identity, err := w.GetPublicKey(ctx, sdk.GetPublicKeyArgs{IdentityKey: true}, originator)
+	if err != nil {
+		return nil, err
+	}
+	user, err := store.FindOrInsertUser(ctx, identity.PublicKey.ToDERHex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	txs, err := store.TransactionEntity().Read().UserID().Equals(user.User.UserID).TxID().Equals(txid).Find(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find action: %w", err)
+	}
+	if len(txs) == 0 {
+		return nil, fmt.Errorf("action %s not found", txid)
+	}
+	return txs[0], nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/entity"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/wdk"
+)
+
+func TestLabelActionAfterCreation(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	rootKey := newKey(t)
+	ws := NewWalletService()
+	if err := ws.InitializeWallet(rootKey.Hex(), "test"); err != nil {
+		t.Fatalf("InitializeWallet: %v", err)
+	}
+	defer ws.ShutdownWallet()
+	ctx := context.Background()
+
+	// An action as CreateAction would have left it, labelled "payment".
+	user, err := ws.storage.FindOrInsertUser(ctx, rootKey.PubKey().ToDERHex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := strings.Repeat("ab", 32)
+	err = ws.storage.TransactionEntity().Create(ctx, &entity.Transaction{
+		UserID:      user.User.UserID,
+		Status:      wdk.TxStatusCompleted,
+		Reference:   "label-test",
+		IsOutgoing:  true,
+		Description: "pay for batch",
+		TxID:        &txid,
+		Labels:      []string{"payment"},
+	})
+	if err != nil {
+		t.Fatalf("create action: %v", err)
+	}
+
+	listed := func(label string) []string {
+		t.Helper()
+		out, err := ws.CallWalletMethod("listActions", `{"labels":["`+label+`"],"includeLabels":true}`, "example.com")
+		if err != nil {
+			t.Fatalf("listActions %s: %v", label, err)
+		}
+		var result struct {
+			Actions []struct {
+				Labels []string `json:"labels"`
+			} `json:"actions"`
+		}
+		json.Unmarshal([]byte(out), &result)
+		if len(result.Actions) == 0 {
+			return nil
+		}
+		return result.Actions[0].Labels
+	}
+	if got := listed("batch-7"); got != nil {
+		t.Fatalf("action already labelled batch-7: %v", got)
+	}
+
+	out, err := ws.CallWalletMethod("labelAction", `{"txid":"`+txid+`","labels":["batch-7","payment"]}`, "example.com")
+	if err != nil || out != `{"labeled":true}` {
+		t.Fatalf("labelAction = %s, %v", out, err)
+	}
+	got := listed("batch-7")
+	if strings.Join(got, ",") != "batch-7,payment" && strings.Join(got, ",") != "payment,batch-7" {
+		t.Fatalf("labels after labelAction = %v, want payment and batch-7", got)
+	}
+
+	if err := ws.LabelAction(ctx, txid, []string{"unfail"}, "example.com"); err == nil || !strings.Contains(err.Error(), "reserved") {
+		t.Errorf("labelling with unfail: err = %v, want it rejected as reserved", err)
+	}
+	if err := ws.LabelAction(ctx, strings.Repeat("cd", 32), []string{"batch-7"}, "example.com"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("labelling an unknown action: err = %v, want not found", err)
+	}
+	if err := ws.LabelAction(ctx, txid, []string{""}, "example.com"); err == nil {
+		t.Error("expected an empty label to be rejected")
+	}
+}
+
+func TestUnlabelAction(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	rootKey := newKey(t)
+	ws := NewWalletService()
+	if err := ws.InitializeWallet(rootKey.Hex(), "test"); err != nil {
+		t.Fatalf("InitializeWallet: %v", err)
+	}
+	defer ws.ShutdownWallet()
+	ctx := context.Background()
+
+	user, err := ws.storage.FindOrInsertUser(ctx, rootKey.PubKey().ToDERHex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := strings.Repeat("ef", 32)
+	err = ws.storage.TransactionEntity().Create(ctx, &entity.Transaction{
+		UserID:      user.User.UserID,
+		Status:      wdk.TxStatusCompleted,
+		Reference:   "unlabel-test",
+		Description: "pay for batch",
+		TxID:        &txid,
+		Labels:      []string{"payment", "batch-7"},
+	})
+	if err != nil {
+		t.Fatalf("create action: %v", err)
+	}
+
+	matches := func(label string) int {
+		t.Helper()
+		out, err := ws.CallWalletMethod("listActions", `{"labels":["`+label+`"]}`, "example.com")
+		if err != nil {
+			t.Fatalf("listActions %s: %v", label, err)
+		}
+		var result struct {
+			TotalActions int `json:"totalActions"`
+		}
+		json.Unmarshal([]byte(out), &result)
+		return result.TotalActions
+	}
+	if matches("batch-7") != 1 {
+		t.Fatal("action is not labelled batch-7 to begin with")
+	}
+
+	out, err := ws.CallWalletMethod("unlabelAction", `{"txid":"`+txid+`","labels":["batch-7","never-there"]}`, "example.com")
+	if err != nil || out != `{"unlabeled":true}` {
+		t.Fatalf("unlabelAction = %s, %v", out, err)
+	}
+	if n := matches("batch-7"); n != 0 {
+		t.Fatalf("%d actions still match batch-7 after removing it", n)
+	}
+	if matches("payment") != 1 {
+		t.Fatal("removing batch-7 also removed payment")
+	}
+
+	// A removed label can be added back.
+	if err := ws.LabelAction(ctx, txid, []string{"batch-7"}, "example.com"); err != nil {
+		t.Fatalf("LabelAction: %v", err)
+	}
+	if matches("batch-7") != 1 {
+		t.Fatal("re-added label batch-7 does not match")
+	}
+
+	if err := ws.UnlabelAction(ctx, txid, []string{failedActionsLabel}, "example.com"); err == nil || !strings.Contains(err.Error(), "reserved") {
+		t.Errorf("removing the failed-actions label: err = %v, want it rejected as reserved", err)
+	}
+}
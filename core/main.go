@@ -1,98 +1,334 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"log/slog"
+	"net"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 )
 
 // walletIdentity is the JSON structure for the wallet identity file.
 type walletIdentity struct {
-	RootKeyHex  string `json:"rootKeyHex"`
+	RootKeyHex  string `json:"rootKeyHex,omitempty"`
 	IdentityKey string `json:"identityKey"`
 	Network     string `json:"network"`
+
+	// Encrypted files hold the root key in Ciphertext instead of
+	// RootKeyHex, sealed with AES-256-GCM under a key derived from a
+	// passphrase with KDF.
+	Encrypted  bool          `json:"encrypted,omitempty"`
+	KDF        string        `json:"kdf,omitempty"`
+	KDFParams  *scryptParams `json:"kdfParams,omitempty"`
+	Salt       string        `json:"salt,omitempty"`
+	Nonce      string        `json:"nonce,omitempty"`
+	Ciphertext string        `json:"ciphertext,omitempty"`
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "genkey" {
+		if err := runGenkey(os.Args[2:], os.Stdout); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return
+			}
+			log.Fatalf("genkey: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "wipe-identity" {
+		if err := runWipeIdentity(os.Args[2:], os.Stdin, os.Stdout); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return
+			}
+			log.Fatalf("wipe-identity: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "recover-outputs" {
+		if err := runRecoverOutputs(os.Args[2:], os.Stdout); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return
+			}
+			log.Fatalf("recover-outputs: %v", err)
+		}
+		return
+	}
+
 	autoApprove := flag.Bool("auto-approve", false, "Auto-approve all permission requests")
 	keyFile := flag.String("key-file", "", "Path to wallet identity JSON file")
-	bridgeURL := flag.String("bridge-url", "http://127.0.0.1:18790", "URL of the Gebunden Bridge service")
+	bridgeURL := flag.String("bridge-url", "http://127.0.0.1:18790", "URL of the Gebunden Bridge service; a comma-separated list enables failover")
+	grantsFile := flag.String("grants-file", "", "Persist permission grants in this JSON file (share it with the bridge's -grants-file)")
+	bridgeStrategy := flag.String("bridge-strategy", BridgeFailover, "How to spread requests over several bridges: failover or round-robin")
+	httpProxy := flag.String("http-proxy", "", "Route wallet HTTP egress through this proxy (hosts in NO_PROXY bypass it)")
+	minFeeRate := flag.Int64("min-fee-rate", 0, "Never pay less than this fee rate, in satoshis per kB")
+	slowOpThreshold := flag.Duration("slow-op-threshold", 0, "Log a warning for wallet calls slower than this, e.g. 2s (0 disables)")
+	operationTimeout := flag.Duration("operation-timeout", 0, "Fail wallet calls still running after this long, e.g. 60s, not counting permission prompts (0 disables)")
+	certifierTimeout := flag.Duration("certifier-timeout", defaultCertifierTimeout, "Give up on a certifier request after this long (0 waits without limit)")
+	discoverCacheMaxAge := flag.Duration("discover-cache-max-age", 0, "Query the overlay again for discovery results cached longer than this (0 keeps them for the cache's TTL)")
+	internalizeAttempts := flag.Int("internalize-attempts", 1, "Try internalizeAction this many times when storage fails transiently")
+	internalizeBackoff := flag.Duration("internalize-backoff", 500*time.Millisecond, "Wait this long before the first internalizeAction retry, doubling it for each one after")
+	dataProtocol := flag.String("data-protocol", "", "Push this protocol prefix, e.g. a B:// address, ahead of createDataAction payloads that name none")
+	maxUnfailRetries := flag.Int("max-unfail-retries", 0, "Stop retrying a failed action after this many unfail requests (0 retries without limit)")
+	httpListen := flag.String("http-listen", defaultHTTPListen, "Address the BRC-100 HTTP server binds to")
+	httpPort := flag.Int("http-port", defaultHTTPPort, "Port the BRC-100 HTTP server listens on")
+	startupTimeout := flag.Duration("startup-timeout", 0, "Exit with code 5 if the wallet is not initialized within this long (0 waits without limit)")
+	warmUp := flag.Bool("warm-up", false, "Check the chain services, overlay and storage answer before reporting ready")
+	warmUpTimeout := flag.Duration("warm-up-timeout", 2*time.Minute, "Exit with code 5 if the warm-up checks have not passed within this long (0 waits without limit)")
+	debugToken := flag.String("debug-token", "", "Serve GET /debug/dump to requests bearing this token (overrides GEBUNDEN_DEBUG_TOKEN)")
+	maxInFlight := flag.Int("max-inflight-per-originator", 0, "Answer 429 to an originator's requests beyond this many in flight at once (0 sets no cap)")
 	flag.Parse()
 
-	runHeadless(*autoApprove, *keyFile, *bridgeURL)
-}
+	if *bridgeStrategy != BridgeFailover && *bridgeStrategy != BridgeRoundRobin {
+		log.Fatalf("Unknown -bridge-strategy %q (want failover or round-robin)", *bridgeStrategy)
+	}
+
+	var walletOpts []WalletServiceOption
+	if *httpProxy != "" {
+		proxy, err := parseProxyURL(*httpProxy)
+		if err != nil {
+			log.Fatalf("Bad -http-proxy: %v", err)
+		}
+		walletOpts = append(walletOpts, WithHTTPProxy(proxy))
+	}
+	if *httpPort <= 0 || *httpPort > 65535 {
+		log.Fatalf("Bad -http-port %d: must be between 1 and 65535", *httpPort)
+	}
+	if *startupTimeout < 0 {
+		log.Fatalf("Bad -startup-timeout %v: must not be negative", *startupTimeout)
+	}
+	if *warmUpTimeout < 0 {
+		log.Fatalf("Bad -warm-up-timeout %v: must not be negative", *warmUpTimeout)
+	}
+	if *warmUp {
+		walletOpts = append(walletOpts, WithWarmUp(*warmUpTimeout))
+	}
+	if *maxInFlight < 0 {
+		log.Fatalf("Bad -max-inflight-per-originator %d: must not be negative", *maxInFlight)
+	}
+	if *minFeeRate < 0 {
+		log.Fatalf("Bad -min-fee-rate %d: must not be negative", *minFeeRate)
+	}
+	if *minFeeRate > 0 {
+		walletOpts = append(walletOpts, WithMinFeeRate(*minFeeRate))
+	}
+	if *slowOpThreshold < 0 {
+		log.Fatalf("Bad -slow-op-threshold %v: must not be negative", *slowOpThreshold)
+	}
+	if *slowOpThreshold > 0 {
+		walletOpts = append(walletOpts, WithSlowOpThreshold(*slowOpThreshold))
+	}
+	if *operationTimeout < 0 {
+		log.Fatalf("Bad -operation-timeout %v: must not be negative", *operationTimeout)
+	}
+	if *operationTimeout > 0 {
+		walletOpts = append(walletOpts, WithDefaultOperationTimeout(*operationTimeout))
+	}
+	if *certifierTimeout < 0 {
+		log.Fatalf("Bad -certifier-timeout %v: must not be negative", *certifierTimeout)
+	}
+	walletOpts = append(walletOpts, WithCertifierTimeout(*certifierTimeout))
+	if *discoverCacheMaxAge < 0 {
+		log.Fatalf("Bad -discover-cache-max-age %v: must not be negative", *discoverCacheMaxAge)
+	}
+	walletOpts = append(walletOpts, WithDiscoverCacheMaxAge(*discoverCacheMaxAge))
+	if *internalizeAttempts < 1 {
+		log.Fatalf("Bad -internalize-attempts %d: must be at least 1", *internalizeAttempts)
+	}
+	if *internalizeBackoff < 0 {
+		log.Fatalf("Bad -internalize-backoff %v: must not be negative", *internalizeBackoff)
+	}
+	walletOpts = append(walletOpts, WithInternalizeRetry(*internalizeAttempts, *internalizeBackoff))
+	if *dataProtocol != "" {
+		walletOpts = append(walletOpts, WithDefaultDataProtocol(*dataProtocol))
+	}
+	if *maxUnfailRetries < 0 {
+		log.Fatalf("Bad -max-unfail-retries %d: must not be negative", *maxUnfailRetries)
+	}
+	if *maxUnfailRetries > 0 {
+		walletOpts = append(walletOpts, WithMaxUnfailRetries(*maxUnfailRetries))
+	}
+
+	if *debugToken == "" {
+		*debugToken = os.Getenv("GEBUNDEN_DEBUG_TOKEN")
+	}
 
-// runHeadless starts the wallet service and HTTP server without the Wails GUI.
-func runHeadless(autoApprove bool, keyFile, bridgeURL string) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelInfo,
 	}))
+	sig, err := runHeadless(logger, *autoApprove, *keyFile, splitURLs(*bridgeURL), *bridgeStrategy, *grantsFile, *httpListen, *httpPort, *maxInFlight, *debugToken, *startupTimeout, walletOpts...)
+	os.Exit(shutdownCode(logger, err, sig))
+}
 
+// splitURLs splits a comma-separated list of URLs, dropping empty items.
+func splitURLs(s string) []string {
+	var urls []string
+	for _, u := range strings.Split(s, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// runHeadless starts the wallet service and HTTP server without the Wails GUI.
+// It returns the signal it was stopped by, or an *exitError if it could
+// not start or the HTTP server failed.
+func runHeadless(logger *slog.Logger, autoApprove bool, keyFile string, bridgeURLs []string, bridgeStrategy, grantsFile, httpListen string, httpPort, maxInFlight int, debugToken string, startupTimeout time.Duration, walletOpts ...WalletServiceOption) (os.Signal, error) {
 	logger.Info("Starting Gebunden in headless mode")
 
 	// Load private key
-	privateKey, network, err := loadPrivateKey(keyFile)
+	privateKey, network, passphrase, err := loadPrivateKey(keyFile)
 	if err != nil {
-		log.Fatalf("Failed to load private key: %v", err)
+		return nil, &exitError{reason: reasonKeyLoad, code: exitKeyLoad, err: fmt.Errorf("failed to load private key: %w", err)}
 	}
 
 	// Initialize wallet
-	walletService := NewWalletService()
+	walletService := NewWalletService(walletOpts...)
 
 	// Set up permission gate pointing at the bridge service
-	gate := NewBridgePermissionGate(bridgeURL, autoApprove)
+	gate := NewBridgePermissionGate(bridgeURLs, bridgeStrategy, autoApprove)
+	if grantsFile != "" {
+		gate.SetGrantStore(NewFileGrantStore(grantsFile))
+	}
 	walletService.SetPermissionGate(gate)
 
-	if err := walletService.InitializeWallet(privateKey, network); err != nil {
-		log.Fatalf("Failed to initialize wallet: %v", err)
+	initDone := make(chan error, 1)
+	go func() {
+		initDone <- walletService.InitializeWalletWithPassphrase(privateKey, network, passphrase)
+	}()
+	var startupExpired <-chan time.Time
+	if startupTimeout > 0 {
+		timer := time.NewTimer(startupTimeout)
+		defer timer.Stop()
+		startupExpired = timer.C
+	}
+	select {
+	case err := <-initDone:
+		if err != nil {
+			return nil, initFailure(fmt.Errorf("failed to initialize wallet: %w", err))
+		}
+	case <-startupExpired:
+		return nil, &exitError{reason: reasonStartupTimeout, code: exitStartupTimeout,
+			err: fmt.Errorf("wallet not initialized within %v", startupTimeout)}
 	}
 	logger.Info("Wallet initialized", "network", network)
 
+	// Signals are handled from here on, so one arriving during a warm-up
+	// that never gives up still shuts the wallet down cleanly.
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+
 	// Start HTTP server
 	httpServer := NewHTTPServer(logger)
 	httpServer.SetWalletService(walletService)
+	httpServer.SetMaxInFlightPerOriginator(maxInFlight)
+	httpServer.SetDebugToken(debugToken)
+
+	// The server outlives any one wallet, so it gets its own context
+	// rather than the wallet's, which a key reload cancels.
+	serverCtx, stopServer := context.WithCancel(context.Background())
+	defer stopServer()
 
+	serverErr := make(chan error, 1)
 	go func() {
-		if err := httpServer.Start(walletService.ctx); err != nil {
-			logger.Error("HTTP server error", "error", err)
+		if err := httpServer.Start(serverCtx, httpListen, httpPort); err != nil {
+			serverErr <- err
 		}
 	}()
 
-	logger.Info("Gebunden headless mode running",
-		"http", "http://127.0.0.1:3321",
-		"bridge", bridgeURL,
-		"autoApprove", autoApprove,
-	)
+	// The server is up meanwhile, so /livez answers, but /readyz and
+	// wallet calls wait for the warm-up.
+	warmUpCtx, stopWarmUp := context.WithCancel(context.Background())
+	defer stopWarmUp()
+	warmUpDone := make(chan error, 1)
+	go func() {
+		warmUpDone <- walletService.WarmUp(warmUpCtx)
+	}()
 
-	// Wait for shutdown signal
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
-	<-sigCh
+	// Wait for shutdown signal; SIGHUP reloads the wallet key
+	var sig os.Signal
+	var exit error
+wait:
+	for {
+		select {
+		case err := <-warmUpDone:
+			if err != nil {
+				httpServer.Stop()
+				walletService.ShutdownWallet()
+				return nil, &exitError{reason: reasonWarmUp, code: exitStartupTimeout, err: err}
+			}
+			warmUpDone = nil
+			logger.Info("Gebunden headless mode running",
+				"http", "http://"+net.JoinHostPort(httpListen, strconv.Itoa(httpPort)),
+				"bridge", strings.Join(bridgeURLs, ","),
+				"bridgeStrategy", bridgeStrategy,
+				"autoApprove", autoApprove,
+			)
+		case sig = <-sigCh:
+			if sig != syscall.SIGHUP {
+				break wait
+			}
+			reloadWallet(logger, walletService, keyFile)
+		case err := <-serverErr:
+			exit = &exitError{reason: reasonHTTPServer, code: exitHTTPServer, err: err}
+			break wait
+		}
+	}
+	// Abandon a warm-up still in progress before shutting the wallet down.
+	if warmUpDone != nil {
+		stopWarmUp()
+		<-warmUpDone
+	}
 
 	logger.Info("Shutting down...")
 	httpServer.Stop()
+	// Flushed caches make the next start warm.
+	if err := walletService.FlushCaches(context.Background()); err != nil {
+		logger.Warn("Failed to flush caches", "error", err)
+	}
 	walletService.ShutdownWallet()
 	logger.Info("Goodbye")
+	return sig, exit
+}
+
+// reloadWallet re-reads the private key and swaps the wallet over to it,
+// keeping the current wallet if the key cannot be loaded.
+func reloadWallet(logger *slog.Logger, ws *WalletService, keyFile string) {
+	logger.Info("SIGHUP received, reloading wallet key")
+	privateKey, network, passphrase, err := loadPrivateKey(keyFile)
+	if err != nil {
+		logger.Error("Key reload refused, keeping current wallet", "error", err)
+		return
+	}
+	if err := ws.ReinitializeWalletWithPassphrase(privateKey, network, passphrase); err != nil {
+		logger.Error("Key reload refused, keeping current wallet", "error", err)
+		return
+	}
+	logger.Info("Wallet key reloaded", "network", network)
 }
 
 // loadPrivateKey loads the wallet private key from a file or environment variable.
 // Priority: 1) -key-file flag, 2) GEBUNDEN_PRIVATE_KEY env, 3) ~/.gebunden/wallet-identity.json
-func loadPrivateKey(keyFile string) (privateKeyHex, network string, err error) {
+// For an encrypted key file it also returns the passphrase that unlocked
+// it, for the storage encryption; the caller wipes it once done.
+func loadPrivateKey(keyFile string) (privateKeyHex, network string, passphrase []byte, err error) {
 	// Check env first
 	if envKey := os.Getenv("GEBUNDEN_PRIVATE_KEY"); envKey != "" {
-		net := os.Getenv("GEBUNDEN_NETWORK")
-		if net == "" || net == "mainnet" {
-			net = "main"
-		} else if net == "testnet" {
-			net = "test"
+		net, err := normalizeNetwork(os.Getenv("GEBUNDEN_NETWORK"))
+		if err != nil {
+			return "", "", nil, fmt.Errorf("GEBUNDEN_NETWORK: %w", err)
 		}
-		return envKey, net, nil
+		return envKey, net, nil, nil
 	}
 
 	// Determine file path
@@ -100,7 +336,7 @@ func loadPrivateKey(keyFile string) (privateKeyHex, network string, err error) {
 	if path == "" {
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
-			return "", "", fmt.Errorf("failed to get home directory: %w", err)
+			return "", "", nil, fmt.Errorf("failed to get home directory: %w", err)
 		}
 		// Search paths in order of preference
 		candidates := []string{
@@ -114,30 +350,59 @@ func loadPrivateKey(keyFile string) (privateKeyHex, network string, err error) {
 			}
 		}
 		if path == "" {
-			return "", "", fmt.Errorf("no wallet identity file found; tried %v", candidates)
+			return "", "", nil, fmt.Errorf("no wallet identity file found; tried %v", candidates)
 		}
 	}
 
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to read key file %s: %w", path, err)
+		return "", "", nil, fmt.Errorf("failed to read key file %s: %w", path, err)
 	}
 
 	var identity walletIdentity
 	if err := json.Unmarshal(data, &identity); err != nil {
-		return "", "", fmt.Errorf("failed to parse key file: %w", err)
+		return "", "", nil, fmt.Errorf("failed to parse key file: %w", err)
+	}
+
+	if identity.Encrypted {
+		passphrase, err = readPassphrase(fmt.Sprintf("Passphrase for %s: ", path))
+		if err != nil {
+			return "", "", nil, err
+		}
+		identity.RootKeyHex, err = decryptIdentity(identity, passphrase)
+		if err != nil {
+			clear(passphrase)
+			return "", "", nil, fmt.Errorf("%s: %w", path, err)
+		}
 	}
 
 	if identity.RootKeyHex == "" {
-		return "", "", fmt.Errorf("rootKeyHex is empty in %s", path)
+		clear(passphrase)
+		return "", "", nil, fmt.Errorf("rootKeyHex is empty in %s", path)
 	}
 
-	net := identity.Network
-	if net == "" || net == "mainnet" {
-		net = "main"
-	} else if net == "testnet" {
-		net = "test"
+	net, err := normalizeNetwork(identity.Network)
+	if err != nil {
+		clear(passphrase)
+		return "", "", nil, fmt.Errorf("%s: %w", path, err)
 	}
 
-	return identity.RootKeyHex, net, nil
+	if len(passphrase) == 0 {
+		passphrase = nil
+	}
+	return identity.RootKeyHex, net, passphrase, nil
+}
+
+// normalizeNetwork maps a configured network name to the wallet's chain
+// name. An empty name means mainnet.
+func normalizeNetwork(name string) (string, error) {
+	switch name {
+	case "", "mainnet", "main":
+		return "main", nil
+	case "testnet", "test":
+		return "test", nil
+	case "regtest":
+		return "regtest", nil
+	}
+	return "", fmt.Errorf("unknown network %q (want mainnet, testnet or regtest)", name)
 }
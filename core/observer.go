@@ -0,0 +1,65 @@
+package main
+
+import (
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+)
+
+// ActionObserver is told about the actions the wallet creates and signs,
+// for example to keep metrics without tracing. Its methods run on a
+// goroutine of their own once the call has succeeded, so they never hold
+// it up, and a panic in them is recovered and logged.
+type ActionObserver interface {
+	// OnActionCreated reports an action created with sats satoshis going
+	// to its outputs. txid is empty when the action awaits signAction.
+	OnActionCreated(txid string, sats int64)
+	// OnActionSigned reports an action signed by signAction.
+	OnActionSigned(txid string)
+}
+
+// WithActionObserver has the wallet report created and signed actions to o.
+func WithActionObserver(o ActionObserver) WalletServiceOption {
+	return func(ws *WalletService) {
+		ws.actionObserver = o
+	}
+}
+
+// observe hands fn the action observer, if any, off the caller's
+// goroutine.
+func (ws *WalletService) observe(callback string, fn func(ActionObserver)) {
+	o := ws.actionObserver
+	if o == nil {
+		return
+	}
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				ws.logger.Error("Action observer panicked", "callback", callback, "panic", r)
+			}
+		}()
+		fn(o)
+	}()
+}
+
+func (ws *WalletService) actionCreated(result *sdk.CreateActionResult, sats int64) {
+	if result == nil {
+		return
+	}
+	txid := observedTxid(result.Txid)
+	ws.observe("OnActionCreated", func(o ActionObserver) { o.OnActionCreated(txid, sats) })
+}
+
+func (ws *WalletService) actionSigned(result *sdk.SignActionResult) {
+	if result == nil {
+		return
+	}
+	txid := observedTxid(result.Txid)
+	ws.observe("OnActionSigned", func(o ActionObserver) { o.OnActionSigned(txid) })
+}
+
+func observedTxid(txid chainhash.Hash) string {
+	if txid == (chainhash.Hash{}) {
+		return ""
+	}
+	return txid.String()
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+)
+
+// recordingObserver sends each callback it gets down a channel.
+type recordingObserver struct{ events chan string }
+
+func (o recordingObserver) OnActionCreated(txid string, sats int64) {
+	o.events <- "created " + txid
+}
+
+func (o recordingObserver) OnActionSigned(txid string) {
+	o.events <- "signed " + txid
+}
+
+// panickingObserver panics in every callback.
+type panickingObserver struct{}
+
+func (panickingObserver) OnActionCreated(string, int64) { panic("observer bug") }
+func (panickingObserver) OnActionSigned(string)         { panic("observer bug") }
+
+func TestActionObserverIsNotified(t *testing.T) {
+	o := recordingObserver{events: make(chan string, 2)}
+	ws := NewWalletService(WithActionObserver(o))
+	w := &fakeActionWallet{}
+
+	created, err := ws.createDataAction(context.Background(), w, "", [][]byte{[]byte("hello")}, "example.com")
+	if err != nil {
+		t.Fatalf("createDataAction: %v", err)
+	}
+	ws.actionSigned(&sdk.SignActionResult{Txid: chainhash.Hash{1}})
+
+	want := map[string]bool{"created " + created.Txid.String(): true, "signed " + chainhash.Hash{1}.String(): true}
+	for range want {
+		select {
+		case event := <-o.events:
+			if !want[event] {
+				t.Errorf("unexpected callback %q", event)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("observer not called")
+		}
+	}
+
+	// Failed calls are not reported.
+	if _, err := ws.createDataAction(context.Background(), w, "", nil, "example.com"); err == nil {
+		t.Fatal("expected an action without data to be rejected")
+	}
+	select {
+	case event := <-o.events:
+		t.Fatalf("callback %q for a failed call", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestActionObserverPanicIsRecovered(t *testing.T) {
+	ws := NewWalletService(WithActionObserver(panickingObserver{}))
+	if _, err := ws.createDataAction(context.Background(), &fakeActionWallet{}, "", [][]byte{[]byte("hello")}, "example.com"); err != nil {
+		t.Fatalf("createDataAction: %v", err)
+	}
+	// Give the observer's goroutine time to panic; the test binary
+	// would die if it were not recovered.
+	time.Sleep(50 * time.Millisecond)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrOperationTimeout is the cause of a wallet call's context ending when
+// the call ran past the default operation timeout.
+var ErrOperationTimeout = errors.New("wallet operation timed out")
+
+// WithDefaultOperationTimeout bounds every wallet method call whose context
+// has no deadline of its own to d, so a stalled overlay lookup, chain
+// tracker or storage backend cannot hang the caller. Time spent waiting on
+// a permission prompt is not counted. Zero, the default, leaves calls
+// unbounded.
+func WithDefaultOperationTimeout(d time.Duration) WalletServiceOption {
+	return func(ws *WalletService) {
+		ws.opTimeout = d
+	}
+}
+
+// withOperationTimeout bounds ctx by the default operation timeout unless
+// it is unset or ctx already has a deadline.
+func (ws *WalletService) withOperationTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ws.opTimeout <= 0 {
+		return ctx, func() {}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeoutCause(ctx, ws.opTimeout, ErrOperationTimeout)
+}
+
+// operationTimer ends a call's context once the call has run for its
+// timeout, leaving out the time it is paused for permission prompts.
+type operationTimer struct {
+	mu        sync.Mutex
+	timer     *time.Timer
+	remaining time.Duration
+	started   time.Time
+	paused    int
+}
+
+// newOperationTimer returns ctx bounded by d of running time. The timer
+// is paused and resumed through the returned operationTimer, and the
+// returned function releases it.
+func newOperationTimer(ctx context.Context, d time.Duration) (context.Context, *operationTimer, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	t := &operationTimer{remaining: d, started: time.Now()}
+	t.timer = time.AfterFunc(d, func() { cancel(ErrOperationTimeout) })
+	return ctx, t, func() {
+		t.timer.Stop()
+		cancel(context.Canceled)
+	}
+}
+
+func (t *operationTimer) pause() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.paused == 0 && t.timer.Stop() {
+		t.remaining -= time.Since(t.started)
+	}
+	t.paused++
+}
+
+func (t *operationTimer) resume() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.paused--
+	if t.paused == 0 {
+		// Should the timer have fired already, firing it again is
+		// harmless: the context is ended once.
+		t.started = time.Now()
+		t.timer.Reset(max(t.remaining, 0))
+	}
+}
+
+// operationError reports err as a timeout when ctx ended because its call
+// ran out of time, rather than as the bare context cancellation the
+// wallet passes back.
+func (ws *WalletService) operationError(ctx context.Context, err error) error {
+	if err != nil && errors.Is(context.Cause(ctx), ErrOperationTimeout) && !errors.Is(err, ErrOperationTimeout) {
+		return fmt.Errorf("%w after %v: %w", ErrOperationTimeout, ws.opTimeout, err)
+	}
+	return err
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// slowGate approves every request after a delay.
+type slowGate struct{ delay time.Duration }
+
+func (g slowGate) RequestPermission(PermissionRequest) (bool, error) {
+	time.Sleep(g.delay)
+	return true, nil
+}
+
+func TestOperationTimeoutEndsStalledCall(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("NO_PROXY", "")
+
+	// A proxy standing in for an overlay that never answers.
+	stalled := make(chan struct{})
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-stalled
+	}))
+	defer proxy.Close()
+	defer close(stalled)
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	ws := NewWalletService(WithHTTPProxy(proxyURL), WithDefaultOperationTimeout(200*time.Millisecond))
+	if err := ws.InitializeWallet(newKey(t).Hex(), "test"); err != nil {
+		t.Fatalf("InitializeWallet: %v", err)
+	}
+	defer ws.ShutdownWallet()
+
+	start := time.Now()
+	args := `{"identityKey":"` + newKey(t).PubKey().ToDERHex() + `"}`
+	_, err := ws.CallWalletMethod("discoverByIdentityKey", args, "example.com")
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("discoverByIdentityKey returned after %v, want it cut off by the timeout", elapsed)
+	}
+	if err != nil && !errors.Is(err, ErrOperationTimeout) {
+		t.Fatalf("err = %v, want ErrOperationTimeout", err)
+	}
+}
+
+func TestOperationTimeoutSkipsPromptsAndDeadlines(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ws := NewWalletService(WithDefaultOperationTimeout(100 * time.Millisecond))
+	ws.SetPermissionGate(slowGate{300 * time.Millisecond})
+	if err := ws.InitializeWallet(newKey(t).Hex(), "test"); err != nil {
+		t.Fatalf("InitializeWallet: %v", err)
+	}
+	defer ws.ShutdownWallet()
+
+	// The prompt outlasts the timeout; the unfunded wallet's own error
+	// should come back, not a timeout.
+	args := `{"description":"test payment","outputs":[{"lockingScript":"76a914f4d7b4a4f8e0a1d0e6b4c0c3f0a7b1d2c3e4f5a688ac","satoshis":1000,"outputDescription":"pay"}]}`
+	if _, err := ws.CallWalletMethod("createAction", args, "example.com"); errors.Is(err, ErrOperationTimeout) {
+		t.Fatalf("createAction timed out while waiting on the prompt: %v", err)
+	}
+
+	// A caller's own deadline is left alone.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	bounded, release := ws.withOperationTimeout(ctx)
+	defer release()
+	if deadline, _ := bounded.Deadline(); time.Until(deadline) < time.Minute {
+		t.Fatalf("deadline %v replaced the caller's", deadline)
+	}
+	bounded, release = ws.withOperationTimeout(context.Background())
+	defer release()
+	if deadline, ok := bounded.Deadline(); !ok || time.Until(deadline) > time.Second {
+		t.Fatalf("deadline = %v, %v; want the default timeout", deadline, ok)
+	}
+}
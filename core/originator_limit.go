@@ -0,0 +1,57 @@
+package main
+
+import "sync"
+
+// originatorLimiter caps how many requests each originator may have in
+// flight at once, so that one app holding many connections open cannot
+// starve the others of the wallet.
+type originatorLimiter struct {
+	max      int
+	mu       sync.Mutex
+	inflight map[string]int
+}
+
+func newOriginatorLimiter(max int) *originatorLimiter {
+	return &originatorLimiter{max: max, inflight: make(map[string]int)}
+}
+
+// acquire takes one of origin's request slots, reporting false if it has
+// none left.
+func (l *originatorLimiter) acquire(origin string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inflight[origin] >= l.max {
+		return false
+	}
+	l.inflight[origin]++
+	return true
+}
+
+// release gives back a slot taken by acquire.
+func (l *originatorLimiter) release(origin string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inflight[origin]--; l.inflight[origin] <= 0 {
+		delete(l.inflight, origin)
+	}
+}
+
+// inFlight is how many requests origin has in flight.
+func (l *originatorLimiter) inFlight(origin string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inflight[origin]
+}
+
+// SetMaxInFlightPerOriginator caps the wallet requests each originator may
+// have in flight; the excess is answered 429 rather than queued. Zero, the
+// default, sets no cap.
+func (s *HTTPServer) SetMaxInFlightPerOriginator(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n <= 0 {
+		s.limiter = nil
+		return
+	}
+	s.limiter = newOriginatorLimiter(n)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMaxInFlightPerOriginator(t *testing.T) {
+	s := NewHTTPServer(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	s.SetMaxInFlightPerOriginator(2)
+	srv := httptest.NewServer(http.HandlerFunc(s.handleRequest))
+	defer srv.Close()
+
+	post := func(origin string, body io.Reader) int {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/getVersion", body)
+		req.Header.Set("Origin", "http://"+origin)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Errorf("request from %s: %v", origin, err)
+			return 0
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// Two requests from greedy.example whose bodies have not finished
+	// arriving hold both of its slots.
+	var writers []*io.PipeWriter
+	done := make(chan int, 2)
+	for range 2 {
+		pr, pw := io.Pipe()
+		writers = append(writers, pw)
+		go func() { done <- post("greedy.example", pr) }()
+	}
+	for deadline := time.Now().Add(2 * time.Second); s.limiter.inFlight("greedy.example") < 2; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("greedy.example's requests never reached the server")
+		}
+	}
+
+	if got := post("greedy.example", nil); got != http.StatusTooManyRequests {
+		t.Fatalf("third concurrent request from greedy.example: status %d, want 429", got)
+	}
+	// No wallet is set, so a request let through is answered 503.
+	if got := post("other.example", nil); got != http.StatusServiceUnavailable {
+		t.Fatalf("request from other.example: status %d, want it let through", got)
+	}
+
+	for _, pw := range writers {
+		pw.Close()
+	}
+	for range 2 {
+		if got := <-done; got != http.StatusServiceUnavailable {
+			t.Fatalf("held request from greedy.example: status %d, want it let through", got)
+		}
+	}
+	if n := s.limiter.inFlight("greedy.example"); n != 0 {
+		t.Fatalf("%d slots still held after the requests finished", n)
+	}
+	if got := post("greedy.example", nil); got != http.StatusServiceUnavailable {
+		t.Fatalf("request from greedy.example after its others finished: status %d", got)
+	}
+}
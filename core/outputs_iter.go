@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+)
+
+// outputsPageSize is how many outputs IterateOutputs holds at a time.
+const outputsPageSize = 1000
+
+// outputLister is the part of the wallet IterateOutputs pages through.
+// *wallet.Wallet implements it.
+type outputLister interface {
+	ListOutputs(ctx context.Context, args sdk.ListOutputsArgs, originator string) (*sdk.ListOutputsResult, error)
+}
+
+// IterateOutputs calls fn for each output ListOutputs would return for
+// args, in order, until fn returns false. It pages through storage so that
+// only one page of outputs is held at a time however large the wallet is.
+// args.Offset is where to start and args.Limit, unlike for ListOutputs,
+// caps the outputs visited in total; a nil Limit visits them all.
+func (ws *WalletService) IterateOutputs(ctx context.Context, args sdk.ListOutputsArgs, originator string, fn func(sdk.Output) bool) error {
+	ws.mu.RLock()
+	w := ws.wallet
+	ws.mu.RUnlock()
+
+	if w == nil {
+		return errWalletNotInitialized
+	}
+	ctx, cancel := ws.withOperationTimeout(ctx)
+	defer cancel()
+	return iterateOutputs(ctx, w, args, originator, fn)
+}
+
+func iterateOutputs(ctx context.Context, w outputLister, args sdk.ListOutputsArgs, originator string, fn func(sdk.Output) bool) error {
+	remaining := uint32(math.MaxUint32)
+	if args.Limit != nil {
+		remaining = *args.Limit
+	}
+	var offset uint32
+	if args.Offset != nil {
+		offset = *args.Offset
+	}
+	for remaining > 0 {
+		limit, start := min(remaining, outputsPageSize), offset
+		args.Limit, args.Offset = &limit, &start
+		page, err := w.ListOutputs(ctx, args, originator)
+		if err != nil {
+			return fmt.Errorf("failed to list outputs: %w", err)
+		}
+		for _, out := range page.Outputs {
+			if !fn(out) {
+				return nil
+			}
+		}
+		n := uint32(len(page.Outputs))
+		offset += n
+		remaining -= n
+		if n < limit || offset >= page.TotalOutputs {
+			return nil
+		}
+	}
+	return nil
+}
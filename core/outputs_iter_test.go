@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+)
+
+func TestIterateOutputsPages(t *testing.T) {
+	f := &fakeLister{}
+	total := 2*outputsPageSize + 500
+	for i := 0; i < total; i++ {
+		f.outputs = append(f.outputs, sdk.Output{Satoshis: uint64(i)})
+	}
+	ctx := context.Background()
+
+	// Every output, in order, one page at a time.
+	var seen int
+	err := iterateOutputs(ctx, f, sdk.ListOutputsArgs{Basket: "tokens"}, "example.com", func(out sdk.Output) bool {
+		if out.Satoshis != uint64(seen) {
+			t.Fatalf("output %d has satoshis %d", seen, out.Satoshis)
+		}
+		seen++
+		return true
+	})
+	if err != nil {
+		t.Fatalf("iterateOutputs: %v", err)
+	}
+	if seen != total || f.calls != 3 {
+		t.Errorf("saw %d outputs in %d calls, want %d in 3", seen, f.calls, total)
+	}
+	for _, basket := range f.baskets {
+		if basket != "tokens" {
+			t.Fatalf("listed basket %q, want tokens", basket)
+		}
+	}
+
+	// Stopping early fetches no further pages.
+	f.calls, seen = 0, 0
+	iterateOutputs(ctx, f, sdk.ListOutputsArgs{}, "example.com", func(sdk.Output) bool {
+		seen++
+		return seen < outputsPageSize+1
+	})
+	if seen != outputsPageSize+1 || f.calls != 2 {
+		t.Errorf("early stop saw %d outputs in %d calls, want %d in 2", seen, f.calls, outputsPageSize+1)
+	}
+
+	// Offset and Limit select a window across a page boundary.
+	offset, limit := uint32(outputsPageSize-10), uint32(30)
+	var got []uint64
+	iterateOutputs(ctx, f, sdk.ListOutputsArgs{Offset: &offset, Limit: &limit}, "example.com", func(out sdk.Output) bool {
+		got = append(got, out.Satoshis)
+		return true
+	})
+	if len(got) != 30 || got[0] != uint64(offset) || got[29] != uint64(offset+29) {
+		t.Errorf("window gave %d outputs from %v, want 30 from %d", len(got), got[:min(1, len(got))], offset)
+	}
+}
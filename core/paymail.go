@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bsv-blockchain/go-sdk/script"
+)
+
+// paymailCacheTTL is how long a resolved destination is reused for the
+// same paymail and amount.
+const paymailCacheTTL = 30 * time.Second
+
+// paymailTimeout bounds each request to a paymail service.
+const paymailTimeout = 15 * time.Second
+
+// Capability names under which paymail services advertise the payment
+// destination endpoint: its name and its BRFC ID.
+var paymentDestinationCapabilities = []string{"paymentDestination", "759684b1a19a"}
+
+// ErrInvalidPaymail is matched by errors for handles that are not of the
+// form alias@domain.tld.
+var ErrInvalidPaymail = errors.New("invalid paymail")
+
+// ResolvePaymailArgs are the args of the resolvePaymail call.
+type ResolvePaymailArgs struct {
+	Paymail string `json:"paymail"`
+	Amount  uint64 `json:"amount"`
+}
+
+// ResolvePaymailResult is the result of the resolvePaymail call.
+type ResolvePaymailResult struct {
+	LockingScript string `json:"lockingScript"`
+}
+
+// paymailResolver looks up payment destinations with the bsvalias
+// protocol and caches them briefly.
+type paymailResolver struct {
+	client *http.Client
+	// wellKnownURL returns the URL of domain's capability document.
+	wellKnownURL func(ctx context.Context, domain string) string
+
+	mu    sync.Mutex
+	cache map[string]cachedDestination
+}
+
+type cachedDestination struct {
+	script  *script.Script
+	expires time.Time
+}
+
+// newPaymailResolver returns a resolver making its requests with client,
+// or with a default client if it is nil.
+func newPaymailResolver(client *http.Client) *paymailResolver {
+	if client == nil {
+		client = &http.Client{Timeout: paymailTimeout}
+	}
+	return &paymailResolver{
+		client:       client,
+		wellKnownURL: wellKnownURL,
+		cache:        make(map[string]cachedDestination),
+	}
+}
+
+// wellKnownURL finds domain's paymail host from its _bsvalias SRV record,
+// falling back to the domain itself on port 443.
+func wellKnownURL(ctx context.Context, domain string) string {
+	host := domain
+	if _, records, err := net.DefaultResolver.LookupSRV(ctx, "bsvalias", "tcp", domain); err == nil && len(records) > 0 {
+		host = strings.TrimSuffix(records[0].Target, ".")
+		if records[0].Port != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(int(records[0].Port)))
+		}
+	}
+	return "https://" + host + "/.well-known/bsvalias"
+}
+
+// ResolvePaymail returns the output script to pay amount satoshis to the
+// paymail handle, as its paymail service gives it.
+func (ws *WalletService) ResolvePaymail(ctx context.Context, paymail string, amount uint64, originator string) (*script.Script, error) {
+	ws.logger.Debug("Resolving paymail", "paymail", paymail, "amount", amount, "origin", originator)
+	ctx, cancel := ws.withOperationTimeout(ctx)
+	defer cancel()
+	return ws.paymail.resolve(ctx, paymail, amount)
+}
+
+func (r *paymailResolver) resolve(ctx context.Context, paymail string, amount uint64) (*script.Script, error) {
+	alias, domain, err := parsePaymail(paymail)
+	if err != nil {
+		return nil, err
+	}
+	handle := alias + "@" + domain
+
+	key := handle + "|" + strconv.FormatUint(amount, 10)
+	r.mu.Lock()
+	cached, ok := r.cache[key]
+	r.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.script, nil
+	}
+
+	endpoint, err := r.paymentDestinationURL(ctx, domain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve paymail %s: %w", handle, err)
+	}
+	endpoint = endpointFor(endpoint, alias, domain)
+
+	body, _ := json.Marshal(map[string]any{
+		"senderName": "Gebunden",
+		"dt":         time.Now().UTC().Format(time.RFC3339),
+		"amount":     amount,
+	})
+	var destination struct {
+		Output string `json:"output"`
+	}
+	if err := r.do(ctx, http.MethodPost, endpoint, body, &destination); err != nil {
+		return nil, fmt.Errorf("failed to resolve paymail %s: %w", handle, err)
+	}
+	raw, err := hex.DecodeString(destination.Output)
+	if err != nil || len(raw) == 0 {
+		return nil, fmt.Errorf("failed to resolve paymail %s: service returned invalid output %q", handle, destination.Output)
+	}
+	s := script.NewFromBytes(raw)
+
+	now := time.Now()
+	r.mu.Lock()
+	for k, c := range r.cache {
+		if now.After(c.expires) {
+			delete(r.cache, k)
+		}
+	}
+	r.cache[key] = cachedDestination{script: s, expires: now.Add(paymailCacheTTL)}
+	r.mu.Unlock()
+	return s, nil
+}
+
+// paymentDestinationURL reads domain's capability document and returns
+// its payment destination URL template.
+func (r *paymailResolver) paymentDestinationURL(ctx context.Context, domain string) (string, error) {
+	caps, err := r.capabilities(ctx, domain)
+	if err != nil {
+		return "", err
+	}
+	endpoint := capability(caps, paymentDestinationCapabilities...)
+	if endpoint == "" {
+		return "", fmt.Errorf("%s does not offer payment destinations", domain)
+	}
+	return endpoint, nil
+}
+
+// capabilities fetches domain's capability document.
+func (r *paymailResolver) capabilities(ctx context.Context, domain string) (map[string]any, error) {
+	var doc struct {
+		Capabilities map[string]any `json:"capabilities"`
+	}
+	if err := r.do(ctx, http.MethodGet, r.wellKnownURL(ctx, domain), nil, &doc); err != nil {
+		return nil, fmt.Errorf("capability discovery: %w", err)
+	}
+	return doc.Capabilities, nil
+}
+
+// capability returns the endpoint caps lists under the first of names it
+// has, or "" if it has none.
+func capability(caps map[string]any, names ...string) string {
+	for _, name := range names {
+		if endpoint, ok := caps[name].(string); ok && endpoint != "" {
+			return endpoint
+		}
+	}
+	return ""
+}
+
+// endpointFor fills alias and domain into an endpoint URL template.
+func endpointFor(template, alias, domain string) string {
+	return strings.NewReplacer("{alias}", alias, "{domain.tld}", domain).Replace(template)
+}
+
+// do sends a request with an optional JSON body and decodes the JSON
+// response into out.
+func (r *paymailResolver) do(ctx context.Context, method, url string, body []byte, out any) error {
+	ctx, cancel := context.WithTimeout(ctx, paymailTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s: %s", method, url, resp.Status)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out); err != nil {
+		return fmt.Errorf("%s %s: invalid response: %w", method, url, err)
+	}
+	return nil
+}
+
+// parsePaymail splits a paymail handle into its alias and domain, both
+// lowercased.
+func parsePaymail(paymail string) (alias, domain string, err error) {
+	alias, domain, ok := strings.Cut(strings.ToLower(strings.TrimSpace(paymail)), "@")
+	if !ok || alias == "" || strings.ContainsFunc(alias, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || strings.ContainsRune(".-_+", r))
+	}) {
+		return "", "", fmt.Errorf("%w %q: want alias@domain.tld", ErrInvalidPaymail, paymail)
+	}
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return "", "", fmt.Errorf("%w %q: want alias@domain.tld", ErrInvalidPaymail, paymail)
+	}
+	for _, label := range labels {
+		if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") || strings.ContainsFunc(label, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-')
+		}) {
+			return "", "", fmt.Errorf("%w %q: bad domain", ErrInvalidPaymail, paymail)
+		}
+	}
+	return alias, domain, nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+	sdktx "github.com/bsv-blockchain/go-sdk/transaction"
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+)
+
+// Capabilities of the paymail P2P transactions protocol: asking for
+// destinations, and handing the recipient the transaction paying them.
+var (
+	p2pDestinationCapabilities     = []string{"2a40af698840"}
+	receiveTransactionCapabilities = []string{"5f1323cddf31"}
+)
+
+// SendToPaymailArgs are the args of the sendToPaymail call.
+type SendToPaymailArgs struct {
+	Paymail string `json:"paymail"`
+	Amount  uint64 `json:"amount"`
+	Note    string `json:"note,omitempty"`
+}
+
+// SendToPaymailResult is the result of the sendToPaymail call. Reference
+// and Note are set when the payment was delivered to the recipient's
+// paymail service rather than only broadcast.
+type SendToPaymailResult struct {
+	Txid      string `json:"txid"`
+	Reference string `json:"reference,omitempty"`
+	Note      string `json:"note,omitempty"`
+}
+
+// actionCreator is the part of the wallet sending to a paymail uses.
+type actionCreator interface {
+	CreateAction(ctx context.Context, args sdk.CreateActionArgs, originator string) (*sdk.CreateActionResult, error)
+	AbortAction(ctx context.Context, args sdk.AbortActionArgs, originator string) (*sdk.AbortActionResult, error)
+}
+
+// SendToPaymail pays amount satoshis to the paymail handle. When the
+// handle's service speaks the P2P transactions protocol, the transaction
+// is built without being broadcast and handed to the service first; if it
+// refuses it, the transaction is aborted, so nothing is spent. Otherwise
+// the wallet pays the service's payment destination directly.
+func (ws *WalletService) SendToPaymail(ctx context.Context, paymail string, amount uint64, originator string) (*SendToPaymailResult, error) {
+	ws.mu.RLock()
+	w := ws.wallet
+	ws.mu.RUnlock()
+
+	if w == nil {
+		return nil, errWalletNotInitialized
+	}
+	ctx, cancel := ws.withOperationTimeout(ctx)
+	defer cancel()
+	return ws.sendToPaymail(ctx, w, paymail, amount, "", originator)
+}
+
+func (ws *WalletService) sendToPaymail(ctx context.Context, w actionCreator, paymail string, amount uint64, note string, originator string) (*SendToPaymailResult, error) {
+	r := ws.paymail
+	alias, domain, err := parsePaymail(paymail)
+	if err != nil {
+		return nil, err
+	}
+	handle := alias + "@" + domain
+	if amount == 0 {
+		return nil, fmt.Errorf("amount must be more than zero")
+	}
+
+	caps, err := r.capabilities(ctx, domain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve paymail %s: %w", handle, err)
+	}
+	destinationURL := capability(caps, p2pDestinationCapabilities...)
+	receiveURL := capability(caps, receiveTransactionCapabilities...)
+	if destinationURL == "" || receiveURL == "" {
+		lockingScript, err := r.resolve(ctx, handle, amount)
+		if err != nil {
+			return nil, err
+		}
+		result, err := w.CreateAction(ctx, sdk.CreateActionArgs{
+			Description: "Payment to " + handle,
+			Outputs: []sdk.CreateActionOutput{{
+				LockingScript:     lockingScript.Bytes(),
+				Satoshis:          amount,
+				OutputDescription: "Payment to " + handle,
+			}},
+		}, originator)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pay %s: %w", handle, err)
+		}
+		ws.actionCreated(result, int64(amount))
+		return &SendToPaymailResult{Txid: result.Txid.String()}, nil
+	}
+
+	// Step 1: ask the recipient where to send.
+	var destination struct {
+		Outputs []struct {
+			Script   string `json:"script"`
+			Satoshis uint64 `json:"satoshis"`
+		} `json:"outputs"`
+		Reference string `json:"reference"`
+	}
+	body, _ := json.Marshal(map[string]any{"satoshis": amount})
+	if err := r.do(ctx, http.MethodPost, endpointFor(destinationURL, alias, domain), body, &destination); err != nil {
+		return nil, fmt.Errorf("failed to get payment destination from %s: %w", handle, err)
+	}
+	if len(destination.Outputs) == 0 || destination.Reference == "" {
+		return nil, fmt.Errorf("failed to get payment destination from %s: no outputs or reference", handle)
+	}
+	outputs := make([]sdk.CreateActionOutput, 0, len(destination.Outputs))
+	var total uint64
+	for i, out := range destination.Outputs {
+		lockingScript, err := hex.DecodeString(out.Script)
+		if err != nil || len(lockingScript) == 0 {
+			return nil, fmt.Errorf("payment destination from %s: output %d has an invalid script", handle, i)
+		}
+		total += out.Satoshis
+		outputs = append(outputs, sdk.CreateActionOutput{
+			LockingScript:     lockingScript,
+			Satoshis:          out.Satoshis,
+			OutputDescription: "Payment to " + handle,
+		})
+	}
+	if total != amount {
+		return nil, fmt.Errorf("payment destination from %s: outputs total %d satoshis, asked to pay %d", handle, total, amount)
+	}
+
+	// Step 2: build and sign the transaction, but keep it to ourselves.
+	noSend := true
+	result, err := w.CreateAction(ctx, sdk.CreateActionArgs{
+		Description: "Payment to " + handle,
+		Outputs:     outputs,
+		Options:     &sdk.CreateActionOptions{NoSend: &noSend},
+	}, originator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pay %s: %w", handle, err)
+	}
+	txid := result.Txid.String()
+	abort := func(cause error) error {
+		if _, err := w.AbortAction(ctx, sdk.AbortActionArgs{Reference: []byte(txid)}, originator); err != nil {
+			return fmt.Errorf("%w; aborting transaction %s also failed: %v", cause, txid, err)
+		}
+		return cause
+	}
+	tx, err := sdktx.NewTransactionFromBEEF(result.Tx)
+	if err != nil || tx == nil {
+		return nil, abort(fmt.Errorf("failed to pay %s: created transaction is unreadable", handle))
+	}
+
+	// Step 3: hand it to the recipient, who broadcasts it.
+	var receipt struct {
+		Txid string `json:"txid"`
+		Note string `json:"note"`
+	}
+	body, _ = json.Marshal(map[string]any{
+		"hex":       tx.Hex(),
+		"reference": destination.Reference,
+		"metadata":  map[string]any{"note": note},
+	})
+	if err := r.do(ctx, http.MethodPost, endpointFor(receiveURL, alias, domain), body, &receipt); err != nil {
+		return nil, abort(fmt.Errorf("%s did not accept the payment: %w", handle, err))
+	}
+	if receipt.Txid != "" && receipt.Txid != txid {
+		return nil, abort(fmt.Errorf("%s acknowledged transaction %s, not %s", handle, receipt.Txid, txid))
+	}
+	ws.actionCreated(result, int64(amount))
+
+	// Step 4: broadcast it too. The recipient has it, so a failure here
+	// is not a failed payment.
+	if _, err := w.CreateAction(ctx, sdk.CreateActionArgs{
+		Description: "Broadcast payment to " + handle,
+		Options:     &sdk.CreateActionOptions{SendWith: []chainhash.Hash{result.Txid}},
+	}, originator); err != nil {
+		ws.logger.Warn("Paymail payment delivered but not broadcast by the wallet", "paymail", handle, "txid", txid, "error", err)
+	}
+	return &SendToPaymailResult{Txid: txid, Reference: destination.Reference, Note: receipt.Note}, nil
+}
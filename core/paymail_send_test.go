@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bsv-blockchain/go-sdk/script"
+	sdktx "github.com/bsv-blockchain/go-sdk/transaction"
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+)
+
+// fakeActionWallet builds unfunded transactions from createAction outputs
+// and records the calls it gets.
+type fakeActionWallet struct {
+	creates []sdk.CreateActionArgs
+	aborted []string
+}
+
+func (f *fakeActionWallet) CreateAction(_ context.Context, args sdk.CreateActionArgs, _ string) (*sdk.CreateActionResult, error) {
+	f.creates = append(f.creates, args)
+	if args.Options != nil && len(args.Options.SendWith) > 0 {
+		return &sdk.CreateActionResult{}, nil
+	}
+	tx := sdktx.NewTransaction()
+	for _, out := range args.Outputs {
+		tx.AddOutput(&sdktx.TransactionOutput{Satoshis: out.Satoshis, LockingScript: script.NewFromBytes(out.LockingScript)})
+	}
+	beef, err := tx.AtomicBEEF(false)
+	if err != nil {
+		return nil, err
+	}
+	return &sdk.CreateActionResult{Txid: *tx.TxID(), Tx: beef}, nil
+}
+
+func (f *fakeActionWallet) AbortAction(_ context.Context, args sdk.AbortActionArgs, _ string) (*sdk.AbortActionResult, error) {
+	f.aborted = append(f.aborted, string(args.Reference))
+	return &sdk.AbortActionResult{Aborted: true}, nil
+}
+
+// fakeP2PPaymailServer serves bob@example.com with the P2P transactions
+// capabilities. The receive endpoint answers with status accept.
+func fakeP2PPaymailServer(t *testing.T, accept int) *httptest.Server {
+	t.Helper()
+	const destinationScript = "76a914f4d7b4a4f8e0a1d0e6b4c0c3f0a7b1d2c3e4f5a688ac"
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	mux.HandleFunc("GET /.well-known/bsvalias", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"bsvalias": "1.0",
+			"capabilities": map[string]any{
+				"2a40af698840": srv.URL + "/p2p-payment-destination/{alias}@{domain.tld}",
+				"5f1323cddf31": srv.URL + "/receive-transaction/{alias}@{domain.tld}",
+			},
+		})
+	})
+	mux.HandleFunc("POST /p2p-payment-destination/bob@example.com", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Satoshis uint64 `json:"satoshis"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		json.NewEncoder(w).Encode(map[string]any{
+			"outputs":   []map[string]any{{"script": destinationScript, "satoshis": body.Satoshis}},
+			"reference": "ref-1",
+		})
+	})
+	mux.HandleFunc("POST /receive-transaction/bob@example.com", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Hex       string `json:"hex"`
+			Reference string `json:"reference"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		tx, err := sdktx.NewTransactionFromHex(body.Hex)
+		if err != nil || body.Reference != "ref-1" || len(tx.Outputs) != 1 || tx.Outputs[0].LockingScript.String() != destinationScript {
+			http.Error(w, "bad transaction", http.StatusBadRequest)
+			return
+		}
+		if accept != http.StatusOK {
+			http.Error(w, "rejected", accept)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"txid": tx.TxID().String(), "note": "thanks"})
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSendToPaymailP2P(t *testing.T) {
+	srv := fakeP2PPaymailServer(t, http.StatusOK)
+	ws := NewWalletService()
+	ws.paymail.wellKnownURL = func(context.Context, string) string { return srv.URL + "/.well-known/bsvalias" }
+	w := &fakeActionWallet{}
+
+	result, err := ws.sendToPaymail(context.Background(), w, "bob@example.com", 5000, "lunch", "example.com")
+	if err != nil {
+		t.Fatalf("sendToPaymail: %v", err)
+	}
+	if len(w.creates) != 2 || w.creates[0].Options == nil || w.creates[0].Options.NoSend == nil || !*w.creates[0].Options.NoSend {
+		t.Fatalf("createAction calls = %+v, want a nosend build then a broadcast", w.creates)
+	}
+	if sendWith := w.creates[1].Options.SendWith; len(sendWith) != 1 || sendWith[0].String() != result.Txid {
+		t.Fatalf("broadcast sendWith = %v, want %s", sendWith, result.Txid)
+	}
+	if result.Reference != "ref-1" || result.Note != "thanks" || len(w.aborted) != 0 {
+		t.Fatalf("result = %+v, aborted %v", result, w.aborted)
+	}
+}
+
+func TestSendToPaymailRejectedIsAborted(t *testing.T) {
+	srv := fakeP2PPaymailServer(t, http.StatusUnprocessableEntity)
+	ws := NewWalletService()
+	ws.paymail.wellKnownURL = func(context.Context, string) string { return srv.URL + "/.well-known/bsvalias" }
+	w := &fakeActionWallet{}
+
+	_, err := ws.sendToPaymail(context.Background(), w, "bob@example.com", 5000, "", "example.com")
+	if err == nil || !strings.Contains(err.Error(), "did not accept the payment") {
+		t.Fatalf("err = %v, want the recipient's refusal", err)
+	}
+	if len(w.creates) != 1 || len(w.aborted) != 1 {
+		t.Fatalf("%d createAction calls and aborts %v; want the built transaction aborted, not broadcast", len(w.creates), w.aborted)
+	}
+
+	if _, err := ws.sendToPaymail(context.Background(), w, "bob@example.com", 0, "", "example.com"); err == nil {
+		t.Fatal("expected a zero amount to be rejected")
+	}
+	if len(w.creates) != 1 {
+		t.Fatal("a rejected send reached createAction")
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// fakePaymailServer serves the bsvalias capability document and payment
+// destinations for alice@example.com, counting destination requests.
+func fakePaymailServer(t *testing.T, output string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	mux.HandleFunc("GET /.well-known/bsvalias", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"bsvalias": "1.0",
+			"capabilities": map[string]any{
+				"paymentDestination": srv.URL + "/api/v1/bsvalias/p2p-payment-destination/{alias}@{domain.tld}",
+			},
+		})
+	})
+	mux.HandleFunc("POST /api/v1/bsvalias/p2p-payment-destination/{handle}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("handle") != "alice@example.com" {
+			http.NotFound(w, r)
+			return
+		}
+		var body struct {
+			Amount uint64 `json:"amount"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Amount != 1000 {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		requests.Add(1)
+		json.NewEncoder(w).Encode(map[string]string{"output": output})
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestResolvePaymail(t *testing.T) {
+	const p2pkhOutput = "76a914f4d7b4a4f8e0a1d0e6b4c0c3f0a7b1d2c3e4f5a688ac"
+	srv, requests := fakePaymailServer(t, p2pkhOutput)
+	ws := NewWalletService()
+	ws.paymail.wellKnownURL = func(context.Context, string) string { return srv.URL + "/.well-known/bsvalias" }
+	ctx := context.Background()
+
+	got, err := ws.ResolvePaymail(ctx, "Alice@Example.com", 1000, "example.com")
+	if err != nil {
+		t.Fatalf("ResolvePaymail: %v", err)
+	}
+	if got.String() != p2pkhOutput {
+		t.Fatalf("script = %s, want %s", got, p2pkhOutput)
+	}
+	if _, err := ws.ResolvePaymail(ctx, "alice@example.com", 1000, "example.com"); err != nil || requests.Load() != 1 {
+		t.Fatalf("second lookup: err %v after %d requests, want it served from the cache", err, requests.Load())
+	}
+
+	if _, err := ws.ResolvePaymail(ctx, "bob@example.com", 1000, "example.com"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("unknown handle: err = %v, want the service's 404", err)
+	}
+	for _, handle := range []string{"", "alice", "@example.com", "alice@", "alice@localhost", "al ice@example.com", "alice@exa_mple.com", "alice@-example.com"} {
+		if _, err := ws.ResolvePaymail(ctx, handle, 1000, "example.com"); !errors.Is(err, ErrInvalidPaymail) {
+			t.Errorf("ResolvePaymail(%q): err = %v, want ErrInvalidPaymail", handle, err)
+		}
+	}
+}
+
+func TestResolvePaymailRejectsBadOutput(t *testing.T) {
+	srv, _ := fakePaymailServer(t, "not hex")
+	ws := NewWalletService()
+	ws.paymail.wellKnownURL = func(context.Context, string) string { return srv.URL + "/.well-known/bsvalias" }
+
+	if _, err := ws.ResolvePaymail(context.Background(), "alice@example.com", 1000, "example.com"); err == nil || !strings.Contains(err.Error(), "invalid output") {
+		t.Fatalf("err = %v, want an invalid output error", err)
+	}
+}
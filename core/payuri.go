@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction/template/p2pkh"
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/defs"
+)
+
+// satoshisPerBSV converts the BSV amounts in payment URIs to satoshis.
+const satoshisPerBSV = 100_000_000
+
+// PayURIArgs are the args of the payURI call.
+type PayURIArgs struct {
+	URI string `json:"uri"`
+}
+
+// PaymentRequest is a parsed BIP21-style payment URI such as
+// bitcoin:1BoatSLRHtKNngkdXEeobR76b53LETtpyT?amount=0.001&label=Coffee.
+type PaymentRequest struct {
+	Address  *script.Address
+	Satoshis uint64
+	Label    string
+	Message  string
+}
+
+// parsePaymentURI parses a payment URI with the bitcoin: or bsv: scheme
+// and checks that its address is for chain. An amount is required, as
+// the wallet pays the request as it is.
+func parsePaymentURI(uri string, chain defs.BSVNetwork) (*PaymentRequest, error) {
+	u, err := url.Parse(strings.TrimSpace(uri))
+	if err != nil {
+		return nil, fmt.Errorf("invalid payment URI: %w", err)
+	}
+	if scheme := strings.ToLower(u.Scheme); scheme != "bitcoin" && scheme != "bsv" {
+		return nil, fmt.Errorf("invalid payment URI: scheme %q is not bitcoin: or bsv:", u.Scheme)
+	}
+	if u.Opaque == "" {
+		return nil, fmt.Errorf("invalid payment URI: no address")
+	}
+	address, err := script.NewAddressFromString(u.Opaque)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payment URI address: %w", err)
+	}
+	mainnet := chain == defs.NetworkMainnet
+	if expected, err := script.NewAddressFromPublicKeyHash(address.PublicKeyHash, mainnet); err != nil || expected.AddressString != address.AddressString {
+		return nil, fmt.Errorf("payment URI address %s is for the wrong network; the wallet is on %s", address.AddressString, chainName(chain))
+	}
+
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payment URI query: %w", err)
+	}
+	req := &PaymentRequest{Address: address, Label: query.Get("label"), Message: query.Get("message")}
+	for param := range query {
+		// BIP21: unknown required parameters must fail the request.
+		if strings.HasPrefix(param, "req-") {
+			return nil, fmt.Errorf("payment URI requires unsupported parameter %q", param)
+		}
+	}
+	amount := query.Get("amount")
+	if amount == "" {
+		return nil, fmt.Errorf("payment URI has no amount")
+	}
+	if req.Satoshis, err = parseBSVAmount(amount); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// parseBSVAmount converts a decimal BSV amount with at most eight
+// fractional digits to satoshis.
+func parseBSVAmount(amount string) (uint64, error) {
+	whole, frac, _ := strings.Cut(amount, ".")
+	if (whole == "" && frac == "") || len(frac) > 8 || strings.Trim(whole+frac, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid payment URI amount %q", amount)
+	}
+	frac += strings.Repeat("0", 8-len(frac))
+	var sats uint64
+	for _, part := range []struct {
+		digits string
+		scale  uint64
+	}{{whole, satoshisPerBSV}, {frac, 1}} {
+		if part.digits == "" {
+			continue
+		}
+		n, err := strconv.ParseUint(part.digits, 10, 64)
+		if err != nil || n > (1<<63)/part.scale {
+			return 0, fmt.Errorf("invalid payment URI amount %q", amount)
+		}
+		sats += n * part.scale
+	}
+	if sats == 0 {
+		return 0, fmt.Errorf("payment URI amount must be more than zero")
+	}
+	return sats, nil
+}
+
+// createActionArgs returns the createAction args paying the request with
+// a single P2PKH output.
+func (p *PaymentRequest) createActionArgs() (sdk.CreateActionArgs, error) {
+	lockingScript, err := p2pkh.Lock(p.Address)
+	if err != nil {
+		return sdk.CreateActionArgs{}, fmt.Errorf("failed to build locking script: %w", err)
+	}
+	description := "Payment to " + p.Address.AddressString
+	if p.Label != "" {
+		description = "Payment to " + p.Label
+	}
+	return sdk.CreateActionArgs{
+		Description: description,
+		Outputs: []sdk.CreateActionOutput{{
+			LockingScript:     lockingScript.Bytes(),
+			Satoshis:          p.Satoshis,
+			OutputDescription: "Payment to " + p.Address.AddressString,
+		}},
+	}, nil
+}
+
+// PayURI pays the BIP21-style payment URI uri, which must be for the
+// wallet's network, and returns the created action.
+func (ws *WalletService) PayURI(ctx context.Context, uri string, originator string) (*sdk.CreateActionResult, error) {
+	ws.mu.RLock()
+	w, chain := ws.wallet, ws.chain
+	ws.mu.RUnlock()
+
+	if w == nil {
+		return nil, errWalletNotInitialized
+	}
+	req, err := parsePaymentURI(uri, chain)
+	if err != nil {
+		return nil, err
+	}
+	args, err := req.createActionArgs()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := ws.withOperationTimeout(ctx)
+	defer cancel()
+	result, err := w.CreateAction(ctx, args, originator)
+	if err != nil {
+		return nil, err
+	}
+	ws.actionCreated(result, int64(req.Satoshis))
+	return result, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction/template/p2pkh"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/defs"
+)
+
+const mainnetAddress = "1BoatSLRHtKNngkdXEeobR76b53LETtpyT"
+
+func TestParsePaymentURI(t *testing.T) {
+	req, err := parsePaymentURI("bitcoin:"+mainnetAddress+"?amount=0.001&label=Coffee%20shop&message=Order%2042", defs.NetworkMainnet)
+	if err != nil {
+		t.Fatalf("parsePaymentURI: %v", err)
+	}
+	if req.Address.AddressString != mainnetAddress || req.Satoshis != 100_000 || req.Label != "Coffee shop" || req.Message != "Order 42" {
+		t.Fatalf("parsed %+v", req)
+	}
+	args, err := req.createActionArgs()
+	if err != nil {
+		t.Fatalf("createActionArgs: %v", err)
+	}
+	want, _ := p2pkh.Lock(req.Address)
+	if len(args.Outputs) != 1 || !bytes.Equal(args.Outputs[0].LockingScript, want.Bytes()) || args.Outputs[0].Satoshis != 100_000 {
+		t.Fatalf("outputs = %+v, want one P2PKH output of 100000 sats", args.Outputs)
+	}
+	if args.Description != "Payment to Coffee shop" {
+		t.Fatalf("description = %q", args.Description)
+	}
+
+	if req, err := parsePaymentURI("BSV:"+mainnetAddress+"?amount=12", defs.NetworkMainnet); err != nil || req.Satoshis != 1_200_000_000 {
+		t.Fatalf("bsv: URI parsed to %+v, %v", req, err)
+	}
+}
+
+func TestParsePaymentURIWrongNetwork(t *testing.T) {
+	_, err := parsePaymentURI("bitcoin:"+mainnetAddress+"?amount=1", defs.NetworkTestnet)
+	if err == nil || !strings.Contains(err.Error(), "wrong network") {
+		t.Fatalf("mainnet address on testnet: err = %v, want a wrong network error", err)
+	}
+}
+
+func TestParsePaymentURIMalformed(t *testing.T) {
+	for _, uri := range []string{
+		"",
+		"bitcoin:",
+		"https://example.com/?amount=1",
+		mainnetAddress + "?amount=1",
+		"bitcoin:notanaddress?amount=1",
+		"bitcoin:" + mainnetAddress,
+		"bitcoin:" + mainnetAddress + "?amount=",
+		"bitcoin:" + mainnetAddress + "?amount=0",
+		"bitcoin:" + mainnetAddress + "?amount=-1",
+		"bitcoin:" + mainnetAddress + "?amount=1e3",
+		"bitcoin:" + mainnetAddress + "?amount=0.000000001",
+		"bitcoin:" + mainnetAddress + "?amount=99999999999999999999",
+		"bitcoin:" + mainnetAddress + "?amount=1&req-somethingyoudontunderstand=50",
+		"bitcoin:" + mainnetAddress + "?amount=1&%zz",
+	} {
+		if req, err := parsePaymentURI(uri, defs.NetworkMainnet); err == nil {
+			t.Errorf("parsePaymentURI(%q) = %+v, want an error", uri, req)
+		}
+	}
+}
+
+func TestPayURIChecksNetworkBeforePrompting(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	gate := &countingGate{}
+	ws := NewWalletService()
+	ws.SetPermissionGate(gate)
+	if err := ws.InitializeWallet(newKey(t).Hex(), "test"); err != nil {
+		t.Fatalf("InitializeWallet: %v", err)
+	}
+	defer ws.ShutdownWallet()
+
+	if _, err := ws.PayURI(context.Background(), "bitcoin:"+mainnetAddress+"?amount=1", "example.com"); err == nil || !strings.Contains(err.Error(), "wrong network") {
+		t.Fatalf("PayURI mainnet address: err = %v, want a wrong network error", err)
+	}
+	if _, err := ws.CallWalletMethod("payURI", `{"uri":"bitcoin:`+mainnetAddress+`?amount=1"}`, "example.com"); err == nil || !strings.Contains(err.Error(), "wrong network") {
+		t.Fatalf("payURI mainnet address: err = %v, want a wrong network error", err)
+	}
+	if gate.calls != 0 {
+		t.Fatal("wrong-network payment reached the permission prompt")
+	}
+
+	// A testnet request is prompted for; the empty wallet then cannot fund it.
+	testnet, err := script.NewAddressFromPublicKey(newKey(t).PubKey(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws.CallWalletMethod("payURI", `{"uri":"bitcoin:`+testnet.AddressString+`?amount=0.0001"}`, "example.com")
+	if gate.calls != 1 {
+		t.Fatalf("testnet payment prompted %d times, want 1", gate.calls)
+	}
+}
@@ -2,9 +2,16 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -26,23 +33,142 @@ type PermissionGate interface {
 	RequestPermission(req PermissionRequest) (bool, error)
 }
 
+// Strategies for choosing among several bridges.
+const (
+	// BridgeFailover always tries the bridges in the order given, moving on
+	// only when one is unavailable.
+	BridgeFailover = "failover"
+	// BridgeRoundRobin rotates the starting bridge on every request.
+	BridgeRoundRobin = "round-robin"
+)
+
+// bridgeRequestTimeout bounds a permission request to one bridge. It is
+// longer than the bridge's own 180s wait for an approver, so a slow
+// approval ends in the bridge's timeout response rather than the client's.
+const bridgeRequestTimeout = 190 * time.Second
+
+// bridgeRetryAfter is how long a bridge that failed is tried only after the
+// healthy ones.
+const bridgeRetryAfter = 30 * time.Second
+
 // BridgePermissionGate proxies permission prompts to the Gebunden Bridge service.
 // The bridge handles the actual user interaction (Telegram, WhatsApp, etc.).
 type BridgePermissionGate struct {
-	bridgeURL   string
+	bridgeURLs  []string
+	strategy    string
 	autoApprove bool
 	client      *http.Client
+	next        atomic.Uint64 // round-robin position
+
+	downMu sync.Mutex
+	down   map[string]time.Time // bridge URL -> when it last failed
+
+	grants GrantStore
 }
 
-// NewBridgePermissionGate creates a new permission gate that talks to the bridge.
-// bridgeURL is the base URL of the bridge service (e.g. http://localhost:18789).
-func NewBridgePermissionGate(bridgeURL string, autoApprove bool) *BridgePermissionGate {
+// NewBridgePermissionGate creates a new permission gate that talks to one or
+// more bridges. bridgeURLs are base URLs of bridge services (e.g.
+// http://localhost:18790); strategy is BridgeFailover or BridgeRoundRobin.
+// A request moves on to the next bridge only if the current one is
+// unreachable or unavailable; any decision it returns is final.
+func NewBridgePermissionGate(bridgeURLs []string, strategy string, autoApprove bool) *BridgePermissionGate {
 	return &BridgePermissionGate{
-		bridgeURL:   bridgeURL,
+		bridgeURLs:  bridgeURLs,
+		strategy:    strategy,
 		autoApprove: autoApprove,
 		client: &http.Client{
-			Timeout: 130 * time.Second, // slightly longer than bridge's 120s timeout
+			Timeout: bridgeRequestTimeout,
 		},
+		down:   make(map[string]time.Time),
+		grants: NewMemoryGrantStore(),
+	}
+}
+
+// SetGrantStore replaces the in-memory grant store, e.g. with a
+// FileGrantStore shared with the bridge.
+func (g *BridgePermissionGate) SetGrantStore(store GrantStore) {
+	g.grants = store
+}
+
+// candidates returns the bridge URLs in the order to try them: healthy
+// bridges first, starting from the strategy's choice, then recently failed
+// ones as a last resort.
+func (g *BridgePermissionGate) candidates() []string {
+	n := len(g.bridgeURLs)
+	start := 0
+	if g.strategy == BridgeRoundRobin && n > 0 {
+		start = int((g.next.Add(1) - 1) % uint64(n))
+	}
+	g.downMu.Lock()
+	defer g.downMu.Unlock()
+	var healthy, failed []string
+	for i := 0; i < n; i++ {
+		url := g.bridgeURLs[(start+i)%n]
+		if since, ok := g.down[url]; ok && time.Since(since) < bridgeRetryAfter {
+			failed = append(failed, url)
+		} else {
+			healthy = append(healthy, url)
+		}
+	}
+	return append(healthy, failed...)
+}
+
+// markHealth records whether a bridge answered.
+func (g *BridgePermissionGate) markHealth(url string, ok bool) {
+	g.downMu.Lock()
+	defer g.downMu.Unlock()
+	if ok {
+		delete(g.down, url)
+	} else {
+		g.down[url] = time.Now()
+	}
+}
+
+// grantKey identifies what a protocol or basket grant covers. Other request
+// types are never granted beyond a single request and return "".
+func grantKey(req PermissionRequest) string {
+	switch req.Type {
+	case "protocol":
+		return fmt.Sprintf("%s|protocol|%v", req.App, req.ExtraData["protocolID"])
+	case "basket":
+		return fmt.Sprintf("%s|basket|%v", req.App, req.ExtraData["basket"])
+	}
+	return ""
+}
+
+// hasGrant reports whether an unexpired grant covers req.
+func (g *BridgePermissionGate) hasGrant(req PermissionRequest) bool {
+	key := grantKey(req)
+	if key == "" {
+		return false
+	}
+	_, ok, err := g.grants.Get(key)
+	if err != nil {
+		slog.Warn("Failed to read permission grant", "key", key, "error", err)
+		return false
+	}
+	return ok
+}
+
+// recordGrant stores a grant for req lasting for the duration chosen by the
+// approver ("1h", "24h" or "forever"). "once" and unknown durations are ignored.
+func (g *BridgePermissionGate) recordGrant(req PermissionRequest, duration string) {
+	key := grantKey(req)
+	if key == "" {
+		return
+	}
+	var ttl time.Duration
+	switch duration {
+	case "1h":
+		ttl = time.Hour
+	case "24h":
+		ttl = 24 * time.Hour
+	case "forever":
+	default:
+		return
+	}
+	if err := g.grants.Put(key, ttl); err != nil {
+		slog.Warn("Failed to store permission grant", "key", key, "error", err)
 	}
 }
 
@@ -55,6 +181,9 @@ func (g *BridgePermissionGate) RequestPermission(req PermissionRequest) (bool, e
 	if g.autoApprove {
 		return true, nil
 	}
+	if g.hasGrant(req) {
+		return true, nil
+	}
 
 	// Ensure timestamp
 	if req.Timestamp == 0 {
@@ -66,29 +195,75 @@ func (g *BridgePermissionGate) RequestPermission(req PermissionRequest) (bool, e
 		return false, fmt.Errorf("failed to marshal permission request: %w", err)
 	}
 
-	url := g.bridgeURL + "/request-permission"
-	resp, err := g.client.Post(url, "application/json", bytes.NewBuffer(body))
+	if len(g.bridgeURLs) == 0 {
+		return false, fmt.Errorf("no bridge configured")
+	}
+	var lastErr error
+	for _, url := range g.candidates() {
+		approved, duration, err := g.askBridge(url, body)
+		if errors.Is(err, errBridgeUnavailable) {
+			g.markHealth(url, false)
+			lastErr = err
+			continue
+		}
+		g.markHealth(url, true)
+		if err != nil {
+			return false, err
+		}
+		if approved {
+			g.recordGrant(req, duration)
+		}
+		return approved, nil
+	}
+	// Every bridge unreachable — deny by default for safety
+	return false, lastErr
+}
+
+// errBridgeUnavailable marks failures that another bridge may not share.
+var errBridgeUnavailable = errors.New("bridge unavailable")
+
+// askBridge posts a permission request to one bridge and returns its decision.
+// A bridge that times out after taking the request may already have prompted
+// the approver, so the timeout denies rather than trying the next bridge.
+func (g *BridgePermissionGate) askBridge(baseURL string, body []byte) (approved bool, duration string, err error) {
+	var connected atomic.Bool
+	trace := &httptrace.ClientTrace{
+		GotConn: func(httptrace.GotConnInfo) { connected.Store(true) },
+	}
+	httpReq, err := http.NewRequestWithContext(httptrace.WithClientTrace(context.Background(), trace),
+		http.MethodPost, baseURL+"/request-permission", bytes.NewReader(body))
+	if err != nil {
+		return false, "", fmt.Errorf("failed to build permission request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := g.client.Do(httpReq)
 	if err != nil {
-		// Bridge unreachable — deny by default for safety
-		return false, fmt.Errorf("bridge unreachable: %w", err)
+		var netErr net.Error
+		if connected.Load() && errors.As(err, &netErr) && netErr.Timeout() {
+			return false, "", fmt.Errorf("permission request to %s timed out waiting for a decision: %v", baseURL, err)
+		}
+		return false, "", fmt.Errorf("%w: %s unreachable: %v", errBridgeUnavailable, baseURL, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusGatewayTimeout {
-		return false, fmt.Errorf("permission request timed out (user did not respond)")
+		return false, "", fmt.Errorf("permission request timed out (user did not respond)")
+	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return false, "", fmt.Errorf("%w: %s returned status %d", errBridgeUnavailable, baseURL, resp.StatusCode)
 	}
 	if resp.StatusCode != http.StatusOK {
-		return false, fmt.Errorf("bridge returned status %d", resp.StatusCode)
+		return false, "", fmt.Errorf("bridge returned status %d", resp.StatusCode)
 	}
 
 	var result struct {
 		ID       string `json:"id"`
 		Approved bool   `json:"approved"`
 		Reason   string `json:"reason"`
+		Duration string `json:"duration"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return false, fmt.Errorf("failed to decode bridge response: %w", err)
+		return false, "", fmt.Errorf("failed to decode bridge response: %w", err)
 	}
-
-	return result.Approved, nil
+	return result.Approved, result.Duration, nil
 }
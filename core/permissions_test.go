@@ -0,0 +1,94 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stubBridge returns a bridge that approves every request and counts them.
+func stubBridge(t *testing.T, hits *atomic.Int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"req","approved":true}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGateFailsOverToSecondaryBridge(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	var hits atomic.Int32
+	secondary := stubBridge(t, &hits)
+
+	gate := NewBridgePermissionGate([]string{down.URL, secondary.URL}, BridgeFailover, false)
+	approved, err := gate.RequestPermission(PermissionRequest{ID: "req", Type: "spend", App: "example.com"})
+	if err != nil {
+		t.Fatalf("RequestPermission: %v", err)
+	}
+	if !approved {
+		t.Fatal("expected the secondary's approval")
+	}
+	if hits.Load() != 1 {
+		t.Fatalf("secondary hits = %d, want 1", hits.Load())
+	}
+	if got := gate.candidates(); got[0] != secondary.URL {
+		t.Fatalf("failed primary should be tried last, got order %v", got)
+	}
+}
+
+func TestGateRoundRobin(t *testing.T) {
+	var hitsA, hitsB atomic.Int32
+	a := stubBridge(t, &hitsA)
+	b := stubBridge(t, &hitsB)
+
+	gate := NewBridgePermissionGate([]string{a.URL, b.URL}, BridgeRoundRobin, false)
+	for i := 0; i < 4; i++ {
+		if _, err := gate.RequestPermission(PermissionRequest{ID: "req", Type: "spend"}); err != nil {
+			t.Fatalf("RequestPermission: %v", err)
+		}
+	}
+	if hitsA.Load() != 2 || hitsB.Load() != 2 {
+		t.Fatalf("hits = %d/%d, want 2/2", hitsA.Load(), hitsB.Load())
+	}
+}
+
+func TestGateDeniesWhenAllBridgesDown(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	gate := NewBridgePermissionGate([]string{down.URL}, BridgeFailover, false)
+	approved, err := gate.RequestPermission(PermissionRequest{ID: "req", Type: "spend"})
+	if approved || err == nil {
+		t.Fatalf("got approved=%v err=%v, want denial with error", approved, err)
+	}
+}
+
+func TestGateDeniesWhenBridgeTimesOutWaiting(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		<-release // an approver who has not answered yet
+	}))
+	defer slow.Close()
+	defer close(release)
+	var hits atomic.Int32
+	secondary := stubBridge(t, &hits)
+
+	gate := NewBridgePermissionGate([]string{slow.URL, secondary.URL}, BridgeFailover, false)
+	gate.client.Timeout = 50 * time.Millisecond
+	approved, err := gate.RequestPermission(PermissionRequest{ID: "req", Type: "spend"})
+	if approved || err == nil {
+		t.Fatalf("got approved=%v err=%v, want denial with error", approved, err)
+	}
+	if hits.Load() != 0 {
+		t.Fatalf("secondary was prompted %d times after the primary took the request", hits.Load())
+	}
+	if got := gate.candidates(); got[0] != slow.URL {
+		t.Fatalf("a bridge waiting on its approver was marked down, order %v", got)
+	}
+}
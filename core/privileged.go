@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+)
+
+// PrivilegedKeyManager performs key operations with the privileged keys,
+// kept apart from the everyday keys (for example in a hardware module).
+// Calls that set privileged are routed to it.
+type PrivilegedKeyManager interface {
+	sdk.KeyOperations
+}
+
+// WithPrivilegedKeyManager routes privileged getPublicKey, encrypt, decrypt,
+// HMAC and signature calls to m. Without one, privileged calls fail with
+// ErrPrivilegedNotSupported.
+func WithPrivilegedKeyManager(m PrivilegedKeyManager) WalletServiceOption {
+	return func(ws *WalletService) {
+		ws.privilegedKeys = m
+	}
+}
+
+// ErrPrivilegedNotSupported is returned for privileged calls the wallet
+// cannot route to a privileged key manager: running them with the everyday
+// keys would silently downgrade the caller's request.
+var ErrPrivilegedNotSupported = errors.New("privileged operations are not supported by this wallet")
+
+// ErrPrivilegedReasonRequired is returned for privileged calls that do not
+// say why they need the privileged keys.
+var ErrPrivilegedReasonRequired = errors.New("privileged operations require a privilegedReason")
+
+// privilegedMethods are the calls a PrivilegedKeyManager can serve.
+var privilegedMethods = map[string]bool{
+	"getPublicKey":         true,
+	"encrypt":              true,
+	"decrypt":              true,
+	"createHmac":           true,
+	"verifyHmac":           true,
+	"createSignature":      true,
+	"verifySignature":      true,
+	"createSignedEnvelope": true,
+}
+
+// checkPrivileged reports whether argsJSON asks for a privileged operation,
+// and returns an error if method cannot be run that way. Every BRC-100
+// args type names the flags the same way.
+func (ws *WalletService) checkPrivileged(method, argsJSON string) (bool, error) {
+	var args struct {
+		Privileged       *bool  `json:"privileged"`
+		PrivilegedReason string `json:"privilegedReason"`
+	}
+	if json.Unmarshal([]byte(argsJSON), &args) != nil || args.Privileged == nil || !*args.Privileged {
+		return false, nil
+	}
+	if ws.privilegedKeys == nil || !privilegedMethods[method] {
+		return true, ErrPrivilegedNotSupported
+	}
+	if args.PrivilegedReason == "" {
+		return true, ErrPrivilegedReasonRequired
+	}
+	return true, nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+)
+
+func TestPrivilegedCallsAreRefused(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ws := NewWalletService()
+	if err := ws.InitializeWallet(newKey(t).Hex(), "test"); err != nil {
+		t.Fatalf("InitializeWallet: %v", err)
+	}
+	defer ws.ShutdownWallet()
+
+	const encryptArgs = `"protocolID":[1,"privileged tests"],"keyID":"1","counterparty":"self","plaintext":[1,2,3]`
+	calls := []struct{ method, args string }{
+		{"getPublicKey", `{"identityKey":true,"privileged":true,"privilegedReason":"test"}`},
+		{"encrypt", `{` + encryptArgs + `,"privileged":true,"privilegedReason":"test"}`},
+	}
+	for _, c := range calls {
+		if _, err := ws.CallWalletMethod(c.method, c.args, "example.com"); !errors.Is(err, ErrPrivilegedNotSupported) {
+			t.Errorf("privileged %s: err = %v, want ErrPrivilegedNotSupported", c.method, err)
+		}
+	}
+
+	// The same calls without the flag still work.
+	if _, err := ws.CallWalletMethod("getPublicKey", `{"identityKey":true,"privileged":false}`, "example.com"); err != nil {
+		t.Errorf("getPublicKey: %v", err)
+	}
+	if _, err := ws.CallWalletMethod("encrypt", `{`+encryptArgs+`}`, "example.com"); err != nil {
+		t.Errorf("encrypt: %v", err)
+	}
+}
+
+// recordingKeyManager is a software PrivilegedKeyManager that notes the
+// reasons it was called with.
+type recordingKeyManager struct {
+	*sdk.ProtoWallet
+	reasons []string
+}
+
+func (m *recordingKeyManager) GetPublicKey(ctx context.Context, args sdk.GetPublicKeyArgs, originator string) (*sdk.GetPublicKeyResult, error) {
+	m.reasons = append(m.reasons, args.PrivilegedReason)
+	return m.ProtoWallet.GetPublicKey(ctx, args, originator)
+}
+
+func (m *recordingKeyManager) Encrypt(ctx context.Context, args sdk.EncryptArgs, originator string) (*sdk.EncryptResult, error) {
+	m.reasons = append(m.reasons, args.PrivilegedReason)
+	return m.ProtoWallet.Encrypt(ctx, args, originator)
+}
+
+func TestPrivilegedCallsUsePrivilegedKeyManager(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	privilegedKey := newKey(t)
+	proto, err := sdk.NewProtoWallet(sdk.ProtoWalletArgs{Type: sdk.ProtoWalletArgsTypePrivateKey, PrivateKey: privilegedKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := &recordingKeyManager{ProtoWallet: proto}
+
+	ws := NewWalletService(WithPrivilegedKeyManager(keys))
+	if err := ws.InitializeWallet(newKey(t).Hex(), "test"); err != nil {
+		t.Fatalf("InitializeWallet: %v", err)
+	}
+	defer ws.ShutdownWallet()
+
+	identity := func(args string) string {
+		t.Helper()
+		out, err := ws.CallWalletMethod("getPublicKey", args, "example.com")
+		if err != nil {
+			t.Fatalf("getPublicKey %s: %v", args, err)
+		}
+		var result struct {
+			PublicKey string `json:"publicKey"`
+		}
+		json.Unmarshal([]byte(out), &result)
+		return result.PublicKey
+	}
+	everyday := identity(`{"identityKey":true}`)
+	privileged := identity(`{"identityKey":true,"privileged":true,"privilegedReason":"hardware check"}`)
+	if want := privilegedKey.PubKey().ToDERHex(); privileged != want {
+		t.Fatalf("privileged identity key = %s, want the privileged key %s", privileged, want)
+	}
+	if everyday == privileged {
+		t.Fatal("unprivileged call used the privileged key")
+	}
+
+	// Data encrypted with the privileged keys only decrypts with them.
+	const encryptArgs = `"protocolID":[1,"privileged tests"],"keyID":"1","counterparty":"self"`
+	out, err := ws.CallWalletMethod("encrypt", `{`+encryptArgs+`,"plaintext":[1,2,3],"privileged":true,"privilegedReason":"seal"}`, "example.com")
+	if err != nil {
+		t.Fatalf("privileged encrypt: %v", err)
+	}
+	var sealed struct {
+		Ciphertext json.RawMessage `json:"ciphertext"`
+	}
+	json.Unmarshal([]byte(out), &sealed)
+	decryptArgs := `{` + encryptArgs + `,"ciphertext":` + string(sealed.Ciphertext)
+	if _, err := ws.CallWalletMethod("decrypt", decryptArgs+`,"privileged":true,"privilegedReason":"unseal"}`, "example.com"); err != nil {
+		t.Fatalf("privileged decrypt: %v", err)
+	}
+	if _, err := ws.CallWalletMethod("decrypt", decryptArgs+`}`, "example.com"); err == nil {
+		t.Fatal("everyday keys decrypted data sealed with the privileged keys")
+	}
+
+	if got, want := len(keys.reasons), 2; got != want || keys.reasons[0] != "hardware check" || keys.reasons[1] != "seal" {
+		t.Fatalf("manager saw reasons %q, want [hardware check seal]", keys.reasons)
+	}
+
+	if _, err := ws.CallWalletMethod("getPublicKey", `{"identityKey":true,"privileged":true}`, "example.com"); !errors.Is(err, ErrPrivilegedReasonRequired) {
+		t.Errorf("privileged call without a reason: err = %v, want ErrPrivilegedReasonRequired", err)
+	}
+	if _, err := ws.CallWalletMethod("listCertificates", `{"certifiers":[],"types":[],"privileged":true,"privilegedReason":"list"}`, "example.com"); !errors.Is(err, ErrPrivilegedNotSupported) {
+		t.Errorf("privileged listCertificates: err = %v, want ErrPrivilegedNotSupported", err)
+	}
+	if caps, _ := ws.Capabilities(context.Background(), "example.com"); !caps.Features.PrivilegedKeyManager {
+		t.Error("capabilities do not advertise the privileged key manager")
+	}
+}
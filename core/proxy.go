@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bsv-blockchain/go-sdk/overlay/lookup"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/defs"
+)
+
+// WalletServiceOption configures a WalletService at construction.
+type WalletServiceOption func(*WalletService)
+
+// WithHTTPProxy routes the wallet's outbound HTTP — certifier calls made by
+// the auth client and overlay lookups — through proxy. HTTPS requests are
+// tunnelled with CONNECT. Hosts listed in NO_PROXY are reached directly.
+func WithHTTPProxy(proxy *url.URL) WalletServiceOption {
+	return func(ws *WalletService) {
+		ws.httpProxy = proxy
+	}
+}
+
+// parseProxyURL validates a -http-proxy value. A bare host:port is taken to
+// be an http:// proxy.
+func parseProxyURL(s string) (*url.URL, error) {
+	if !strings.Contains(s, "://") {
+		s = "http://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid proxy URL %q: scheme must be http or https", s)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: missing host", s)
+	}
+	return u, nil
+}
+
+// egressClient returns the HTTP client for the wallet's outbound calls, or
+// nil to keep the toolbox defaults when no proxy is configured.
+func (ws *WalletService) egressClient() *http.Client {
+	if ws.httpProxy == nil {
+		return nil
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxyFunc(ws.httpProxy, noProxyFromEnv())
+	transport.TLSHandshakeTimeout = 5 * time.Second
+	return &http.Client{
+		Transport: transport,
+		Timeout:   15 * time.Second,
+	}
+}
+
+// lookupResolver returns an overlay lookup resolver for chain whose
+// queries use client, or the default client when it is nil.
+func lookupResolver(client *http.Client, chain defs.BSVNetwork) *lookup.LookupResolver {
+	resolver := &lookup.LookupResolver{NetworkPreset: overlayNetwork(chain)}
+	if client != nil {
+		resolver.Facilitator = &lookup.HTTPSOverlayLookupFacilitator{Client: client}
+	}
+	return lookup.NewLookupResolver(resolver)
+}
+
+func noProxyFromEnv() string {
+	if v := os.Getenv("NO_PROXY"); v != "" {
+		return v
+	}
+	return os.Getenv("no_proxy")
+}
+
+// proxyFunc sends every request through proxy unless its host matches
+// noProxy.
+func proxyFunc(proxy *url.URL, noProxy string) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		if bypassProxy(req.URL, noProxy) {
+			return nil, nil
+		}
+		return proxy, nil
+	}
+}
+
+// bypassProxy reports whether u matches a NO_PROXY list: a comma-separated
+// set of "*", IP addresses, CIDR ranges and domain names, each optionally
+// with a port. A domain also matches its subdomains.
+func bypassProxy(u *url.URL, noProxy string) bool {
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if port == "" {
+		if u.Scheme == "https" {
+			port = "443"
+		} else {
+			port = "80"
+		}
+	}
+	ip := net.ParseIP(host)
+
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return true
+		}
+		if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && cidr.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		entryHost, entryPort := entry, ""
+		if h, p, err := net.SplitHostPort(entry); err == nil {
+			entryHost, entryPort = h, p
+		}
+		if entryPort != "" && entryPort != port {
+			continue
+		}
+		if entryIP := net.ParseIP(entryHost); entryIP != nil {
+			if ip != nil && entryIP.Equal(ip) {
+				return true
+			}
+			continue
+		}
+		entryHost = strings.TrimPrefix(entryHost, "*")
+		entryHost = strings.TrimPrefix(entryHost, ".")
+		if host == entryHost || strings.HasSuffix(host, "."+entryHost) {
+			return true
+		}
+	}
+	return false
+}
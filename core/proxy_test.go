@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	clients "github.com/bsv-blockchain/go-sdk/auth/clients/authhttp"
+	sdkwallet "github.com/bsv-blockchain/go-sdk/wallet"
+)
+
+// fakeProxy records the method and target of every request it receives and
+// refuses them all.
+type fakeProxy struct {
+	mu      sync.Mutex
+	targets []string
+}
+
+func (p *fakeProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	if r.Method == http.MethodConnect {
+		p.targets = append(p.targets, "CONNECT "+r.Host)
+	} else {
+		p.targets = append(p.targets, r.Method+" "+r.URL.String())
+	}
+	p.mu.Unlock()
+	http.Error(w, "blocked by test proxy", http.StatusForbidden)
+}
+
+func (p *fakeProxy) seen() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.targets...)
+}
+
+func startFakeProxy(t *testing.T) (*fakeProxy, *url.URL) {
+	t.Helper()
+	p := &fakeProxy{}
+	srv := httptest.NewServer(p)
+	t.Cleanup(srv.Close)
+	u, _ := url.Parse(srv.URL)
+	return p, u
+}
+
+func TestCertifierCallsGoThroughProxy(t *testing.T) {
+	t.Setenv("NO_PROXY", "")
+	proxy, proxyURL := startFakeProxy(t)
+
+	ws := NewWalletService(WithHTTPProxy(proxyURL))
+	client := ws.egressClient()
+	if client == nil {
+		t.Fatal("expected an egress client when a proxy is set")
+	}
+
+	// The wallet builds its certifier client from this transport.
+	auth := clients.New(sdkwallet.NewTestWalletForRandomKey(t), clients.WithHttpClientTransport(client.Transport))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	auth.Fetch(ctx, "http://certifier.example/signCertificate", &clients.SimplifiedFetchRequestOptions{Method: http.MethodPost})
+
+	seen := proxy.seen()
+	if len(seen) == 0 {
+		t.Fatal("certifier call did not reach the proxy")
+	}
+	if u, err := url.Parse(seen[0][len("POST "):]); err != nil || u.Host != "certifier.example" {
+		t.Fatalf("proxy saw %q, want a request for certifier.example", seen[0])
+	}
+
+	// TLS targets are tunnelled with CONNECT.
+	client.Get("https://certifier.example/signCertificate")
+	seen = proxy.seen()
+	if last := seen[len(seen)-1]; last != "CONNECT certifier.example:443" {
+		t.Fatalf("proxy saw %q for an https call, want CONNECT", last)
+	}
+}
+
+func TestNoProxyHostsBypassProxy(t *testing.T) {
+	proxy, proxyURL := startFakeProxy(t)
+	direct := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer direct.Close()
+	t.Setenv("NO_PROXY", "internal.example,127.0.0.1")
+
+	client := NewWalletService(WithHTTPProxy(proxyURL)).egressClient()
+	resp, err := client.Get(direct.URL)
+	if err != nil {
+		t.Fatalf("direct call: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || len(proxy.seen()) != 0 {
+		t.Fatalf("status %d, proxy saw %v; want a direct call", resp.StatusCode, proxy.seen())
+	}
+
+	for host, want := range map[string]bool{
+		"http://internal.example/":     true,
+		"http://api.internal.example/": true,
+		"http://notinternal.example/":  false,
+		"https://certifier.example/":   false,
+		"http://127.0.0.1:8080/":       true,
+		"http://10.0.0.1/":             false,
+	} {
+		u, _ := url.Parse(host)
+		if got := bypassProxy(u, "internal.example,127.0.0.1"); got != want {
+			t.Errorf("bypassProxy(%s) = %v, want %v", host, got, want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/entity"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/storage"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/wallet"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/wdk"
+)
+
+// receiptProtocol is what receipts are signed under; the key ID is the
+// txid, so a receipt's signature is good for no other transaction.
+var receiptProtocol = sdk.Protocol{SecurityLevel: sdk.SecurityLevelEveryApp, Protocol: "payment receipt"}
+
+// Receipt is a wallet's signed statement that it took in a transaction,
+// for the sender to show that a payment arrived.
+type Receipt struct {
+	Txid string `json:"txid"`
+	// Satoshis is what the transaction added to the wallet.
+	Satoshis int64 `json:"satoshis"`
+	// AcceptedAt is when the wallet took the transaction in.
+	AcceptedAt time.Time `json:"acceptedAt"`
+	// Envelope signs the fields above, for anyone to check.
+	Envelope SignedEnvelope `json:"envelope"`
+}
+
+// CreateReceiptArgs are the args of the createReceipt call.
+type CreateReceiptArgs struct {
+	Txid string `json:"txid"`
+}
+
+// receiptData is what a receipt's envelope signs.
+func receiptData(r *Receipt) ([]byte, error) {
+	return json.Marshal(struct {
+		Txid       string    `json:"txid"`
+		Satoshis   int64     `json:"satoshis"`
+		AcceptedAt time.Time `json:"acceptedAt"`
+	}{r.Txid, r.Satoshis, r.AcceptedAt.UTC()})
+}
+
+// CreateReceipt signs a receipt for txid, an action the wallet took in
+// with internalizeAction. Actions the wallet sent, and ones it has not
+// accepted, get no receipt.
+func (ws *WalletService) CreateReceipt(ctx context.Context, txid string, originator string) (*Receipt, error) {
+	ws.mu.RLock()
+	w, store := ws.wallet, ws.storage
+	ws.mu.RUnlock()
+
+	if w == nil {
+		return nil, errWalletNotInitialized
+	}
+	ctx, cancel := ws.withOperationTimeout(ctx)
+	defer cancel()
+	return receiptFor(ctx, w, store, txid, originator)
+}
+
+// receiptFor signs a receipt for the action txid in store.
+func receiptFor(ctx context.Context, w *wallet.Wallet, store *storage.Provider, txid string, originator string) (*Receipt, error) {
+	if err := validateTxid(txid); err != nil {
+		return nil, err
+	}
+	tx, err := findAction(ctx, w, store, txid, originator)
+	if err != nil {
+		return nil, err
+	}
+	return createReceipt(ctx, w, tx, originator)
+}
+
+func createReceipt(ctx context.Context, keys sdk.KeyOperations, tx *entity.Transaction, originator string) (*Receipt, error) {
+	if tx.IsOutgoing {
+		return nil, errors.New("the action was sent by this wallet, not taken in")
+	}
+	if tx.Status != wdk.TxStatusCompleted && tx.Status != wdk.TxStatusUnproven {
+		return nil, fmt.Errorf("the action has not been accepted: its status is %s", tx.Status)
+	}
+	r := &Receipt{Txid: *tx.TxID, Satoshis: tx.Satoshis, AcceptedAt: tx.CreatedAt.UTC()}
+	data, err := receiptData(r)
+	if err != nil {
+		return nil, err
+	}
+	env, err := createSignedEnvelope(ctx, keys, sdk.CreateSignatureArgs{
+		EncryptionArgs: sdk.EncryptionArgs{
+			ProtocolID:   receiptProtocol,
+			KeyID:        r.Txid,
+			Counterparty: sdk.Counterparty{Type: sdk.CounterpartyTypeAnyone},
+		},
+		Data: data,
+	}, originator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign receipt: %w", err)
+	}
+	r.Envelope = *env
+	return r, nil
+}
+
+// VerifyReceipt reports whether r was signed by the wallet it names as
+// signer, for its txid, amount and time. Any wallet can check a receipt.
+// As with VerifySignedEnvelope, a receipt that does not verify gives
+// false and no error.
+func (ws *WalletService) VerifyReceipt(ctx context.Context, r *Receipt, originator string) (bool, error) {
+	ws.mu.RLock()
+	w := ws.wallet
+	ws.mu.RUnlock()
+
+	if w == nil {
+		return false, errWalletNotInitialized
+	}
+	ctx, cancel := ws.withOperationTimeout(ctx)
+	defer cancel()
+	return verifyReceipt(ctx, w, r, originator)
+}
+
+func verifyReceipt(ctx context.Context, w sdk.KeyOperations, r *Receipt, originator string) (bool, error) {
+	if r == nil {
+		return false, errors.New("no receipt to verify")
+	}
+	// A signature made for anything else is no receipt, however valid.
+	env := r.Envelope
+	if env.ProtocolID != receiptProtocol || env.KeyID != r.Txid || env.Hashed ||
+		env.Counterparty.Type != sdk.CounterpartyTypeAnyone {
+		return false, nil
+	}
+	data, err := receiptData(r)
+	if err != nil {
+		return false, err
+	}
+	return verifySignedEnvelope(ctx, w, data, &env, originator)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/entity"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/wdk"
+)
+
+func TestReceiptVerifies(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	rootKey := newKey(t)
+	ws := NewWalletService()
+	if err := ws.InitializeWallet(rootKey.Hex(), "test"); err != nil {
+		t.Fatalf("InitializeWallet: %v", err)
+	}
+	defer ws.ShutdownWallet()
+	ctx := context.Background()
+
+	// An action as internalizeAction would have left it, and one the
+	// wallet sent.
+	user, err := ws.storage.FindOrInsertUser(ctx, rootKey.PubKey().ToDERHex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	received, sent := strings.Repeat("ab", 32), strings.Repeat("cd", 32)
+	for _, tx := range []*entity.Transaction{
+		{UserID: user.User.UserID, Status: wdk.TxStatusCompleted, Reference: "receipt-in", Satoshis: 1500, Description: "payment in", TxID: &received},
+		{UserID: user.User.UserID, Status: wdk.TxStatusCompleted, Reference: "receipt-out", IsOutgoing: true, Satoshis: -900, Description: "payment out", TxID: &sent},
+	} {
+		if err := ws.storage.TransactionEntity().Create(ctx, tx); err != nil {
+			t.Fatalf("create action: %v", err)
+		}
+	}
+
+	receipt, err := ws.CreateReceipt(ctx, received, "example.com")
+	if err != nil {
+		t.Fatalf("CreateReceipt: %v", err)
+	}
+	if receipt.Txid != received || receipt.Satoshis != 1500 || receipt.AcceptedAt.IsZero() {
+		t.Fatalf("receipt = %+v", receipt)
+	}
+	if receipt.Envelope.Signer != rootKey.PubKey().ToDERHex() {
+		t.Fatalf("receipt signed by %s, want the wallet's identity", receipt.Envelope.Signer)
+	}
+
+	// The sender checks the receipt as it arrives, in JSON.
+	raw, err := json.Marshal(receipt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Receipt
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatal(err)
+	}
+	sender, err := sdk.NewProtoWallet(sdk.ProtoWalletArgs{Type: sdk.ProtoWalletArgsTypePrivateKey, PrivateKey: newKey(t)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := verifyReceipt(ctx, sender, &got, "example.com"); err != nil || !ok {
+		t.Fatalf("receipt gave %v, %v", ok, err)
+	}
+	if ok, err := ws.VerifyReceipt(ctx, &got, "example.com"); err != nil || !ok {
+		t.Fatalf("receipt checked by its signer gave %v, %v", ok, err)
+	}
+
+	// And through the dispatcher, as an app would.
+	out, err := ws.CallWalletMethod("createReceipt", `{"txid":"`+received+`"}`, "example.com")
+	if err != nil {
+		t.Fatalf("createReceipt: %v", err)
+	}
+	if out, err := ws.CallWalletMethod("verifyReceipt", out, "example.com"); err != nil || out != `{"valid":true}` {
+		t.Fatalf("verifyReceipt = %s, %v", out, err)
+	}
+	if out, err := ws.CallWalletMethod("verifyReceipt", strings.Replace(out, "1500", "15000", 1), "example.com"); err != nil || out != `{"valid":false}` {
+		t.Fatalf("verifyReceipt of a tampered receipt = %s, %v", out, err)
+	}
+
+	for name, tamper := range map[string]func(r *Receipt){
+		"amount":     func(r *Receipt) { r.Satoshis = 15000 },
+		"time":       func(r *Receipt) { r.AcceptedAt = r.AcceptedAt.Add(time.Hour) },
+		"txid":       func(r *Receipt) { r.Txid = sent },
+		"moved txid": func(r *Receipt) { r.Txid, r.Envelope.KeyID = sent, sent },
+	} {
+		r := got
+		tamper(&r)
+		if ok, err := verifyReceipt(ctx, sender, &r, "example.com"); err != nil || ok {
+			t.Errorf("receipt with tampered %s gave %v, %v; want false", name, ok, err)
+		}
+	}
+
+	if _, err := ws.CreateReceipt(ctx, sent, "example.com"); err == nil {
+		t.Error("expected no receipt for an action the wallet sent")
+	}
+	if _, err := ws.CreateReceipt(ctx, strings.Repeat("ef", 32), "example.com"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("receipt for an unknown action: err = %v", err)
+	}
+}
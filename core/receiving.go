@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction/template/p2pkh"
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/brc29"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/storage"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/wdk/primitives"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// receivingIndex is how many receiving scripts a user has been given for
+// a basket. The toolbox has no table for it, so it gets its own, in the
+// same database.
+type receivingIndex struct {
+	UserID int    `gorm:"primaryKey;autoIncrement:false"`
+	Basket string `gorm:"primaryKey"`
+	Issued uint32 `gorm:"not null"`
+}
+
+func (receivingIndex) TableName() string { return "gebunden_receiving_indexes" }
+
+// NextReceivingScriptArgs are the args of the nextReceivingScript call.
+type NextReceivingScriptArgs struct {
+	Basket string `json:"basket,omitempty"`
+}
+
+// ReceivingScript is a fresh output script for a payment into the wallet,
+// with what internalizeAction needs to take the payment in.
+type ReceivingScript struct {
+	// LockingScript is the hex P2PKH script to pay to.
+	LockingScript string `json:"lockingScript"`
+	Basket        string `json:"basket"`
+	// Index is the script's place among those given out for the basket;
+	// no two scripts of a basket share one.
+	Index uint32 `json:"index"`
+	// Output takes the payment in once its OutputIndex is set to the
+	// script's output in the paying transaction: a wallet payment for the
+	// default basket, else a basket insertion whose custom instructions
+	// hold the key derivation.
+	Output sdk.InternalizeOutput `json:"output"`
+}
+
+// receivingInstructions are the custom instructions of a receiving script
+// outside the default basket, from which its key is derived again.
+type receivingInstructions struct {
+	ProtocolID   sdk.Protocol `json:"protocolID"`
+	KeyID        string       `json:"keyID"`
+	Counterparty string       `json:"counterparty"`
+}
+
+// NextReceivingScript derives the next unused receiving script of basket,
+// the default basket if empty, for an app to put on an invoice. The index
+// it is derived at is counted in storage, so neither concurrent calls nor
+// a restart hand out the same script twice.
+//
+// Scripts are BRC-29 payments from the wallet to itself, keyed by basket
+// and index, so they can be derived again from the root key alone.
+func (ws *WalletService) NextReceivingScript(ctx context.Context, basket string, originator string) (*ReceivingScript, error) {
+	ws.mu.RLock()
+	w, store := ws.wallet, ws.storage
+	ws.mu.RUnlock()
+
+	if w == nil {
+		return nil, errWalletNotInitialized
+	}
+	ctx, cancel := ws.withOperationTimeout(ctx)
+	defer cancel()
+	return ws.nextReceivingScript(ctx, w, store, basket, originator)
+}
+
+func (ws *WalletService) nextReceivingScript(ctx context.Context, w sdk.PublicKeyGetter, store *storage.Provider, basket string, originator string) (*ReceivingScript, error) {
+	if basket == "" {
+		basket = defaultBasket
+	}
+	if err := primitives.StringUnder300(basket).Validate(); err != nil {
+		return nil, fmt.Errorf("basket must be %w", err)
+	}
+
+	// The identity key also validates the originator.
+	identity, err := w.GetPublicKey(ctx, sdk.GetPublicKeyArgs{IdentityKey: true}, originator)
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite takes one writer at a time, and the first calls on a new
+	// storage all insert the user; holding the lock spares the calls of
+	// this process from failing on each other's writes.
+	ws.receivingMu.Lock()
+	user, err := store.FindOrInsertUser(ctx, identity.PublicKey.ToDERHex())
+	if err != nil {
+		ws.receivingMu.Unlock()
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	index, err := nextReceivingIndex(ctx, store, user.User.UserID, basket)
+	ws.receivingMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return receivingScript(ctx, w, identity.PublicKey, basket, index, originator)
+}
+
+// nextReceivingIndex counts one more script given out for basket and
+// returns its index.
+func nextReceivingIndex(ctx context.Context, store *storage.Provider, userID int, basket string) (uint32, error) {
+	db, err := receivingIndexes(ctx, store)
+	if err != nil {
+		return 0, err
+	}
+	var row receivingIndex
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "basket"}},
+			DoUpdates: clause.Assignments(map[string]any{"issued": gorm.Expr("issued + 1")}),
+		}).Create(&receivingIndex{UserID: userID, Basket: basket, Issued: 1}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("user_id = ? AND basket = ?", userID, basket).Take(&row).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count receiving script: %w", err)
+	}
+	return row.Issued - 1, nil
+}
+
+// reserveReceivingIndexes counts at least issued scripts given out for
+// basket, so that the next one is derived past those already paid to.
+func reserveReceivingIndexes(ctx context.Context, store *storage.Provider, userID int, basket string, issued uint32) error {
+	db, err := receivingIndexes(ctx, store)
+	if err != nil {
+		return err
+	}
+	err = db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "basket"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"issued": gorm.Expr("CASE WHEN issued < ? THEN ? ELSE issued END", issued, issued),
+		}),
+	}).Create(&receivingIndex{UserID: userID, Basket: basket, Issued: issued}).Error
+	if err != nil {
+		return fmt.Errorf("failed to reserve receiving scripts: %w", err)
+	}
+	return nil
+}
+
+// receivingIndexes is the table of receiving indexes, created on first use.
+func receivingIndexes(ctx context.Context, store *storage.Provider) (*gorm.DB, error) {
+	db := store.Database.DB.WithContext(ctx)
+	if err := db.AutoMigrate(&receivingIndex{}); err != nil {
+		return nil, fmt.Errorf("failed to set up receiving indexes: %w", err)
+	}
+	return db, nil
+}
+
+// receivingScript derives the receiving script of basket at index.
+func receivingScript(ctx context.Context, w sdk.PublicKeyGetter, identity *ec.PublicKey, basket string, index uint32, originator string) (*ReceivingScript, error) {
+	prefix, suffix := []byte(basket), []byte(strconv.FormatUint(uint64(index), 10))
+	keyID := brc29.KeyID{
+		DerivationPrefix: base64.StdEncoding.EncodeToString(prefix),
+		DerivationSuffix: base64.StdEncoding.EncodeToString(suffix),
+	}
+	derived, err := w.GetPublicKey(ctx, sdk.GetPublicKeyArgs{
+		EncryptionArgs: sdk.EncryptionArgs{
+			ProtocolID:   brc29.Protocol,
+			KeyID:        keyID.String(),
+			Counterparty: sdk.Counterparty{Type: sdk.CounterpartyTypeSelf},
+		},
+	}, originator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive receiving key: %w", err)
+	}
+	address, err := script.NewAddressFromPublicKey(derived.PublicKey, true)
+	if err != nil {
+		return nil, err
+	}
+	lockingScript, err := p2pkh.Lock(address)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &ReceivingScript{LockingScript: lockingScript.String(), Basket: basket, Index: index}
+	if basket == defaultBasket {
+		r.Output = sdk.InternalizeOutput{
+			Protocol: sdk.InternalizeProtocolWalletPayment,
+			PaymentRemittance: &sdk.Payment{
+				DerivationPrefix:  prefix,
+				DerivationSuffix:  suffix,
+				SenderIdentityKey: identity,
+			},
+		}
+		return r, nil
+	}
+	instructions, err := json.Marshal(receivingInstructions{
+		ProtocolID:   brc29.Protocol,
+		KeyID:        keyID.String(),
+		Counterparty: "self",
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.Output = sdk.InternalizeOutput{
+		Protocol: sdk.InternalizeProtocolBasketInsertion,
+		InsertionRemittance: &sdk.BasketInsertion{
+			Basket:             basket,
+			CustomInstructions: string(instructions),
+		},
+	}
+	return r, nil
+}
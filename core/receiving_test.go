@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/brc29"
+)
+
+func TestNextReceivingScriptConcurrent(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	rootKey := newKey(t)
+	ws := NewWalletService()
+	if err := ws.InitializeWallet(rootKey.Hex(), "test"); err != nil {
+		t.Fatalf("InitializeWallet: %v", err)
+	}
+	defer ws.ShutdownWallet()
+
+	const calls = 16
+	scripts := make([]*ReceivingScript, calls)
+	errs := make([]error, calls)
+	var wg sync.WaitGroup
+	for i := range calls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scripts[i], errs[i] = ws.NextReceivingScript(context.Background(), "", "example.com")
+		}()
+	}
+	wg.Wait()
+
+	seenScripts, seenIndexes := map[string]bool{}, map[uint32]bool{}
+	for i, r := range scripts {
+		if errs[i] != nil {
+			t.Fatalf("call %d: %v", i, errs[i])
+		}
+		if seenScripts[r.LockingScript] || seenIndexes[r.Index] {
+			t.Fatalf("call %d got script %s at index %d, already given out", i, r.LockingScript, r.Index)
+		}
+		seenScripts[r.LockingScript], seenIndexes[r.Index] = true, true
+		if r.Index >= calls {
+			t.Errorf("call %d got index %d, want one under %d", i, r.Index, calls)
+		}
+	}
+
+	// The script is the one a wallet payment with the returned remittance
+	// is taken in as.
+	r := scripts[0]
+	remittance := r.Output.PaymentRemittance
+	if remittance == nil || !remittance.SenderIdentityKey.IsEqual(rootKey.PubKey()) {
+		t.Fatalf("output %+v, want a wallet payment from the wallet itself", r.Output)
+	}
+	want, err := brc29.LockForSelf(rootKey.PubKey(), brc29.KeyID{
+		DerivationPrefix: base64.StdEncoding.EncodeToString(remittance.DerivationPrefix),
+		DerivationSuffix: base64.StdEncoding.EncodeToString(remittance.DerivationSuffix),
+	}, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.LockingScript != want.String() {
+		t.Errorf("script %s, want %s", r.LockingScript, want)
+	}
+
+	// Other baskets count apart, and the count survives a restart.
+	other, err := ws.NextReceivingScript(context.Background(), "invoices", "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Index != 0 || other.Output.InsertionRemittance == nil || other.Output.InsertionRemittance.Basket != "invoices" {
+		t.Errorf("first invoices script at index %d with output %+v, want index 0 inserted into invoices", other.Index, other.Output)
+	}
+	out, err := ws.CallWalletMethod("nextReceivingScript", `{"basket":"invoices"}`, "example.com")
+	if err != nil {
+		t.Fatalf("nextReceivingScript: %v", err)
+	}
+	var dispatched ReceivingScript
+	if err := json.Unmarshal([]byte(out), &dispatched); err != nil {
+		t.Fatal(err)
+	}
+	if dispatched.Index != 1 || dispatched.Basket != "invoices" || dispatched.LockingScript == other.LockingScript {
+		t.Errorf("nextReceivingScript = %s, want the second invoices script", out)
+	}
+	if err := ws.ReinitializeWallet(rootKey.Hex(), "test"); err != nil {
+		t.Fatalf("ReinitializeWallet: %v", err)
+	}
+	next, err := ws.NextReceivingScript(context.Background(), defaultBasket, "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Index != calls || seenScripts[next.LockingScript] {
+		t.Errorf("after restart got index %d, want %d", next.Index, calls)
+	}
+}
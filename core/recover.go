@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/storage"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/wdk"
+)
+
+// utxoSource is the part of the chain services recovery looks outputs up
+// with.
+type utxoSource interface {
+	HashOutputScript(scriptHex string) (string, error)
+	GetUtxoStatus(ctx context.Context, scriptHash string, outpoint *transaction.Outpoint) (*wdk.UtxoStatusResult, error)
+	GetBEEF(ctx context.Context, txID string, knownTxIDs []string) (*transaction.Beef, error)
+}
+
+// recoveryWallet derives the receiving scripts and takes their outputs in.
+type recoveryWallet interface {
+	sdk.PublicKeyGetter
+	internalizer
+}
+
+// defaultGapLimit is how many unused receiving scripts in a row end a
+// recovery scan unless -gap-limit says otherwise.
+const defaultGapLimit = 20
+
+// recoverOriginator is the originator recover-outputs calls the wallet as.
+const recoverOriginator = "recover.gebunden.local"
+
+// RecoveryReport is what RecoverOutputs found.
+type RecoveryReport struct {
+	// Scanned counts the receiving scripts looked up, the empty ones that
+	// ended the scan included.
+	Scanned   uint32            `json:"scanned"`
+	Recovered []RecoveredOutput `json:"recovered"`
+	Satoshis  uint64            `json:"satoshis"`
+	// Failed lists the transactions whose outputs were found but could
+	// not be taken in; RecoverOutputs can be run again for them.
+	Failed []string `json:"failed,omitempty"`
+}
+
+// RecoveredOutput is an unspent output taken in by RecoverOutputs.
+type RecoveredOutput struct {
+	Txid     string `json:"txid"`
+	Vout     uint32 `json:"vout"`
+	Index    uint32 `json:"index"`
+	Satoshis uint64 `json:"satoshis"`
+}
+
+// RecoverOutputs finds the unspent outputs paid to the default basket's
+// receiving scripts, as NextReceivingScript gives them out, and takes
+// them in, for a wallet restored from its key onto empty storage. Scripts
+// are looked up with the chain services in index order until gapLimit of
+// them in a row have no unspent outputs. Outputs of actions the storage
+// already holds are left alone, and the next receiving script given out
+// is past the last one found paid to.
+func (ws *WalletService) RecoverOutputs(ctx context.Context, gapLimit int, originator string) (*RecoveryReport, error) {
+	ws.mu.RLock()
+	w, store, chain := ws.wallet, ws.storage, ws.services
+	ws.mu.RUnlock()
+
+	if w == nil {
+		return nil, errWalletNotInitialized
+	}
+	ctx, cancel := ws.withOperationTimeout(ctx)
+	defer cancel()
+	return ws.recoverOutputs(ctx, w, store, chain, gapLimit, originator)
+}
+
+func (ws *WalletService) recoverOutputs(ctx context.Context, w recoveryWallet, store *storage.Provider, chain utxoSource, gapLimit int, originator string) (*RecoveryReport, error) {
+	if gapLimit <= 0 {
+		return nil, errors.New("gap limit must be positive")
+	}
+	identity, err := w.GetPublicKey(ctx, sdk.GetPublicKeyArgs{IdentityKey: true}, originator)
+	if err != nil {
+		return nil, err
+	}
+	user, err := store.FindOrInsertUser(ctx, identity.PublicKey.ToDERHex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	userID := user.User.UserID
+
+	report := &RecoveryReport{Recovered: []RecoveredOutput{}}
+	// The outputs found, per transaction in the order first found.
+	var txids []string
+	outputs := make(map[string][]sdk.InternalizeOutput)
+	found := make(map[string][]RecoveredOutput)
+	used := uint32(0)
+	for index, empty := uint32(0), 0; empty < gapLimit; index++ {
+		r, err := receivingScript(ctx, w, identity.PublicKey, defaultBasket, index, originator)
+		if err != nil {
+			return nil, err
+		}
+		scriptHash, err := chain.HashOutputScript(r.LockingScript)
+		if err != nil {
+			return nil, err
+		}
+		status, err := chain.GetUtxoStatus(ctx, scriptHash, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up receiving script %d: %w", index, err)
+		}
+		report.Scanned++
+		if len(status.Details) == 0 {
+			empty++
+			continue
+		}
+		empty, used = 0, index+1
+		for _, utxo := range status.Details {
+			if _, ok := outputs[utxo.TxID]; !ok {
+				txids = append(txids, utxo.TxID)
+			}
+			out := r.Output
+			out.OutputIndex = utxo.Index
+			outputs[utxo.TxID] = append(outputs[utxo.TxID], out)
+			found[utxo.TxID] = append(found[utxo.TxID], RecoveredOutput{Txid: utxo.TxID, Vout: utxo.Index, Index: index, Satoshis: utxo.Satoshis})
+		}
+	}
+	if used > 0 {
+		if err := reserveReceivingIndexes(ctx, store, userID, defaultBasket, used); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, txid := range txids {
+		known, err := store.TransactionEntity().Read().UserID().Equals(userID).TxID().Equals(txid).Count(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find action: %w", err)
+		}
+		if known > 0 {
+			continue
+		}
+		if err := ws.recoverTransaction(ctx, w, chain, txid, outputs[txid], originator); err != nil {
+			ws.logger.Warn("Failed to recover outputs", "txid", txid, "error", err)
+			report.Failed = append(report.Failed, txid)
+			continue
+		}
+		for _, out := range found[txid] {
+			report.Recovered = append(report.Recovered, out)
+			report.Satoshis += out.Satoshis
+		}
+	}
+	ws.logger.Info("Outputs recovered", "scanned", report.Scanned, "outputs", len(report.Recovered), "satoshis", report.Satoshis, "failed", len(report.Failed))
+	return report, nil
+}
+
+// recoverTransaction takes in the outputs of txid paid to receiving
+// scripts.
+func (ws *WalletService) recoverTransaction(ctx context.Context, w internalizer, chain utxoSource, txid string, outputs []sdk.InternalizeOutput, originator string) error {
+	hash, err := chainhash.NewHashFromHex(txid)
+	if err != nil {
+		return err
+	}
+	beef, err := chain.GetBEEF(ctx, txid, nil)
+	if err != nil {
+		return err
+	}
+	atomic, err := beef.AtomicBytes(hash)
+	if err != nil {
+		return err
+	}
+	_, err = ws.internalizeAction(ctx, w, sdk.InternalizeActionArgs{
+		Tx:          atomic,
+		Outputs:     outputs,
+		Description: "Recovered payment",
+		Labels:      []string{"recovered"},
+	}, originator)
+	return err
+}
+
+// runRecoverOutputs implements `gebunden recover-outputs`: open the wallet
+// of the configured key, as the daemon would, run RecoverOutputs on it and
+// print what was found. The daemon must not be running on the same storage.
+func runRecoverOutputs(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("recover-outputs", flag.ContinueOnError)
+	keyFile := fs.String("key-file", "", "Path to wallet identity JSON file")
+	gapLimit := fs.Int("gap-limit", defaultGapLimit, "Stop after this many unused receiving scripts in a row")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *gapLimit <= 0 {
+		return fmt.Errorf("-gap-limit must be positive")
+	}
+
+	privateKey, network, passphrase, err := loadPrivateKey(*keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load private key: %w", err)
+	}
+	ws := NewWalletService()
+	if err := ws.InitializeWalletWithPassphrase(privateKey, network, passphrase); err != nil {
+		return err
+	}
+	defer ws.ShutdownWallet()
+
+	report, err := ws.RecoverOutputs(context.Background(), *gapLimit, recoverOriginator)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Scanned %d receiving scripts, recovered %d outputs worth %d satoshis\n", report.Scanned, len(report.Recovered), report.Satoshis)
+	for _, txid := range report.Failed {
+		fmt.Fprintf(stdout, "Failed to take in %s; run again to retry\n", txid)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/script"
+	sdktx "github.com/bsv-blockchain/go-sdk/transaction"
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/brc29"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/wdk"
+)
+
+// fakeInternalizer is a wallet recording the payments it takes in.
+type fakeInternalizer struct {
+	*sdk.ProtoWallet
+	internalized []sdk.InternalizeActionArgs
+}
+
+func (f *fakeInternalizer) InternalizeAction(_ context.Context, args sdk.InternalizeActionArgs, _ string) (*sdk.InternalizeActionResult, error) {
+	f.internalized = append(f.internalized, args)
+	return &sdk.InternalizeActionResult{Accepted: true}, nil
+}
+
+func newFakeInternalizer(t *testing.T, key *ec.PrivateKey) *fakeInternalizer {
+	t.Helper()
+	w, err := sdk.NewProtoWallet(sdk.ProtoWalletArgs{Type: sdk.ProtoWalletArgsTypePrivateKey, PrivateKey: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fakeInternalizer{ProtoWallet: w}
+}
+
+// fakeUTXOs is a chain service knowing the unspent outputs of some
+// scripts. Its script hash is the script itself.
+type fakeUTXOs struct {
+	utxos   map[string][]wdk.UtxoDetail
+	beefs   map[string]*sdktx.Beef
+	queried int
+}
+
+func (f *fakeUTXOs) HashOutputScript(scriptHex string) (string, error) { return scriptHex, nil }
+
+func (f *fakeUTXOs) GetUtxoStatus(_ context.Context, scriptHash string, _ *sdktx.Outpoint) (*wdk.UtxoStatusResult, error) {
+	f.queried++
+	details := f.utxos[scriptHash]
+	return &wdk.UtxoStatusResult{Details: details, IsUtxo: len(details) > 0}, nil
+}
+
+func (f *fakeUTXOs) GetBEEF(_ context.Context, txid string, _ []string) (*sdktx.Beef, error) {
+	return f.beefs[txid], nil
+}
+
+// pay records a transaction paying satoshis to each of the wallet's
+// receiving scripts at indexes.
+func (f *fakeUTXOs) pay(t *testing.T, rootKey *ec.PrivateKey, satoshis uint64, indexes ...uint32) string {
+	t.Helper()
+	tx := sdktx.NewTransaction()
+	scripts := make([]*script.Script, len(indexes))
+	for i, index := range indexes {
+		lock, err := brc29.LockForSelf(rootKey.PubKey(), brc29.KeyID{
+			DerivationPrefix: base64.StdEncoding.EncodeToString([]byte(defaultBasket)),
+			DerivationSuffix: base64.StdEncoding.EncodeToString([]byte(strconv.Itoa(int(index)))),
+		}, rootKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scripts[i] = lock
+		tx.AddOutput(&sdktx.TransactionOutput{LockingScript: lock, Satoshis: satoshis})
+	}
+	txid := tx.TxID().String()
+	for vout, lock := range scripts {
+		f.utxos[lock.String()] = append(f.utxos[lock.String()], wdk.UtxoDetail{TxID: txid, Index: uint32(vout), Satoshis: satoshis})
+	}
+	beef, err := sdktx.NewBeefFromTransaction(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.beefs[txid] = beef
+	return txid
+}
+
+func TestRecoverOutputsStopsAfterGap(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	rootKey := newKey(t)
+	ws := NewWalletService()
+	if err := ws.InitializeWallet(rootKey.Hex(), "test"); err != nil {
+		t.Fatalf("InitializeWallet: %v", err)
+	}
+	defer ws.ShutdownWallet()
+	ctx := context.Background()
+
+	w := newFakeInternalizer(t, rootKey)
+	chain := &fakeUTXOs{utxos: map[string][]wdk.UtxoDetail{}, beefs: map[string]*sdktx.Beef{}}
+	first := chain.pay(t, rootKey, 1000, 0, 2)
+	second := chain.pay(t, rootKey, 500, 5)
+	// Past the gap of 3 after index 5, so never looked up.
+	chain.pay(t, rootKey, 700, 9)
+
+	report, err := ws.recoverOutputs(ctx, w, ws.storage, chain, 3, "example.com")
+	if err != nil {
+		t.Fatalf("recoverOutputs: %v", err)
+	}
+	if report.Scanned != 9 || chain.queried != 9 {
+		t.Errorf("scanned %d scripts in %d lookups, want indexes 0 to 8", report.Scanned, chain.queried)
+	}
+	if len(report.Recovered) != 3 || report.Satoshis != 2500 || len(report.Failed) != 0 {
+		t.Fatalf("recovered %+v worth %d, failed %v; want 3 outputs worth 2500", report.Recovered, report.Satoshis, report.Failed)
+	}
+
+	// One internalizeAction per transaction, with a wallet payment per
+	// output found.
+	if len(w.internalized) != 2 {
+		t.Fatalf("took in %d transactions, want 2", len(w.internalized))
+	}
+	for i, want := range []struct {
+		txid  string
+		vouts []uint32
+	}{{first, []uint32{0, 1}}, {second, []uint32{0}}} {
+		in := w.internalized[i]
+		_, _, txid, err := sdktx.ParseBeef(in.Tx)
+		if err != nil || txid.String() != want.txid {
+			t.Fatalf("transaction %d is %v (%v), want %s", i, txid, err, want.txid)
+		}
+		if len(in.Outputs) != len(want.vouts) {
+			t.Fatalf("transaction %d took in %d outputs, want %d", i, len(in.Outputs), len(want.vouts))
+		}
+		for j, out := range in.Outputs {
+			if out.OutputIndex != want.vouts[j] || out.Protocol != sdk.InternalizeProtocolWalletPayment {
+				t.Errorf("transaction %d output %d is %+v, want a wallet payment of vout %d", i, j, out, want.vouts[j])
+			}
+		}
+	}
+
+	// Receiving scripts given out next are past those found paid to.
+	next, err := ws.NextReceivingScript(ctx, "", "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Index != 6 {
+		t.Errorf("next receiving script at index %d, want 6", next.Index)
+	}
+}
+
+func TestRecoverOutputsRejectsZeroGap(t *testing.T) {
+	ws := NewWalletService()
+	w := newFakeInternalizer(t, newKey(t))
+	if _, err := ws.recoverOutputs(context.Background(), w, nil, &fakeUTXOs{}, 0, "example.com"); err == nil {
+		t.Fatal("recovered with a gap limit of 0")
+	}
+}
+
+func TestRecoverOutputsCommandRejectsGapLimit(t *testing.T) {
+	if err := runRecoverOutputs([]string{"-gap-limit", "0"}, io.Discard); err == nil || !strings.Contains(err.Error(), "gap-limit") {
+		t.Fatalf("runRecoverOutputs -gap-limit 0: err = %v, want it rejected", err)
+	}
+}
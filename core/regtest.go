@@ -0,0 +1,76 @@
+package main
+
+import (
+	"github.com/bsv-blockchain/go-sdk/overlay"
+	"github.com/bsv-blockchain/go-sdk/overlay/lookup"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/defs"
+)
+
+// chainRegtest is a local regression test network. The toolbox knows only
+// mainnet and testnet, so a regtest wallet runs as a testnet wallet, whose
+// address and key formats regtest shares, with storage of its own, overlay
+// lookups against a local overlay and no chain services.
+const chainRegtest defs.BSVNetwork = "regtest"
+
+// WithLookupResolver has the wallet's overlay lookups, used to discover
+// identities and certificates, go through r instead of the resolver for
+// its network, for example one pointing at a development overlay.
+func WithLookupResolver(r *lookup.LookupResolver) WalletServiceOption {
+	return func(ws *WalletService) {
+		ws.lookupResolver = r
+	}
+}
+
+// parseChain parses a wallet network name: main, test or regtest.
+func parseChain(chain string) (defs.BSVNetwork, error) {
+	if chain == string(chainRegtest) {
+		return chainRegtest, nil
+	}
+	return defs.ParseBSVNetworkStr(chain)
+}
+
+// chainName is the name chain goes by in messages.
+func chainName(chain defs.BSVNetwork) string {
+	switch chain {
+	case defs.NetworkMainnet:
+		return "mainnet"
+	case defs.NetworkTestnet:
+		return "testnet"
+	}
+	return string(chain)
+}
+
+// toolboxNetwork is the network the toolbox runs a wallet on chain as.
+func toolboxNetwork(chain defs.BSVNetwork) defs.BSVNetwork {
+	if chain == chainRegtest {
+		return defs.NetworkTestnet
+	}
+	return chain
+}
+
+// overlayNetwork is the overlay network preset for chain. The local preset
+// sends every lookup to an overlay on localhost:8080.
+func overlayNetwork(chain defs.BSVNetwork) overlay.Network {
+	switch chain {
+	case defs.NetworkMainnet:
+		return overlay.NetworkMainnet
+	case chainRegtest:
+		return overlay.NetworkLocal
+	}
+	return overlay.NetworkTestnet
+}
+
+// servicesConfig is the chain services configuration for chain. The
+// public providers serve only mainnet and testnet, so on regtest they are
+// all turned off rather than asked about transactions they cannot know.
+func servicesConfig(chain defs.BSVNetwork) defs.WalletServices {
+	cfg := defs.DefaultServicesConfig(toolboxNetwork(chain))
+	if chain == chainRegtest {
+		cfg.ArcConfig.Enabled = false
+		cfg.BHS.Enabled = false
+		cfg.WhatsOnChain.Enabled = false
+		cfg.Bitails.Enabled = false
+		cfg.ChaintracksClient.Enabled = false
+	}
+	return cfg
+}
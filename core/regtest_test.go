@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bsv-blockchain/go-sdk/overlay"
+	"github.com/bsv-blockchain/go-sdk/overlay/lookup"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/wdk"
+)
+
+func TestRegtestWallet(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// A development overlay standing in for the local preset's.
+	var lookups atomic.Int32
+	overlayHost := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"type":"output-list","outputs":[]}`))
+	}))
+	defer overlayHost.Close()
+
+	key := newKey(t)
+	ws := NewWalletService(WithLookupResolver(lookup.NewLookupResolver(&lookup.LookupResolver{
+		NetworkPreset: overlay.NetworkTestnet,
+		HostOverrides: map[string][]string{"ls_identity": {overlayHost.URL}},
+	})))
+	if err := ws.InitializeWallet(key.Hex(), "regtest"); err != nil {
+		t.Fatalf("InitializeWallet: %v", err)
+	}
+	defer ws.ShutdownWallet()
+
+	if got := ws.GetNetwork(); got != "regtest" {
+		t.Fatalf("GetNetwork = %q, want regtest", got)
+	}
+	identityKey, _ := wdk.IdentityKey(key.Hex())
+	if _, err := os.Stat(filepath.Join(home, ".gebunden", "wallet-"+identityKey+"-regtest.sqlite")); err != nil {
+		t.Fatalf("regtest storage: %v", err)
+	}
+
+	// BRC-100 knows only mainnet and testnet; regtest reports testnet.
+	if got, err := ws.CallWalletMethod("getNetwork", "", "example.com"); err != nil || got != `{"network":"test"}` {
+		t.Fatalf("getNetwork = %s, %v", got, err)
+	}
+	if _, err := ws.CallWalletMethod("discoverByIdentityKey", `{"identityKey":"`+newKey(t).PubKey().ToDERHex()+`"}`, "example.com"); err != nil {
+		t.Fatalf("discoverByIdentityKey: %v", err)
+	}
+	if lookups.Load() == 0 {
+		t.Fatal("discovery did not use the custom lookup resolver")
+	}
+
+	// Regtest addresses are testnet addresses.
+	if _, err := parsePaymentURI("bitcoin:1BoatSLRHtKNngkdXEeobR76b53LETtpyT?amount=1", chainRegtest); err == nil || !strings.Contains(err.Error(), "on regtest") {
+		t.Fatalf("mainnet address on regtest: err = %v", err)
+	}
+}
+
+func TestRegtestHasNoChainServices(t *testing.T) {
+	cfg := servicesConfig(chainRegtest)
+	if cfg.ArcConfig.Enabled || cfg.WhatsOnChain.Enabled || cfg.BHS.Enabled || cfg.Bitails.Enabled || cfg.ChaintracksClient.Enabled {
+		t.Fatalf("regtest services config enables public providers: %+v", cfg)
+	}
+	if overlayNetwork(chainRegtest) != overlay.NetworkLocal {
+		t.Fatal("regtest does not use the local overlay preset")
+	}
+	if _, err := parseChain("stn"); err == nil {
+		t.Fatal("expected an unknown network to be rejected")
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+)
+
+func newKey(t *testing.T) *ec.PrivateKey {
+	t.Helper()
+	key, err := ec.NewPrivateKey()
+	if err != nil {
+		t.Fatalf("NewPrivateKey: %v", err)
+	}
+	return key
+}
+
+// identityKeyOf asks the running wallet for its identity key.
+func identityKeyOf(t *testing.T, ws *WalletService) string {
+	t.Helper()
+	result, err := ws.CallWalletMethod("getPublicKey", `{"identityKey":true}`, "example.com")
+	if err != nil {
+		t.Fatalf("getPublicKey: %v", err)
+	}
+	return result
+}
+
+func TestReinitializeWalletSwapsKey(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	first, second := newKey(t), newKey(t)
+
+	ws := NewWalletService()
+	if err := ws.InitializeWallet(first.Hex(), "test"); err != nil {
+		t.Fatalf("InitializeWallet: %v", err)
+	}
+	defer ws.ShutdownWallet()
+	if got := identityKeyOf(t, ws); !strings.Contains(got, first.PubKey().ToDERHex()) {
+		t.Fatalf("identity before reload = %s", got)
+	}
+
+	// A bad key is refused and the running wallet keeps serving.
+	if err := ws.ReinitializeWallet("not-a-key", "test"); err == nil {
+		t.Fatal("expected an invalid key to be refused")
+	}
+	if got := identityKeyOf(t, ws); !strings.Contains(got, first.PubKey().ToDERHex()) {
+		t.Fatalf("identity after refused reload = %s, want the original key", got)
+	}
+
+	if err := ws.ReinitializeWallet(second.Hex(), "test"); err != nil {
+		t.Fatalf("ReinitializeWallet: %v", err)
+	}
+	if got := identityKeyOf(t, ws); !strings.Contains(got, second.PubKey().ToDERHex()) {
+		t.Fatalf("identity after reload = %s, want the new key", got)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShutdownCancelsInFlightDiscovery(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("NO_PROXY", "")
+
+	// A proxy that accepts the overlay lookup and never answers it.
+	arrived := make(chan struct{})
+	var once sync.Once
+	release := make(chan struct{})
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() { close(arrived) })
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer proxy.Close()
+	defer close(release)
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	ws := NewWalletService(WithHTTPProxy(proxyURL))
+	if err := ws.InitializeWallet(newKey(t).Hex(), "test"); err != nil {
+		t.Fatalf("InitializeWallet: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		args := `{"identityKey":"` + newKey(t).PubKey().ToDERHex() + `"}`
+		_, err := ws.CallWalletMethod("discoverByIdentityKey", args, "example.com")
+		done <- err
+	}()
+
+	select {
+	case <-arrived:
+	case err := <-done:
+		t.Fatalf("discovery finished before reaching the network: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("discovery never reached the network")
+	}
+
+	// Closing the monitor can wait on one of its own tasks, so time the
+	// call's return rather than ShutdownWallet as a whole.
+	start := time.Now()
+	shutDown := make(chan struct{})
+	go func() {
+		ws.ShutdownWallet()
+		close(shutDown)
+	}()
+	defer func() { <-shutDown }()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expected the cancelled discovery to fail")
+		}
+		// The SDK gives up on a tracker after a second on its own, so
+		// only a much faster return shows the shutdown cancelled it.
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Fatalf("discovery took %v to stop after shutdown", elapsed)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("discovery kept running after shutdown")
+	}
+}
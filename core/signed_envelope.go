@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+)
+
+// SignedEnvelope is a detached signature together with what a verifier
+// needs to check it: who signed, and the protocol, key ID and
+// counterparty the signing key was derived for. It holds no secrets.
+type SignedEnvelope struct {
+	Signature    string           `json:"signature"` // DER, hex
+	Signer       string           `json:"signer"`    // identity key, hex
+	ProtocolID   sdk.Protocol     `json:"protocolID"`
+	KeyID        string           `json:"keyID"`
+	Counterparty sdk.Counterparty `json:"counterparty"`
+	// Hashed is set when the signature is over a hash the signer was
+	// handed rather than over the data itself.
+	Hashed bool `json:"hashed,omitempty"`
+}
+
+// VerifySignedEnvelopeArgs are the args of the verifySignedEnvelope call.
+// Data is encoded as createSignature's data is.
+type VerifySignedEnvelopeArgs struct {
+	Data     sdk.BytesList  `json:"data"`
+	Envelope SignedEnvelope `json:"envelope"`
+}
+
+// CreateSignedEnvelope signs as createSignature does and bundles the
+// signature with the signing parameters, so that it can be handed to a
+// verifier on its own. Privileged calls go to the privileged key manager.
+func (ws *WalletService) CreateSignedEnvelope(ctx context.Context, args sdk.CreateSignatureArgs, originator string) (*SignedEnvelope, error) {
+	ws.mu.RLock()
+	w := ws.wallet
+	ws.mu.RUnlock()
+
+	if w == nil {
+		return nil, errWalletNotInitialized
+	}
+	var keys sdk.KeyOperations = w
+	if args.Privileged {
+		if ws.privilegedKeys == nil {
+			return nil, ErrPrivilegedNotSupported
+		}
+		if args.PrivilegedReason == "" {
+			return nil, ErrPrivilegedReasonRequired
+		}
+		keys = ws.privilegedKeys
+	}
+	ctx, cancel := ws.withOperationTimeout(ctx)
+	defer cancel()
+	return createSignedEnvelope(ctx, keys, args, originator)
+}
+
+func createSignedEnvelope(ctx context.Context, keys sdk.KeyOperations, args sdk.CreateSignatureArgs, originator string) (*SignedEnvelope, error) {
+	// Signing keys derived for no one in particular are derived for anyone.
+	if args.Counterparty.Type == sdk.CounterpartyUninitialized {
+		args.Counterparty = sdk.Counterparty{Type: sdk.CounterpartyTypeAnyone}
+	}
+	signed, err := keys.CreateSignature(ctx, args, originator)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := keys.GetPublicKey(ctx, sdk.GetPublicKeyArgs{
+		IdentityKey:    true,
+		EncryptionArgs: sdk.EncryptionArgs{Privileged: args.Privileged, PrivilegedReason: args.PrivilegedReason},
+	}, originator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signer identity key: %w", err)
+	}
+	return &SignedEnvelope{
+		Signature:    hex.EncodeToString(signed.Signature.Serialize()),
+		Signer:       identity.PublicKey.ToDERHex(),
+		ProtocolID:   args.ProtocolID,
+		KeyID:        args.KeyID,
+		Counterparty: args.Counterparty,
+		Hashed:       len(args.HashToDirectlySign) > 0,
+	}, nil
+}
+
+// VerifySignedEnvelope reports whether env holds a valid signature of
+// data, or of the hash data when env is Hashed. Envelopes signed for
+// anyone can be checked by any wallet; those signed for a counterparty
+// only by that counterparty's wallet, and those signed for self only by
+// the signer's. As with VerifyExternalSignature, a signature that does
+// not verify gives false and no error.
+func (ws *WalletService) VerifySignedEnvelope(ctx context.Context, data []byte, env *SignedEnvelope, originator string) (bool, error) {
+	ws.mu.RLock()
+	w := ws.wallet
+	ws.mu.RUnlock()
+
+	if w == nil {
+		return false, errWalletNotInitialized
+	}
+	ctx, cancel := ws.withOperationTimeout(ctx)
+	defer cancel()
+	return verifySignedEnvelope(ctx, w, data, env, originator)
+}
+
+func verifySignedEnvelope(ctx context.Context, w sdk.KeyOperations, data []byte, env *SignedEnvelope, originator string) (bool, error) {
+	if env == nil {
+		return false, errors.New("no envelope to verify")
+	}
+	signer, err := ec.PublicKeyFromString(env.Signer)
+	if err != nil {
+		return false, fmt.Errorf("invalid envelope signer: %w", err)
+	}
+	der, err := hex.DecodeString(env.Signature)
+	if err != nil {
+		return false, fmt.Errorf("invalid envelope signature: %w", err)
+	}
+	sig, err := ec.FromDER(der)
+	if err != nil {
+		return false, fmt.Errorf("invalid envelope signature: %w", err)
+	}
+
+	// The verifier derives the signing key's public half for the signer,
+	// from the side of the counterparty it was derived for.
+	counterparty := sdk.Counterparty{Type: sdk.CounterpartyTypeOther, Counterparty: signer}
+	var verifier sdk.KeyOperations
+	switch env.Counterparty.Type {
+	case sdk.CounterpartyTypeAnyone, sdk.CounterpartyUninitialized:
+		verifier, err = verifierFor(ctx, w, nil, originator)
+	case sdk.CounterpartyTypeSelf:
+		counterparty = sdk.Counterparty{Type: sdk.CounterpartyTypeSelf}
+		verifier, err = verifierFor(ctx, w, signer, originator)
+	default:
+		verifier, err = verifierFor(ctx, w, env.Counterparty.Counterparty, originator)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	args := sdk.VerifySignatureArgs{
+		EncryptionArgs: sdk.EncryptionArgs{
+			ProtocolID:   env.ProtocolID,
+			KeyID:        env.KeyID,
+			Counterparty: counterparty,
+		},
+		Signature: sig,
+	}
+	if env.Hashed {
+		args.HashToDirectlyVerify = data
+	} else {
+		args.Data = data
+	}
+	result, err := verifier.VerifySignature(ctx, args, originator)
+	if err != nil {
+		return false, fmt.Errorf("failed to verify signature: %w", err)
+	}
+	return result.Valid, nil
+}
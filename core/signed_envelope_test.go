@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+)
+
+func TestSignedEnvelopeVerifies(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ws := NewWalletService()
+	if err := ws.InitializeWallet(newKey(t).Hex(), "test"); err != nil {
+		t.Fatalf("InitializeWallet: %v", err)
+	}
+	defer ws.ShutdownWallet()
+	ctx := context.Background()
+
+	recipientKey := newKey(t)
+	recipient, err := sdk.NewProtoWallet(sdk.ProtoWalletArgs{Type: sdk.ProtoWalletArgsTypePrivateKey, PrivateKey: recipientKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("invoice 42 paid")
+	sign := func(counterparty sdk.Counterparty) *SignedEnvelope {
+		t.Helper()
+		env, err := ws.CreateSignedEnvelope(ctx, sdk.CreateSignatureArgs{
+			EncryptionArgs: sdk.EncryptionArgs{
+				ProtocolID:   sdk.Protocol{SecurityLevel: sdk.SecurityLevelEveryAppAndCounterparty, Protocol: "invoice receipts"},
+				KeyID:        "42",
+				Counterparty: counterparty,
+			},
+			Data: data,
+		}, "example.com")
+		if err != nil {
+			t.Fatalf("CreateSignedEnvelope: %v", err)
+		}
+		// The envelope travels as JSON.
+		raw, err := json.Marshal(env)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var received SignedEnvelope
+		if err := json.Unmarshal(raw, &received); err != nil {
+			t.Fatalf("unmarshal %s: %v", raw, err)
+		}
+		return &received
+	}
+
+	for name, env := range map[string]*SignedEnvelope{
+		"for the recipient": sign(sdk.Counterparty{Type: sdk.CounterpartyTypeOther, Counterparty: recipientKey.PubKey()}),
+		"for anyone":        sign(sdk.Counterparty{}),
+	} {
+		if ok, err := verifySignedEnvelope(ctx, recipient, data, env, "example.com"); err != nil || !ok {
+			t.Errorf("%s: envelope gave %v, %v", name, ok, err)
+		}
+		if ok, err := verifySignedEnvelope(ctx, recipient, []byte("invoice 43 paid"), env, "example.com"); err != nil || ok {
+			t.Errorf("%s: tampered data gave %v, %v; want false", name, ok, err)
+		}
+	}
+
+	self := sign(sdk.Counterparty{Type: sdk.CounterpartyTypeSelf})
+	if ok, err := ws.VerifySignedEnvelope(ctx, data, self, "example.com"); err != nil || !ok {
+		t.Errorf("envelope for self checked by the signer gave %v, %v", ok, err)
+	}
+	if _, err := verifySignedEnvelope(ctx, recipient, data, self, "example.com"); err == nil {
+		t.Error("expected an envelope for self to be uncheckable by another wallet")
+	}
+
+	// And through the dispatcher, as an app would.
+	env, err := ws.CallWalletMethod("createSignedEnvelope", `{"protocolID":[1,"invoice receipts"],"keyID":"42","data":[1,2,3]}`, "example.com")
+	if err != nil {
+		t.Fatalf("createSignedEnvelope: %v", err)
+	}
+	for data, want := range map[string]string{"[1,2,3]": `{"valid":true}`, "[1,2,4]": `{"valid":false}`} {
+		out, err := ws.CallWalletMethod("verifySignedEnvelope", `{"data":`+data+`,"envelope":`+env+`}`, "example.com")
+		if err != nil || out != want {
+			t.Errorf("verifySignedEnvelope of %s = %s, %v; want %s", data, out, err, want)
+		}
+	}
+}
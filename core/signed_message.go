@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+)
+
+// Signed messages (BRC-77) are a version, the signer's identity key, the
+// verifier's identity key or a zero byte for anyone, a key ID, and the
+// signature, made with a key derived under signedMessageProtocol.
+var (
+	signedMessageVersion  = []byte{0x42, 0x42, 0x33, 0x01}
+	signedMessageProtocol = sdk.Protocol{SecurityLevel: sdk.SecurityLevelEveryAppAndCounterparty, Protocol: "message signing"}
+)
+
+const (
+	signedMessageKeySize   = 33
+	signedMessageKeyIDSize = 32
+)
+
+// ErrMalformedSignature is returned for signatures that are not BRC-77
+// signed message signatures.
+var ErrMalformedSignature = errors.New("malformed signed message signature")
+
+// VerifyExternalSignatureArgs are the args of the verifyExternalSignature
+// call. Message and Signature are encoded as createSignature's data is;
+// SignerIdentityKey is hex.
+type VerifyExternalSignatureArgs struct {
+	Message           sdk.BytesList `json:"message"`
+	Signature         sdk.BytesList `json:"signature"`
+	SignerIdentityKey string        `json:"signerIdentityKey"`
+}
+
+// VerifyExternalSignature reports whether signature, a BRC-77 signed
+// message signature made by another wallet, is signerIdentityKey's
+// signature of message, addressed to this wallet or to anyone. A
+// signature that is well formed but does not verify, because the message
+// was changed or someone else signed it, gives false and no error.
+// Malformed signatures and those addressed to another wallet are errors.
+func (ws *WalletService) VerifyExternalSignature(ctx context.Context, message, signature []byte, signerIdentityKey *ec.PublicKey, originator string) (bool, error) {
+	ws.mu.RLock()
+	w := ws.wallet
+	ws.mu.RUnlock()
+
+	if w == nil {
+		return false, errWalletNotInitialized
+	}
+	ctx, cancel := ws.withOperationTimeout(ctx)
+	defer cancel()
+	return verifySignedMessage(ctx, w, message, signature, signerIdentityKey, originator)
+}
+
+func verifySignedMessage(ctx context.Context, w sdk.KeyOperations, message, signature []byte, signerIdentityKey *ec.PublicKey, originator string) (bool, error) {
+	if signerIdentityKey == nil {
+		return false, errors.New("signer identity key is required")
+	}
+	rest, ok := bytes.CutPrefix(signature, signedMessageVersion)
+	if !ok {
+		return false, fmt.Errorf("%w: unknown version", ErrMalformedSignature)
+	}
+	if len(rest) < signedMessageKeySize+1 {
+		return false, fmt.Errorf("%w: too short", ErrMalformedSignature)
+	}
+	signer, err := ec.PublicKeyFromBytes(rest[:signedMessageKeySize])
+	if err != nil {
+		return false, fmt.Errorf("%w: signer key: %v", ErrMalformedSignature, err)
+	}
+	rest = rest[signedMessageKeySize:]
+
+	var recipient *ec.PublicKey
+	if rest[0] == 0 {
+		rest = rest[1:]
+	} else {
+		if len(rest) < signedMessageKeySize {
+			return false, fmt.Errorf("%w: too short", ErrMalformedSignature)
+		}
+		if recipient, err = ec.PublicKeyFromBytes(rest[:signedMessageKeySize]); err != nil {
+			return false, fmt.Errorf("%w: verifier key: %v", ErrMalformedSignature, err)
+		}
+		rest = rest[signedMessageKeySize:]
+	}
+	verifier, err := verifierFor(ctx, w, recipient, originator)
+	if err != nil {
+		return false, err
+	}
+	if len(rest) < signedMessageKeyIDSize+1 {
+		return false, fmt.Errorf("%w: too short", ErrMalformedSignature)
+	}
+	keyID := base64.StdEncoding.EncodeToString(rest[:signedMessageKeyIDSize])
+	sig, err := ec.FromDER(rest[signedMessageKeyIDSize:])
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrMalformedSignature, err)
+	}
+
+	if !signer.IsEqual(signerIdentityKey) {
+		return false, nil
+	}
+	result, err := verifier.VerifySignature(ctx, sdk.VerifySignatureArgs{
+		EncryptionArgs: sdk.EncryptionArgs{
+			ProtocolID:   signedMessageProtocol,
+			KeyID:        keyID,
+			Counterparty: sdk.Counterparty{Type: sdk.CounterpartyTypeOther, Counterparty: signer},
+		},
+		Data:      message,
+		Signature: sig,
+	}, originator)
+	if err != nil {
+		return false, fmt.Errorf("failed to verify signature: %w", err)
+	}
+	return result.Valid, nil
+}
+
+// verifierFor returns the keys that check a signature made with a key
+// derived for recipient, or for anyone when recipient is nil. Keys derived
+// for a recipient take the recipient's own keys to check, so only those
+// for this wallet, w, can be.
+func verifierFor(ctx context.Context, w sdk.KeyOperations, recipient *ec.PublicKey, originator string) (sdk.KeyOperations, error) {
+	if recipient == nil {
+		return sdk.NewProtoWallet(sdk.ProtoWalletArgs{Type: sdk.ProtoWalletArgsTypeAnyone})
+	}
+	identity, err := w.GetPublicKey(ctx, sdk.GetPublicKeyArgs{IdentityKey: true}, originator)
+	if err != nil {
+		return nil, err
+	}
+	if !recipient.IsEqual(identity.PublicKey) {
+		return nil, fmt.Errorf("signature is addressed to %s, not this wallet", recipient.ToDERHex())
+	}
+	return w, nil
+}
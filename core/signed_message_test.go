@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+)
+
+// signMessage signs message as signer's wallet would for verifier, or for
+// anyone when verifier is nil.
+func signMessage(t *testing.T, signer *ec.PrivateKey, verifier *ec.PublicKey, message []byte) []byte {
+	t.Helper()
+	keyID := make([]byte, signedMessageKeyIDSize)
+	rand.Read(keyID)
+	counterparty := sdk.Counterparty{Type: sdk.CounterpartyTypeAnyone}
+	if verifier != nil {
+		counterparty = sdk.Counterparty{Type: sdk.CounterpartyTypeOther, Counterparty: verifier}
+	}
+	w, err := sdk.NewProtoWallet(sdk.ProtoWalletArgs{Type: sdk.ProtoWalletArgsTypePrivateKey, PrivateKey: signer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := w.CreateSignature(context.Background(), sdk.CreateSignatureArgs{
+		EncryptionArgs: sdk.EncryptionArgs{
+			ProtocolID:   signedMessageProtocol,
+			KeyID:        base64.StdEncoding.EncodeToString(keyID),
+			Counterparty: counterparty,
+		},
+		Data: message,
+	}, "")
+	if err != nil {
+		t.Fatalf("CreateSignature: %v", err)
+	}
+
+	sig := bytes.Clone(signedMessageVersion)
+	sig = append(sig, signer.PubKey().Compressed()...)
+	if verifier != nil {
+		sig = append(sig, verifier.Compressed()...)
+	} else {
+		sig = append(sig, 0)
+	}
+	sig = append(sig, keyID...)
+	return append(sig, result.Signature.Serialize()...)
+}
+
+func TestVerifyExternalSignature(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	key := newKey(t)
+	ws := NewWalletService()
+	if err := ws.InitializeWallet(key.Hex(), "test"); err != nil {
+		t.Fatalf("InitializeWallet: %v", err)
+	}
+	defer ws.ShutdownWallet()
+	ctx := context.Background()
+
+	signer := newKey(t)
+	message := []byte("meet at noon")
+	for name, sig := range map[string][]byte{
+		"for us":     signMessage(t, signer, key.PubKey(), message),
+		"for anyone": signMessage(t, signer, nil, message),
+	} {
+		if ok, err := ws.VerifyExternalSignature(ctx, message, sig, signer.PubKey(), "example.com"); err != nil || !ok {
+			t.Errorf("%s: valid signature gave %v, %v", name, ok, err)
+		}
+		if ok, err := ws.VerifyExternalSignature(ctx, []byte("meet at one"), sig, signer.PubKey(), "example.com"); err != nil || ok {
+			t.Errorf("%s: tampered message gave %v, %v; want false", name, ok, err)
+		}
+		if ok, err := ws.VerifyExternalSignature(ctx, message, sig, newKey(t).PubKey(), "example.com"); err != nil || ok {
+			t.Errorf("%s: wrong signer key gave %v, %v; want false", name, ok, err)
+		}
+	}
+
+	// A signature naming the expected signer but made by another key.
+	forged := signMessage(t, newKey(t), key.PubKey(), message)
+	copy(forged[len(signedMessageVersion):], signer.PubKey().Compressed())
+	if ok, err := ws.VerifyExternalSignature(ctx, message, forged, signer.PubKey(), "example.com"); err != nil || ok {
+		t.Errorf("forged signer gave %v, %v; want false", ok, err)
+	}
+
+	if _, err := ws.VerifyExternalSignature(ctx, message, signMessage(t, signer, newKey(t).PubKey(), message), signer.PubKey(), "example.com"); err == nil {
+		t.Error("expected a signature for another wallet to be an error")
+	}
+	if _, err := ws.VerifyExternalSignature(ctx, message, []byte{1, 2, 3}, signer.PubKey(), "example.com"); !errors.Is(err, ErrMalformedSignature) {
+		t.Errorf("garbage signature: err = %v, want ErrMalformedSignature", err)
+	}
+
+	// And through the dispatcher, as an app would.
+	args, _ := json.Marshal(VerifyExternalSignatureArgs{Message: message, Signature: signMessage(t, signer, nil, message), SignerIdentityKey: signer.PubKey().ToDERHex()})
+	if out, err := ws.CallWalletMethod("verifyExternalSignature", string(args), "example.com"); err != nil || out != `{"valid":true}` {
+		t.Errorf("verifyExternalSignature = %s, %v", out, err)
+	}
+	if _, err := ws.CallWalletMethod("verifyExternalSignature", `{"message":[1],"signature":[1],"signerIdentityKey":"zz"}`, "example.com"); err == nil {
+		t.Error("expected a bad signer identity key to be rejected")
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// WithSlowOpThreshold logs a warning for every wallet method call that
+// takes longer than d. Time spent waiting on a permission prompt is not
+// counted. Zero, the default, disables the warning.
+func WithSlowOpThreshold(d time.Duration) WalletServiceOption {
+	return func(ws *WalletService) {
+		ws.slowOpThreshold = d
+	}
+}
+
+// timedGate forwards to a PermissionGate and adds up how long it blocks,
+// so prompts can be left out of a call's duration. It also pauses the
+// call's operation timer, if any, while it blocks.
+type timedGate struct {
+	gate    PermissionGate
+	waiting atomic.Int64 // nanoseconds
+	timer   *operationTimer
+}
+
+func (g *timedGate) RequestPermission(req PermissionRequest) (bool, error) {
+	if g.timer != nil {
+		g.timer.pause()
+		defer g.timer.resume()
+	}
+	start := time.Now()
+	defer func() { g.waiting.Add(int64(time.Since(start))) }()
+	return g.gate.RequestPermission(req)
+}
+
+// logSlowOp warns when a call to method, less the time spent waiting on
+// gate, ran past the configured threshold.
+func (ws *WalletService) logSlowOp(method, origin string, start time.Time, gate *timedGate) {
+	if ws.slowOpThreshold <= 0 {
+		return
+	}
+	duration := time.Since(start)
+	if gate != nil {
+		duration -= time.Duration(gate.waiting.Load())
+	}
+	if duration > ws.slowOpThreshold {
+		ws.logger.Warn("Slow wallet operation", "method", method, "origin", origin,
+			"duration", duration, "threshold", ws.slowOpThreshold)
+	}
+}
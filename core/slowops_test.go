@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlowWalletOperationIsLogged(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("NO_PROXY", "")
+
+	// A proxy standing in for a slow overlay: every lookup takes 300ms.
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	for _, tc := range []struct {
+		threshold time.Duration
+		wantWarn  bool
+	}{
+		{100 * time.Millisecond, true},
+		{time.Minute, false},
+		{0, false},
+	} {
+		ws := NewWalletService(WithHTTPProxy(proxyURL), WithSlowOpThreshold(tc.threshold))
+		var logs bytes.Buffer
+		ws.logger = slog.New(slog.NewTextHandler(&logs, nil))
+		if err := ws.InitializeWallet(newKey(t).Hex(), "test"); err != nil {
+			t.Fatalf("InitializeWallet: %v", err)
+		}
+
+		args := `{"identityKey":"` + newKey(t).PubKey().ToDERHex() + `"}`
+		ws.CallWalletMethod("discoverByIdentityKey", args, "example.com")
+		ws.ShutdownWallet()
+
+		warned := strings.Contains(logs.String(), `msg="Slow wallet operation" method=discoverByIdentityKey`)
+		if warned != tc.wantWarn {
+			t.Errorf("threshold %v: slow-op warning logged = %v, want %v\n%s", tc.threshold, warned, tc.wantWarn, logs.String())
+		}
+	}
+}
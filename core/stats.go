@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+)
+
+// statsPageSize is the largest page the list methods return.
+const statsPageSize = 10000
+
+// defaultBasket holds the outputs that make up the wallet's balance.
+const defaultBasket = "default"
+
+// walletLister is the part of the wallet the statistics are read from.
+// *wallet.Wallet implements it.
+type walletLister interface {
+	ListOutputs(ctx context.Context, args sdk.ListOutputsArgs, originator string) (*sdk.ListOutputsResult, error)
+	ListActions(ctx context.Context, args sdk.ListActionsArgs, originator string) (*sdk.ListActionsResult, error)
+	ListCertificates(ctx context.Context, args sdk.ListCertificatesArgs, originator string) (*sdk.ListCertificatesResult, error)
+}
+
+// WalletStats summarises the wallet for dashboards.
+type WalletStats struct {
+	// Balance is the total of the spendable outputs, in satoshis.
+	Balance          uint64 `json:"balance"`
+	SpendableOutputs int    `json:"spendableOutputs"`
+	Certificates     int    `json:"certificates"`
+	// ActionsByStatus counts actions by their status, e.g. "completed".
+	ActionsByStatus map[string]int `json:"actionsByStatus"`
+}
+
+// Stats returns aggregate statistics for the wallet.
+func (ws *WalletService) Stats(ctx context.Context, originator string) (*WalletStats, error) {
+	ws.mu.RLock()
+	w := ws.wallet
+	ws.mu.RUnlock()
+
+	if w == nil {
+		return nil, errWalletNotInitialized
+	}
+	ctx, cancel := ws.withOperationTimeout(ctx)
+	defer cancel()
+	return walletStats(ctx, w, originator)
+}
+
+// walletStats gathers the statistics from w, paging through outputs and
+// actions at the largest page size.
+func walletStats(ctx context.Context, w walletLister, originator string) (*WalletStats, error) {
+	balance, err := walletBalance(ctx, w, BalanceArgs{}, originator)
+	if err != nil {
+		return nil, err
+	}
+
+	certs, err := w.ListCertificates(ctx, sdk.ListCertificatesArgs{}, originator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count certificates: %w", err)
+	}
+
+	byStatus := make(map[string]int)
+	limit := uint32(statsPageSize)
+	for offset := uint32(0); ; {
+		page, err := w.ListActions(ctx, sdk.ListActionsArgs{Limit: &limit, Offset: &offset}, originator)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list actions: %w", err)
+		}
+		for _, a := range page.Actions {
+			byStatus[string(a.Status)]++
+		}
+		offset += uint32(len(page.Actions))
+		if len(page.Actions) < statsPageSize || offset >= page.TotalActions {
+			break
+		}
+	}
+
+	return &WalletStats{
+		Balance:          balance.Satoshis,
+		SpendableOutputs: balance.Outputs,
+		Certificates:     int(certs.TotalCertificates),
+		ActionsByStatus:  byStatus,
+	}, nil
+}
+
+// BalanceArgs selects the outputs GetBalance adds up.
+type BalanceArgs struct {
+	// Basket to count; empty means the default basket.
+	Basket string `json:"basket,omitempty"`
+}
+
+// BalanceResult is the value of a basket's spendable outputs.
+type BalanceResult struct {
+	Satoshis uint64 `json:"satoshis"`
+	Outputs  int    `json:"outputs"`
+}
+
+// GetBalance returns the total value and number of spendable outputs in
+// args.Basket.
+func (ws *WalletService) GetBalance(ctx context.Context, args BalanceArgs, originator string) (*BalanceResult, error) {
+	ws.mu.RLock()
+	w := ws.wallet
+	ws.mu.RUnlock()
+
+	if w == nil {
+		return nil, errWalletNotInitialized
+	}
+	ctx, cancel := ws.withOperationTimeout(ctx)
+	defer cancel()
+	return walletBalance(ctx, w, args, originator)
+}
+
+// walletBalance adds up the spendable outputs of a basket, visiting them
+// with iterateOutputs so wallets with many outputs are counted in full.
+func walletBalance(ctx context.Context, w walletLister, args BalanceArgs, originator string) (*BalanceResult, error) {
+	basket := args.Basket
+	if basket == "" {
+		basket = defaultBasket
+	}
+	var balance BalanceResult
+	err := iterateOutputs(ctx, w, sdk.ListOutputsArgs{Basket: basket}, originator, func(out sdk.Output) bool {
+		if out.Spendable {
+			balance.Satoshis += out.Satoshis
+			balance.Outputs++
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &balance, nil
+}
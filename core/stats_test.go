@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+)
+
+// fakeLister serves canned outputs, actions and certificates in pages the
+// way storage does.
+type fakeLister struct {
+	outputs []sdk.Output
+	actions []sdk.Action
+	certs   int
+	calls   int
+	baskets []string
+}
+
+func page[T any](items []T, limit, offset *uint32) []T {
+	start := min(int(*offset), len(items))
+	end := min(start+int(*limit), len(items))
+	return items[start:end]
+}
+
+func (f *fakeLister) ListOutputs(_ context.Context, args sdk.ListOutputsArgs, _ string) (*sdk.ListOutputsResult, error) {
+	f.calls++
+	f.baskets = append(f.baskets, args.Basket)
+	return &sdk.ListOutputsResult{TotalOutputs: uint32(len(f.outputs)), Outputs: page(f.outputs, args.Limit, args.Offset)}, nil
+}
+
+func (f *fakeLister) ListActions(_ context.Context, args sdk.ListActionsArgs, _ string) (*sdk.ListActionsResult, error) {
+	f.calls++
+	return &sdk.ListActionsResult{TotalActions: uint32(len(f.actions)), Actions: page(f.actions, args.Limit, args.Offset)}, nil
+}
+
+func (f *fakeLister) ListCertificates(context.Context, sdk.ListCertificatesArgs, string) (*sdk.ListCertificatesResult, error) {
+	f.calls++
+	return &sdk.ListCertificatesResult{TotalCertificates: uint32(f.certs)}, nil
+}
+
+func TestWalletStatsAggregates(t *testing.T) {
+	f := &fakeLister{certs: 3}
+	// More outputs than fit on one page, so the balance spans two.
+	for i := 0; i < outputsPageSize+5; i++ {
+		f.outputs = append(f.outputs, sdk.Output{Satoshis: 10, Spendable: i%2 == 0})
+	}
+	for _, status := range []sdk.ActionStatus{
+		sdk.ActionStatusCompleted, sdk.ActionStatusCompleted, sdk.ActionStatusUnproven, sdk.ActionStatusNoSend,
+	} {
+		f.actions = append(f.actions, sdk.Action{Status: status})
+	}
+
+	stats, err := walletStats(context.Background(), f, "example.com")
+	if err != nil {
+		t.Fatalf("walletStats: %v", err)
+	}
+
+	wantOutputs := (outputsPageSize + 5 + 1) / 2
+	if stats.SpendableOutputs != wantOutputs || stats.Balance != uint64(wantOutputs*10) {
+		t.Errorf("got %d outputs worth %d, want %d worth %d", stats.SpendableOutputs, stats.Balance, wantOutputs, wantOutputs*10)
+	}
+	if stats.Certificates != 3 {
+		t.Errorf("certificates = %d, want 3", stats.Certificates)
+	}
+	got, _ := json.Marshal(stats.ActionsByStatus)
+	if want := `{"completed":2,"nosend":1,"unproven":1}`; string(got) != want {
+		t.Errorf("actions by status = %s, want %s", got, want)
+	}
+	// Two pages of outputs, one of actions, one certificate count.
+	if f.calls != 4 {
+		t.Errorf("made %d storage calls, want 4", f.calls)
+	}
+}
+
+func TestGetBalance(t *testing.T) {
+	f := &fakeLister{}
+	for i := 0; i < 2*outputsPageSize+1; i++ {
+		f.outputs = append(f.outputs, sdk.Output{Satoshis: 7, Spendable: i != 0})
+	}
+	balance, err := walletBalance(context.Background(), f, BalanceArgs{Basket: "tokens"}, "example.com")
+	if err != nil {
+		t.Fatalf("walletBalance: %v", err)
+	}
+	if want := 2 * outputsPageSize; balance.Outputs != want || balance.Satoshis != uint64(7*want) {
+		t.Errorf("balance = %+v, want %d outputs worth %d", balance, want, 7*want)
+	}
+	if got := strings.Join(f.baskets, ","); got != "tokens,tokens,tokens" {
+		t.Errorf("listed baskets %s, want three pages of tokens", got)
+	}
+
+	f.baskets = nil
+	walletBalance(context.Background(), f, BalanceArgs{}, "example.com")
+	if len(f.baskets) == 0 || f.baskets[0] != defaultBasket {
+		t.Errorf("listed baskets %q, want the default basket", f.baskets)
+	}
+
+	// Originators are checked as for any other call.
+	t.Setenv("HOME", t.TempDir())
+	ws := NewWalletService()
+	if err := ws.InitializeWallet(newKey(t).Hex(), "test"); err != nil {
+		t.Fatalf("InitializeWallet: %v", err)
+	}
+	defer ws.ShutdownWallet()
+	if _, err := ws.GetBalance(context.Background(), BalanceArgs{}, "bad..example.com"); err == nil || !strings.Contains(err.Error(), "originator") {
+		t.Errorf("bad originator: err = %v, want an originator error", err)
+	}
+	out, err := ws.CallWalletMethod("getBalance", "", "example.com")
+	if err != nil || out != `{"satoshis":0,"outputs":0}` {
+		t.Errorf("getBalance on an empty wallet = %s, %v", out, err)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/defs"
+)
+
+// StorageEncryption prepares the wallet database configuration for at-rest
+// encryption under passphrase, for example by pointing it at an encrypting
+// SQLite build and keying the connection. It is called once per wallet
+// opened, before the database is, and must not keep passphrase, which is
+// wiped as soon as it returns.
+//
+// The passphrase is the one that unlocked the key file, so the database is
+// no more exposed than the key: someone holding a copy of the disk needs it
+// for either. It does not protect a running wallet, whose process holds
+// both the key and the open database, and whatever the configuration keeps
+// of it, such as a keyed connection string, lives as long as the storage.
+type StorageEncryption func(cfg *defs.Database, passphrase []byte) error
+
+// errStoragePassphraseRequired is returned when storage encryption is
+// configured but the key came without a passphrase to encrypt under.
+var errStoragePassphraseRequired = errors.New("storage encryption needs the passphrase of an encrypted key file")
+
+// WithStorageEncryption encrypts the wallet database under the passphrase
+// of the encrypted key file, passed in with InitializeWalletWithPassphrase
+// or ReinitializeWalletWithPassphrase. Wallets opened without one fail.
+func WithStorageEncryption(enc StorageEncryption) WalletServiceOption {
+	return func(ws *WalletService) {
+		ws.storageEncryption = enc
+	}
+}
+
+// InitializeWalletWithPassphrase is InitializeWallet for a key from an
+// encrypted key file. passphrase goes to the storage encryption, if one is
+// configured, and is wiped before it returns.
+func (ws *WalletService) InitializeWalletWithPassphrase(privateKeyHex, chain string, passphrase []byte) error {
+	defer clear(passphrase)
+	return ws.initializeWallet(privateKeyHex, chain, passphrase)
+}
+
+// ReinitializeWalletWithPassphrase is ReinitializeWallet for a key from an
+// encrypted key file, treating passphrase as InitializeWalletWithPassphrase
+// does.
+func (ws *WalletService) ReinitializeWalletWithPassphrase(privateKeyHex, chain string, passphrase []byte) error {
+	defer clear(passphrase)
+	return ws.reinitializeWallet(privateKeyHex, chain, passphrase)
+}
+
+// encryptStorage applies the storage encryption, if any, to cfg.
+func (ws *WalletService) encryptStorage(cfg *defs.Database, passphrase []byte) error {
+	if ws.storageEncryption == nil {
+		return nil
+	}
+	if len(passphrase) == 0 {
+		return errStoragePassphraseRequired
+	}
+	return ws.storageEncryption(cfg, passphrase)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/defs"
+)
+
+func TestStorageEncryptionGetsKeyFilePassphrase(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GEBUNDEN_PRIVATE_KEY", "")
+	const secret = "correct horse battery staple"
+	rootKey := newKey(t).Hex()
+	identity, err := encryptIdentity(rootKey, "02ab", "testnet", []byte(secret))
+	if err != nil {
+		t.Fatalf("encryptIdentity: %v", err)
+	}
+	t.Setenv("GEBUNDEN_KEY_PASSPHRASE", secret)
+	privateKey, network, passphrase, err := loadPrivateKey(writeIdentity(t, identity))
+	if err != nil {
+		t.Fatalf("loadPrivateKey: %v", err)
+	}
+	if string(passphrase) != secret {
+		t.Fatalf("loadPrivateKey passphrase = %q, want the key file's", passphrase)
+	}
+
+	var got []byte
+	var dbPath string
+	var logs bytes.Buffer
+	ws := NewWalletService(WithStorageEncryption(func(cfg *defs.Database, p []byte) error {
+		got = bytes.Clone(p)
+		dbPath = cfg.SQLite.ConnectionString
+		return nil
+	}))
+	ws.logger = slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	if err := ws.InitializeWalletWithPassphrase(privateKey, network, passphrase); err != nil {
+		t.Fatalf("InitializeWalletWithPassphrase: %v", err)
+	}
+	defer ws.ShutdownWallet()
+
+	if string(got) != secret || dbPath == "" {
+		t.Fatalf("storage encryption got passphrase %q for %q, want the key file's for the wallet database", got, dbPath)
+	}
+	if !bytes.Equal(passphrase, make([]byte, len(secret))) {
+		t.Error("passphrase was not wiped after the wallet was opened")
+	}
+	if bytes.Contains(logs.Bytes(), []byte(secret)) {
+		t.Error("passphrase appears in the logs")
+	}
+
+	// Without a passphrase there is nothing to encrypt under.
+	ws = NewWalletService(WithStorageEncryption(func(*defs.Database, []byte) error { return nil }))
+	if err := ws.InitializeWallet(privateKey, network); !errors.Is(err, errStoragePassphraseRequired) {
+		t.Fatalf("InitializeWallet without a passphrase: err = %v, want errStoragePassphraseRequired", err)
+	}
+}
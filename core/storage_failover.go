@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"syscall"
+
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/wallet"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/wdk"
+)
+
+// StorageProviderFactory builds a storage backend for a wallet. It is
+// given the wallet, which remote storage clients authenticate with, and
+// returns a cleanup function releasing the backend.
+type StorageProviderFactory = wallet.StorageProviderFactoryWithWalletReturningCleanupAndError
+
+// WithFallbackStorage adds storage backends that reads fall back to, in
+// order, when the wallet's own storage fails with a transient error.
+// Writes only ever go to the wallet's own storage.
+func WithFallbackStorage(factories ...StorageProviderFactory) WalletServiceOption {
+	return func(ws *WalletService) {
+		ws.fallbackStorage = append(ws.fallbackStorage, factories...)
+	}
+}
+
+// failoverStorage is a storage provider whose reads are retried against
+// fallback backends when the primary fails transiently. Everything else
+// goes to the primary alone.
+type failoverStorage struct {
+	wdk.WalletStorageProvider
+	fallbacks []wdk.WalletStorageProvider
+	logger    *slog.Logger
+}
+
+// newFailoverStorage builds the fallbacks from factories for w and wraps
+// primary with them. The returned function releases the fallbacks.
+func newFailoverStorage(w sdk.Interface, primary wdk.WalletStorageProvider, factories []StorageProviderFactory, logger *slog.Logger) (*failoverStorage, func(), error) {
+	f := &failoverStorage{WalletStorageProvider: primary, logger: logger}
+	var cleanups []func()
+	cleanup := func() {
+		for _, c := range cleanups {
+			c()
+		}
+	}
+	for i, factory := range factories {
+		fallback, c, err := factory(w)
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("failed to create fallback storage %d: %w", i+1, err)
+		}
+		f.fallbacks = append(f.fallbacks, fallback)
+		if c != nil {
+			cleanups = append(cleanups, c)
+		}
+	}
+	return f, cleanup, nil
+}
+
+// readWithFailover runs read against the primary and, while it fails
+// transiently, against each fallback in turn.
+func readWithFailover[T any](ctx context.Context, f *failoverStorage, method string, read func(wdk.WalletStorageProvider) (T, error)) (T, error) {
+	result, err := read(f.WalletStorageProvider)
+	if err == nil || !isTransientStorageError(ctx, err) {
+		f.logger.Debug("Storage read served", "method", method, "backend", "primary")
+		return result, err
+	}
+	for i, fallback := range f.fallbacks {
+		f.logger.Debug("Storage read failed, trying fallback", "method", method, "fallback", i+1, "error", err)
+		var fallbackErr error
+		if result, fallbackErr = read(fallback); fallbackErr == nil {
+			f.logger.Debug("Storage read served", "method", method, "backend", fmt.Sprintf("fallback %d", i+1))
+			return result, nil
+		}
+		if !isTransientStorageError(ctx, fallbackErr) {
+			return result, fallbackErr
+		}
+	}
+	return result, err
+}
+
+// isTransientStorageError reports whether err is a failure worth retrying
+// elsewhere: the backend was unreachable, timed out or busy. Errors after
+// the caller's own context is done are not.
+func isTransientStorageError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	// Remote storage and SQLite report these only as text.
+	msg := strings.ToLower(err.Error())
+	for _, transient := range []string{"connection refused", "connection reset", "database is locked", "timeout"} {
+		if strings.Contains(msg, transient) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *failoverStorage) ListCertificates(ctx context.Context, auth wdk.AuthID, args wdk.ListCertificatesArgs) (*wdk.ListCertificatesResult, error) {
+	return readWithFailover(ctx, f, "ListCertificates", func(s wdk.WalletStorageProvider) (*wdk.ListCertificatesResult, error) {
+		return s.ListCertificates(ctx, auth, args)
+	})
+}
+
+func (f *failoverStorage) ListOutputs(ctx context.Context, auth wdk.AuthID, args wdk.ListOutputsArgs) (*wdk.ListOutputsResult, error) {
+	return readWithFailover(ctx, f, "ListOutputs", func(s wdk.WalletStorageProvider) (*wdk.ListOutputsResult, error) {
+		return s.ListOutputs(ctx, auth, args)
+	})
+}
+
+func (f *failoverStorage) ListActions(ctx context.Context, auth wdk.AuthID, args wdk.ListActionsArgs) (*wdk.ListActionsResult, error) {
+	return readWithFailover(ctx, f, "ListActions", func(s wdk.WalletStorageProvider) (*wdk.ListActionsResult, error) {
+		return s.ListActions(ctx, auth, args)
+	})
+}
+
+func (f *failoverStorage) FindOutputBasketsAuth(ctx context.Context, auth wdk.AuthID, filters wdk.FindOutputBasketsArgs) (wdk.TableOutputBaskets, error) {
+	return readWithFailover(ctx, f, "FindOutputBasketsAuth", func(s wdk.WalletStorageProvider) (wdk.TableOutputBaskets, error) {
+		return s.FindOutputBasketsAuth(ctx, auth, filters)
+	})
+}
+
+func (f *failoverStorage) FindOutputsAuth(ctx context.Context, auth wdk.AuthID, filters wdk.FindOutputsArgs) (wdk.TableOutputs, error) {
+	return readWithFailover(ctx, f, "FindOutputsAuth", func(s wdk.WalletStorageProvider) (wdk.TableOutputs, error) {
+		return s.FindOutputsAuth(ctx, auth, filters)
+	})
+}
+
+func (f *failoverStorage) ListTransactions(ctx context.Context, auth wdk.AuthID, args wdk.ListTransactionsArgs) (*wdk.ListTransactionsResult, error) {
+	return readWithFailover(ctx, f, "ListTransactions", func(s wdk.WalletStorageProvider) (*wdk.ListTransactionsResult, error) {
+		return s.ListTransactions(ctx, auth, args)
+	})
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/wdk"
+)
+
+// fakeStorage answers ListActions and CreateAction with err, if set, and
+// counts its calls. Other methods are left to the nil embedded provider.
+type fakeStorage struct {
+	wdk.WalletStorageProvider
+	err     error
+	lists   int
+	creates int
+}
+
+func (s *fakeStorage) ListActions(context.Context, wdk.AuthID, wdk.ListActionsArgs) (*wdk.ListActionsResult, error) {
+	s.lists++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &wdk.ListActionsResult{}, nil
+}
+
+func (s *fakeStorage) CreateAction(context.Context, wdk.AuthID, wdk.ValidCreateActionArgs) (*wdk.StorageCreateActionResult, error) {
+	s.creates++
+	return nil, s.err
+}
+
+func TestFailoverStorageRetriesTransientReads(t *testing.T) {
+	ctx := context.Background()
+	var logs strings.Builder
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	primary := &fakeStorage{err: fmt.Errorf("list actions: %w", syscall.ECONNREFUSED)}
+	down := &fakeStorage{err: errors.New("dial tcp: i/o timeout")}
+	up := &fakeStorage{}
+	f := &failoverStorage{WalletStorageProvider: primary, fallbacks: []wdk.WalletStorageProvider{down, up}, logger: logger}
+
+	if _, err := f.ListActions(ctx, wdk.AuthID{}, wdk.ListActionsArgs{}); err != nil {
+		t.Fatalf("ListActions: %v", err)
+	}
+	if primary.lists != 1 || down.lists != 1 || up.lists != 1 {
+		t.Fatalf("calls = %d, %d, %d; want each backend tried once", primary.lists, down.lists, up.lists)
+	}
+	if !strings.Contains(logs.String(), `backend="fallback 2"`) {
+		t.Fatalf("log does not name the serving backend:\n%s", logs.String())
+	}
+
+	// Writes never fail over.
+	if _, err := f.CreateAction(ctx, wdk.AuthID{}, wdk.ValidCreateActionArgs{}); !errors.Is(err, syscall.ECONNREFUSED) {
+		t.Fatalf("CreateAction: err = %v, want the primary's error", err)
+	}
+	if primary.creates != 1 || down.creates != 0 || up.creates != 0 {
+		t.Fatal("a write went to a fallback")
+	}
+
+	// Nor do reads the primary rejects for good.
+	primary.err = errors.New("invalid list actions args")
+	if _, err := f.ListActions(ctx, wdk.AuthID{}, wdk.ListActionsArgs{}); err == nil || up.lists != 1 {
+		t.Fatalf("ListActions with a permanent error: err = %v after %d fallback calls", err, up.lists)
+	}
+}
+
+func TestFallbackStorageBuiltWithWallet(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	fallback := &fakeStorage{}
+	var built int
+	released := make(chan struct{})
+	var builtFor sdk.Interface
+	ws := NewWalletService(WithFallbackStorage(func(w sdk.Interface) (wdk.WalletStorageProvider, func(), error) {
+		built++
+		builtFor = w
+		return fallback, func() { close(released) }, nil
+	}))
+	if err := ws.InitializeWallet(newKey(t).Hex(), "test"); err != nil {
+		t.Fatalf("InitializeWallet: %v", err)
+	}
+	if built != 1 || builtFor == nil {
+		t.Fatalf("fallback built %d times", built)
+	}
+	if _, err := ws.CallWalletMethod("listActions", `{"labels":[]}`, "example.com"); err != nil {
+		t.Fatalf("listActions: %v", err)
+	}
+	if fallback.lists != 0 {
+		t.Fatal("healthy primary storage failed over")
+	}
+
+	if err := ws.InvalidateDiscoverCache(); err != nil {
+		t.Fatal(err)
+	}
+	if built != 1 {
+		t.Fatalf("rebuilding the wallet built the fallback again (%d)", built)
+	}
+	ws.ShutdownWallet()
+	select {
+	case <-released:
+	case <-time.After(5 * time.Second):
+		t.Fatal("fallback not released on shutdown")
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// maxTransactionSize is the largest transaction, in bytes, that nodes
+// relay under their default policy (maxtxsizepolicy).
+const maxTransactionSize = 10_000_000
+
+// Fixed parts of a serialized transaction: version and lock time, each
+// input's outpoint and sequence number, and each output's value.
+const (
+	txOverheadSize = 4 + 4
+	txInputSize    = 32 + 4 + 4
+	txOutputSize   = 8
+)
+
+// ErrTransactionTooLarge is matched by every *TransactionTooLargeError.
+var ErrTransactionTooLarge = errors.New("transaction too large")
+
+// TransactionTooLargeError reports createAction args whose transaction
+// would exceed the size limit before any work is done to build it.
+type TransactionTooLargeError struct {
+	Estimate int // estimated size in bytes, excluding funding inputs and change
+	Limit    int
+}
+
+func (e *TransactionTooLargeError) Error() string {
+	return fmt.Sprintf("transaction too large: at least %d bytes, limit is %d", e.Estimate, e.Limit)
+}
+
+func (e *TransactionTooLargeError) Unwrap() error { return ErrTransactionTooLarge }
+
+// estimateTransactionSize returns a lower bound on the serialized size of
+// the transaction args describe. Inputs the wallet adds to fund it and its
+// change outputs are not known yet, so they are not counted.
+func estimateTransactionSize(args SDKCreateActionArgs) int {
+	size := txOverheadSize + varIntSize(len(args.Inputs)) + varIntSize(len(args.Outputs))
+	for _, in := range args.Inputs {
+		script := len(in.UnlockingScript)
+		if script == 0 {
+			script = int(in.UnlockingScriptLength)
+		}
+		size += txInputSize + varIntSize(script) + script
+	}
+	for _, out := range args.Outputs {
+		script := len(out.LockingScript)
+		size += txOutputSize + varIntSize(script) + script
+	}
+	return size
+}
+
+// checkTransactionSize rejects args whose transaction cannot fit under
+// maxTransactionSize.
+func checkTransactionSize(args SDKCreateActionArgs) error {
+	if estimate := estimateTransactionSize(args); estimate > maxTransactionSize {
+		return &TransactionTooLargeError{Estimate: estimate, Limit: maxTransactionSize}
+	}
+	return nil
+}
+
+// varIntSize is the length of n encoded as a Bitcoin variable-length integer.
+func varIntSize(n int) int {
+	switch {
+	case n < 0xfd:
+		return 1
+	case n <= 0xffff:
+		return 3
+	case n <= 0xffffffff:
+		return 5
+	default:
+		return 9
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+)
+
+// countingGate records whether a permission prompt was raised.
+type countingGate struct{ calls int }
+
+func (g *countingGate) RequestPermission(PermissionRequest) (bool, error) {
+	g.calls++
+	return true, nil
+}
+
+func TestOversizedCreateActionRejectedEarly(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	gate := &countingGate{}
+	ws := NewWalletService()
+	ws.SetPermissionGate(gate)
+	if err := ws.InitializeWallet(newKey(t).Hex(), "test"); err != nil {
+		t.Fatalf("InitializeWallet: %v", err)
+	}
+	defer ws.ShutdownWallet()
+
+	args := sdk.CreateActionArgs{
+		Description: "oversized data push",
+		Outputs: []sdk.CreateActionOutput{
+			{LockingScript: make([]byte, 6_000_000), Satoshis: 1, OutputDescription: "data"},
+			{LockingScript: make([]byte, 6_000_000), Satoshis: 1, OutputDescription: "data"},
+		},
+	}
+	argsJSON, _ := json.Marshal(args)
+
+	_, err := ws.CallWalletMethod("createAction", string(argsJSON), "example.com")
+	if !errors.Is(err, ErrTransactionTooLarge) {
+		t.Fatalf("err = %v, want ErrTransactionTooLarge", err)
+	}
+	var tooLarge *TransactionTooLargeError
+	if !errors.As(err, &tooLarge) || tooLarge.Limit != maxTransactionSize || tooLarge.Estimate <= 12_000_000 {
+		t.Fatalf("error details = %+v, want an estimate over 12 MB against the policy limit", tooLarge)
+	}
+	if gate.calls != 0 {
+		t.Fatal("oversized transaction reached the permission prompt")
+	}
+}
+
+func TestEstimateTransactionSize(t *testing.T) {
+	args := SDKCreateActionArgs{
+		Inputs:  []sdk.CreateActionInput{{UnlockingScriptLength: 107}},
+		Outputs: []sdk.CreateActionOutput{{LockingScript: make([]byte, 25)}},
+	}
+	// A one-input P2PKH-sized spend to one P2PKH output: 8 overhead, 2
+	// counts, 40+1+107 for the input and 8+1+25 for the output.
+	if got := estimateTransactionSize(args); got != 192 {
+		t.Fatalf("estimate = %d, want 192", got)
+	}
+	if err := checkTransactionSize(args); err != nil {
+		t.Fatalf("small transaction rejected: %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/storage"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/wallet"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/wdk"
+)
+
+// permanentlyFailedLabel is added to failed actions that have used up
+// their retries, telling them apart in failed-action listings.
+const permanentlyFailedLabel = "permanently-failed"
+
+// unfailRetriesKeyPrefix prefixes the storage key-value entries counting
+// how many times each failed action was queued to be retried.
+const unfailRetriesKeyPrefix = "gebunden.unfailRetries."
+
+// WithMaxUnfailRetries caps how many times listing failed actions with
+// "unfail" queues the same action to be retried. Actions past the cap are
+// left failed and labelled permanently-failed. Zero, the default, retries
+// without limit.
+func WithMaxUnfailRetries(n int) WalletServiceOption {
+	return func(ws *WalletService) {
+		ws.maxUnfailRetries = n
+	}
+}
+
+// isUnfailRequest reports whether listActions labels ask to list failed
+// actions and queue them to be retried.
+func isUnfailRequest(labels []string) bool {
+	return slices.Contains(labels, failedActionsLabel) && slices.Contains(labels, string(wdk.TxStatusUnfail))
+}
+
+// listFailedActionsCapped lists failed actions like listActions does with
+// "unfail", but queues an action to be retried only while it is under
+// ws.maxUnfailRetries retries. Actions already queued are not counted
+// again until the monitor has rechecked them.
+func (ws *WalletService) listFailedActionsCapped(ctx context.Context, w *wallet.Wallet, store *storage.Provider, args sdk.ListActionsArgs, originator string) (*sdk.ListActionsResult, error) {
+	args.Labels = slices.DeleteFunc(slices.Clone(args.Labels), func(label string) bool {
+		return label == string(wdk.TxStatusUnfail)
+	})
+	// Labels tell which actions are already marked permanently failed.
+	includeLabels := args.IncludeLabels != nil && *args.IncludeLabels
+	withLabels := true
+	args.IncludeLabels = &withLabels
+	result, err := w.ListActions(ctx, args, originator)
+	if err != nil {
+		return nil, err
+	}
+
+	txids := make([]string, 0, len(result.Actions))
+	for _, action := range result.Actions {
+		txids = append(txids, action.Txid.String())
+	}
+	repos := store.Database.CreateRepositories()
+	statuses, err := repos.FindKnownTxStatuses(ctx, txids...)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range result.Actions {
+		action := &result.Actions[i]
+		txid := action.Txid.String()
+		if statuses[txid] == wdk.ProvenTxStatusUnfail {
+			continue
+		}
+
+		key := unfailRetriesKeyPrefix + txid
+		retries := 0
+		if value, found, err := repos.Get(ctx, key); err != nil {
+			return nil, err
+		} else if found {
+			retries, _ = strconv.Atoi(string(value))
+		}
+
+		if retries >= ws.maxUnfailRetries {
+			if slices.Contains(action.Labels, permanentlyFailedLabel) {
+				continue
+			}
+			if err := labelAction(ctx, w, store, txid, []string{permanentlyFailedLabel}, originator); err != nil {
+				return nil, fmt.Errorf("failed to mark action %s permanently failed: %w", txid, err)
+			}
+			action.Labels = append(action.Labels, permanentlyFailedLabel)
+			ws.logger.Warn("Failed action is out of retries, marked permanently failed",
+				"txid", txid, "retries", retries)
+			continue
+		}
+
+		if err := repos.Set(ctx, key, []byte(strconv.Itoa(retries+1))); err != nil {
+			return nil, err
+		}
+		if err := repos.UpdateKnownTxStatus(ctx, txid, wdk.ProvenTxStatusUnfail, nil, nil); err != nil {
+			return nil, fmt.Errorf("failed to queue action %s to be retried: %w", txid, err)
+		}
+	}
+
+	if !includeLabels {
+		for i := range result.Actions {
+			result.Actions[i].Labels = nil
+		}
+	}
+	return result, nil
+}
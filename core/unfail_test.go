@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/defs"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/entity"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/wdk"
+)
+
+func TestUnfailStopsAfterMaxRetries(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	rootKey := newKey(t)
+	ws := NewWalletService(WithMaxUnfailRetries(2))
+	if err := ws.InitializeWallet(rootKey.Hex(), "test"); err != nil {
+		t.Fatalf("InitializeWallet: %v", err)
+	}
+	defer ws.ShutdownWallet()
+	ctx := context.Background()
+
+	// A failed action whose broadcast the network rejected.
+	user, err := ws.storage.FindOrInsertUser(ctx, rootKey.PubKey().ToDERHex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := strings.Repeat("cd", 32)
+	err = ws.storage.TransactionEntity().Create(ctx, &entity.Transaction{
+		UserID:      user.User.UserID,
+		Status:      wdk.TxStatusFailed,
+		Reference:   "unfail-test",
+		IsOutgoing:  true,
+		Description: "never mined",
+		TxID:        &txid,
+	})
+	if err != nil {
+		t.Fatalf("create action: %v", err)
+	}
+	knownTxs := ws.storage.Database.DB.Table(defs.DefaultTablePrefix + "known_txes")
+	if err := knownTxs.Create(map[string]any{"tx_id": txid, "status": string(wdk.ProvenTxStatusInvalid)}).Error; err != nil {
+		t.Fatalf("create known tx: %v", err)
+	}
+	status := func() wdk.ProvenTxReqStatus {
+		t.Helper()
+		statuses, err := ws.storage.Database.CreateRepositories().FindKnownTxStatuses(ctx, txid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return statuses[txid]
+	}
+	// recheckFails does what the monitor does when the retried
+	// transaction is still not found.
+	recheckFails := func() {
+		t.Helper()
+		err := ws.storage.Database.CreateRepositories().UpdateKnownTxStatus(ctx, txid, wdk.ProvenTxStatusInvalid, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	unfail := func() []string {
+		t.Helper()
+		out, err := ws.CallWalletMethod("listActions", `{"labels":["`+failedActionsLabel+`","unfail"],"includeLabels":true}`, "example.com")
+		if err != nil {
+			t.Fatalf("listActions: %v", err)
+		}
+		var result struct {
+			Actions []struct {
+				Labels []string `json:"labels"`
+			} `json:"actions"`
+		}
+		json.Unmarshal([]byte(out), &result)
+		if len(result.Actions) != 1 {
+			t.Fatalf("listed %d failed actions, want 1", len(result.Actions))
+		}
+		return result.Actions[0].Labels
+	}
+
+	unfail()
+	if got := status(); got != wdk.ProvenTxStatusUnfail {
+		t.Fatalf("after first unfail status = %s, want unfail", got)
+	}
+	// Asking again before the recheck is not another retry.
+	unfail()
+	recheckFails()
+	unfail()
+	if got := status(); got != wdk.ProvenTxStatusUnfail {
+		t.Fatalf("after second unfail status = %s, want unfail", got)
+	}
+	recheckFails()
+
+	labels := unfail()
+	if got := status(); got != wdk.ProvenTxStatusInvalid {
+		t.Fatalf("out of retries, status = %s, want it left invalid", got)
+	}
+	if !slices.Contains(labels, permanentlyFailedLabel) {
+		t.Fatalf("labels = %v, want %s", labels, permanentlyFailedLabel)
+	}
+	if labels := unfail(); status() != wdk.ProvenTxStatusInvalid || !slices.Contains(labels, permanentlyFailedLabel) {
+		t.Fatalf("listing again retried the action or lost its label: %v", labels)
+	}
+}
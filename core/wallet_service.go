@@ -3,19 +3,27 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	sdktx "github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/bsv-blockchain/go-sdk/overlay/lookup"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/script"
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
 	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/defs"
 	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/monitor"
 	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/services"
 	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/storage"
 	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/wallet"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/wallet/pending"
 	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/wdk"
 )
 
@@ -30,22 +38,94 @@ type WalletService struct {
 	chain          defs.BSVNetwork
 	ctx            context.Context
 	cancel         context.CancelFunc
+	inflight       *sync.WaitGroup
 	permissionGate PermissionGate
+	httpProxy      *url.URL
+	feeModel       defs.FeeModel
+	minFeeRate     int64
+	// slowOpThreshold is how long a call may take before it is logged.
+	slowOpThreshold time.Duration
+	// opTimeout bounds calls that come without a deadline of their own.
+	opTimeout time.Duration
+	// dataProtocol prefixes the payloads of data actions naming none.
+	dataProtocol   string
+	privilegedKeys PrivilegedKeyManager
+	actionObserver ActionObserver
+	// certifierTimeout bounds each request to a certifier; 0 means none.
+	certifierTimeout time.Duration
+	lookupResolver   *lookup.LookupResolver
+	// maxUnfailRetries caps retries of each failed action; 0 means no cap.
+	maxUnfailRetries int
+	newWallet        func() (*wallet.Wallet, error)
+	paymail          *paymailResolver
+	fallbackStorage  []StorageProviderFactory
+	// storageEncryption keys the database with the key file's passphrase.
+	storageEncryption StorageEncryption
+	// discoverMaxAge bounds the age of cached discovery results; the
+	// cache of the current wallet dates from discoverCacheSince.
+	discoverMaxAge     time.Duration
+	discoverCacheSince time.Time
+	now                func() time.Time
+	lookups            *lookupCache
+	// warmingUp holds readiness back until WarmUp has passed.
+	warmingUp     atomic.Bool
+	warmUpTimeout time.Duration
+	warmUpRetry   time.Duration
+	warmUpChecks  []warmUpCheck
+	// internalizeAttempts bounds tries of internalizeAction on transient
+	// storage errors, the first of them retried after internalizeBackoff.
+	internalizeAttempts int
+	internalizeBackoff  time.Duration
+	// receivingMu serializes counting receiving scripts in storage.
+	receivingMu sync.Mutex
 }
 
 // NewWalletService creates a new WalletService
-func NewWalletService() *WalletService {
+func NewWalletService(opts ...WalletServiceOption) *WalletService {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelDebug,
 	}))
-	return &WalletService{
-		logger: logger,
-		chain:  defs.NetworkMainnet,
+	ws := &WalletService{
+		logger:           logger,
+		chain:            defs.NetworkMainnet,
+		feeModel:         defaultFeeModel,
+		certifierTimeout: defaultCertifierTimeout,
+		now:              time.Now,
+		warmUpRetry:      defaultWarmUpRetry,
+		warmUpChecks:     warmUpChecks,
 	}
+	for _, opt := range opts {
+		opt(ws)
+	}
+	ws.paymail = newPaymailResolver(ws.egressClient())
+	return ws
+}
+
+// walletInstance is one initialized wallet with the storage, services and
+// monitor behind it.
+type walletInstance struct {
+	wallet      *wallet.Wallet
+	storage     *storage.Provider
+	monitor     *monitor.Daemon
+	services    *services.WalletServices
+	chain       defs.BSVNetwork
+	identityKey string
+	ctx         context.Context
+	cancel      context.CancelFunc
+	// inflight counts CallWalletMethod calls still using this wallet.
+	inflight *sync.WaitGroup
+	// newWallet builds another wallet over the same storage and services.
+	newWallet func() (*wallet.Wallet, error)
+	// lookups records the overlay answers of every wallet newWallet builds.
+	lookups *lookupCache
 }
 
 // InitializeWallet creates and initializes the wallet with the given private key and chain
 func (ws *WalletService) InitializeWallet(privateKeyHex string, chain string) error {
+	return ws.initializeWallet(privateKeyHex, chain, nil)
+}
+
+func (ws *WalletService) initializeWallet(privateKeyHex, chain string, passphrase []byte) error {
 	ws.mu.Lock()
 	defer ws.mu.Unlock()
 
@@ -54,39 +134,79 @@ func (ws *WalletService) InitializeWallet(privateKeyHex string, chain string) er
 		return nil
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	ws.ctx = ctx
-	ws.cancel = cancel
+	inst, err := ws.openWallet(privateKeyHex, chain, passphrase)
+	if err != nil {
+		return err
+	}
+	ws.install(inst)
+	return nil
+}
+
+// ReinitializeWallet replaces the running wallet with one for a new key.
+// The new wallet is fully built before the swap, so an invalid key leaves
+// the current wallet in place. Calls already in progress finish on the old
+// wallet, which is shut down once they have.
+func (ws *WalletService) ReinitializeWallet(privateKeyHex string, chain string) error {
+	return ws.reinitializeWallet(privateKeyHex, chain, nil)
+}
 
-	network, err := defs.ParseBSVNetworkStr(chain)
+func (ws *WalletService) reinitializeWallet(privateKeyHex, chain string, passphrase []byte) error {
+	inst, err := ws.openWallet(privateKeyHex, chain, passphrase)
 	if err != nil {
-		cancel()
-		return fmt.Errorf("invalid network: %w", err)
+		return err
+	}
+
+	ws.mu.Lock()
+	old := ws.detach()
+	ws.install(inst)
+	ws.mu.Unlock()
+
+	ws.logger.Info("Wallet reloaded", "chain", chain, "identityKey", inst.identityKey)
+	if old != nil {
+		old.inflight.Wait()
+		old.close()
+	}
+	return nil
+}
+
+// storageError marks a wallet that could not be opened because its
+// storage could not be set up.
+type storageError struct{ err error }
+
+func (e *storageError) Error() string { return e.err.Error() }
+func (e *storageError) Unwrap() error { return e.err }
+
+// openWallet builds and starts a wallet without touching the service state.
+// passphrase, if any, is handed to the storage encryption.
+func (ws *WalletService) openWallet(privateKeyHex string, chain string, passphrase []byte) (*walletInstance, error) {
+	walletChain, err := parseChain(chain)
+	if err != nil {
+		return nil, fmt.Errorf("invalid network: %w", err)
+	}
+	network := toolboxNetwork(walletChain)
+
+	identityKey, err := wdk.IdentityKey(privateKeyHex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive identity key: %w", err)
 	}
-	ws.chain = network
 
 	ws.logger.Info("Initializing wallet", "chain", chain)
 
+	ctx, cancel := context.WithCancel(context.Background())
+
 	// Create services
-	activeServices := services.New(ws.logger, defs.DefaultServicesConfig(network))
-	ws.services = activeServices
+	activeServices := services.New(ws.logger, servicesConfig(walletChain))
 
 	// Determine database path
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		cancel()
-		return fmt.Errorf("failed to get home directory: %w", err)
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
 	}
 	dataDir := filepath.Join(homeDir, ".gebunden")
 	if err := os.MkdirAll(dataDir, 0o755); err != nil {
 		cancel()
-		return fmt.Errorf("failed to create data directory: %w", err)
-	}
-
-	identityKey, err := wdk.IdentityKey(privateKeyHex)
-	if err != nil {
-		cancel()
-		return fmt.Errorf("failed to derive identity key: %w", err)
+		return nil, fmt.Errorf("failed to create data directory: %w", &storageError{err})
 	}
 
 	dbPath := filepath.Join(dataDir, fmt.Sprintf("wallet-%s-%s.sqlite", identityKey, chain))
@@ -95,10 +215,14 @@ func (ws *WalletService) InitializeWallet(privateKeyHex string, chain string) er
 	dbConfig := defs.DefaultDBConfig()
 	dbConfig.Engine = defs.DBTypeSQLite
 	dbConfig.SQLite.ConnectionString = dbPath
+	if err := ws.encryptStorage(&dbConfig, passphrase); err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to set up storage encryption: %w", &storageError{err})
+	}
 
 	providerOpts := []storage.ProviderOption{
 		storage.WithDBConfig(dbConfig),
-		storage.WithFeeModel(defs.FeeModel{Type: defs.SatPerKB, Value: 100}),
+		storage.WithFeeModel(ws.effectiveFeeModel()),
 		storage.WithCommission(defs.DefaultCommission()),
 		storage.WithLogger(ws.logger),
 		storage.WithBackgroundBroadcasterContext(ctx),
@@ -107,34 +231,95 @@ func (ws *WalletService) InitializeWallet(privateKeyHex string, chain string) er
 	activeStorage, err := storage.NewGORMProvider(network, activeServices, providerOpts...)
 	if err != nil {
 		cancel()
-		return fmt.Errorf("failed to create storage provider: %w", err)
+		return nil, fmt.Errorf("failed to create storage provider: %w", &storageError{err})
 	}
-	ws.storage = activeStorage
 
 	// Run migrations
 	_, err = activeStorage.Migrate(ctx, "BSV Desktop Wallet", identityKey)
 	if err != nil {
 		cancel()
-		return fmt.Errorf("failed to migrate storage: %w", err)
+		return nil, fmt.Errorf("failed to migrate storage: %w", &storageError{err})
 	}
 
-	// Create wallet
-	w, err := wallet.New(network, privateKeyHex, activeStorage,
+	// Create wallet. Wallets built by newWallet share the actions waiting
+	// for signAction, so replacing one loses none of them.
+	pendingSignActions := pending.NewSignActionLocalRepository(ws.logger, pending.DefaultPendingSignActionsTTL)
+	client := ws.egressClient()
+	if client != nil {
+		ws.logger.Info("Routing wallet HTTP through proxy", "proxy", ws.httpProxy.Redacted())
+	}
+	// The auth client only talks to certifiers, so its transport is
+	// where certifier requests are timed.
+	certifier := &certifierTransport{base: http.DefaultTransport, timeout: ws.certifierTimeout}
+	if client != nil {
+		certifier.base = client.Transport
+	}
+	walletOpts := walletOptions(
 		wallet.WithLogger(ws.logger),
 		wallet.WithServices(activeServices),
+		wallet.WithPendingSignActionsRepository(pendingSignActions),
+		wallet.WithAuthHTTPClient(&http.Client{Transport: certifier}),
 	)
+	resolver := ws.lookupResolver
+	if resolver == nil {
+		resolver = lookupResolver(client, walletChain)
+	}
+	// Overlay answers flushed by the last wallet on this network are
+	// served while fresh, and this wallet's are recorded for the next.
+	lookups := newLookupCache(ws.now, ws.lookupCacheTTL())
+	if path, err := cachesPath(walletChain); err == nil {
+		if n, err := lookups.load(path); err != nil {
+			ws.logger.Warn("Failed to load flushed caches", "error", err)
+		} else if n > 0 {
+			ws.logger.Info("Loaded flushed overlay answers", "count", n)
+		}
+	}
+	walletOpts = append(walletOpts, wallet.WithLookupResolver(cachingResolver(resolver, lookups)))
+	// Fallback storages are built once, with the first wallet, and shared
+	// by the wallets built after it; they are released with the context.
+	var failover *failoverStorage
+	newWallet := func() (*wallet.Wallet, error) {
+		if failover != nil {
+			return wallet.New(network, privateKeyHex, failover, walletOpts...)
+		}
+		if len(ws.fallbackStorage) == 0 {
+			return wallet.New(network, privateKeyHex, activeStorage, walletOpts...)
+		}
+		return wallet.NewWithStorageFactory(network, privateKeyHex, func(w sdk.Interface) (wdk.WalletStorageProvider, error) {
+			f, release, err := newFailoverStorage(w, activeStorage, ws.fallbackStorage, ws.logger)
+			if err != nil {
+				return nil, err
+			}
+			context.AfterFunc(ctx, release)
+			failover = f
+			return f, nil
+		}, walletOpts...)
+	}
+	w, err := newWallet()
 	if err != nil {
 		cancel()
-		return fmt.Errorf("failed to create wallet: %w", err)
+		return nil, fmt.Errorf("failed to create wallet: %w", err)
+	}
+
+	inst := &walletInstance{
+		wallet:      w,
+		storage:     activeStorage,
+		services:    activeServices,
+		chain:       walletChain,
+		identityKey: identityKey,
+		ctx:         ctx,
+		cancel:      cancel,
+		inflight:    &sync.WaitGroup{},
+		newWallet:   newWallet,
+		lookups:     lookups,
 	}
-	ws.wallet = w
 
 	// Start monitor daemon
 	daemon, err := monitor.NewDaemonWithGORMLocker(ctx, ws.logger, activeStorage, activeStorage.Database.DB)
 	if err != nil {
 		ws.logger.Warn("Failed to create monitor daemon", "error", err)
 	} else {
-		ws.monitor = daemon
+		inst.monitor = daemon
 		monitorConfig := defs.DefaultMonitorConfig()
 		if err := daemon.Start(monitorConfig.Tasks.EnabledTasks()); err != nil {
 			ws.logger.Warn("Failed to start monitor", "error", err)
@@ -144,26 +329,75 @@ func (ws *WalletService) InitializeWallet(privateKeyHex string, chain string) er
 	}
 
 	ws.logger.Info("Wallet initialized successfully", "chain", chain)
-	return nil
+	return inst, nil
 }
 
-// ShutdownWallet gracefully shuts down the wallet
-func (ws *WalletService) ShutdownWallet() error {
-	ws.mu.Lock()
-	defer ws.mu.Unlock()
+// walletOptions collects wallet.New options in a slice; the toolbox keeps
+// their type internal, so it cannot be named here.
+func walletOptions[O any](opts ...O) []O { return opts }
+
+// install makes inst the active wallet. The caller holds ws.mu.
+func (ws *WalletService) install(inst *walletInstance) {
+	ws.wallet = inst.wallet
+	ws.storage = inst.storage
+	ws.monitor = inst.monitor
+	ws.services = inst.services
+	ws.chain = inst.chain
+	ws.ctx = inst.ctx
+	ws.cancel = inst.cancel
+	ws.inflight = inst.inflight
+	ws.newWallet = inst.newWallet
+	ws.lookups = inst.lookups
+	ws.discoverCacheSince = ws.now()
+}
 
-	if ws.monitor != nil {
-		_ = ws.monitor.Stop()
-		ws.monitor = nil
+// detach clears the active wallet and returns it, or nil if there is none.
+// The caller holds ws.mu.
+func (ws *WalletService) detach() *walletInstance {
+	if ws.wallet == nil {
+		return nil
+	}
+	old := &walletInstance{
+		wallet:    ws.wallet,
+		storage:   ws.storage,
+		monitor:   ws.monitor,
+		services:  ws.services,
+		chain:     ws.chain,
+		ctx:       ws.ctx,
+		cancel:    ws.cancel,
+		inflight:  ws.inflight,
+		newWallet: ws.newWallet,
+		lookups:   ws.lookups,
 	}
+	ws.wallet, ws.storage, ws.monitor, ws.services = nil, nil, nil, nil
+	ws.ctx, ws.cancel, ws.inflight = nil, nil, nil
+	ws.newWallet, ws.lookups = nil, nil
+	return old
+}
 
-	if ws.wallet != nil {
-		ws.wallet.Close()
-		ws.wallet = nil
+// close stops the monitor, closes the wallet and cancels its context.
+func (inst *walletInstance) close() {
+	if inst.monitor != nil {
+		_ = inst.monitor.Stop()
+	}
+	inst.wallet.Close()
+	if inst.cancel != nil {
+		inst.cancel()
 	}
+}
 
-	if ws.cancel != nil {
-		ws.cancel()
+// ShutdownWallet shuts down the wallet. Calls still in progress are
+// cancelled rather than waited for: their context is the wallet's root
+// context, which is cancelled first.
+func (ws *WalletService) ShutdownWallet() error {
+	ws.mu.Lock()
+	old := ws.detach()
+	ws.mu.Unlock()
+
+	if old != nil {
+		old.cancel()
+		old.inflight.Wait()
+		old.close()
 	}
 
 	ws.logger.Info("Wallet shut down")
@@ -257,18 +491,55 @@ func checkPermission(gate PermissionGate, method, origin string, permType string
 // CallWalletMethod dispatches a wallet method call by name with JSON args and origin.
 // This is the single entry point for both the HTTP server and frontend calls.
 func (ws *WalletService) CallWalletMethod(method string, argsJSON string, origin string) (string, error) {
+	if !knownWalletMethods[method] {
+		return "", fmt.Errorf("unknown wallet method: %s", method)
+	}
+	privileged, err := ws.checkPrivileged(method, argsJSON)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", method, err)
+	}
+
 	ws.mu.RLock()
-	w := ws.wallet
+	w, store := ws.wallet, ws.storage
 	gate := ws.permissionGate
+	inflight := ws.inflight
+	// Calls run under the wallet's root context, so shutting the wallet
+	// down aborts their network and storage work.
+	ctx := ws.ctx
+	if w != nil {
+		// Taken under the lock so a reload cannot close w before this
+		// call is counted.
+		inflight.Add(1)
+	}
 	ws.mu.RUnlock()
 
 	if w == nil {
-		return "", fmt.Errorf("wallet not initialized")
+		return "", errWalletNotInitialized
+	}
+	defer inflight.Done()
+
+	start := time.Now()
+	var timer *operationTimer
+	if _, ok := ctx.Deadline(); !ok && ws.opTimeout > 0 {
+		var release context.CancelFunc
+		ctx, timer, release = newOperationTimer(ctx, ws.opTimeout)
+		defer release()
+	}
+	var timed *timedGate
+	if gate != nil {
+		timed = &timedGate{gate: gate, timer: timer}
+		gate = timed
+	}
+	defer ws.logSlowOp(method, origin, start, timed)
+
+	// Key operations use the privileged keys when the call asks for them.
+	var keys sdk.KeyOperations = w
+	if privileged {
+		keys = ws.privilegedKeys
+		ws.logger.Info("Privileged key operation", "method", method, "origin", origin)
 	}
 
-	ctx := context.Background()
 	var result any
-	var err error
 
 	switch method {
 
@@ -280,41 +551,81 @@ func (ws *WalletService) CallWalletMethod(method string, argsJSON string, origin
 		if e := json.Unmarshal([]byte(argsJSON), &args); e != nil {
 			return "", fmt.Errorf("invalid args: %w", e)
 		}
-		// Calculate total output satoshis for the spend prompt
-		var totalSats uint64
-		for _, o := range args.Outputs {
-			totalSats += o.Satoshis
-		}
-		if args.InputBEEF != nil {
-			if beef, e := sdktx.NewBeefFromBytes(args.InputBEEF); e == nil {
-				for i := range args.Inputs {
-					op := args.Inputs[i].Outpoint
-					beefTx := beef.Transactions[op.Txid]
-					if beefTx == nil || beefTx.Transaction == nil {
-						continue
-					}
-					if int(op.Index) >= len(beefTx.Transaction.Outputs) {
-						continue
-					}
-					totalSats -= beefTx.Transaction.Outputs[op.Index].Satoshis
-				}
-			}
+		result, err = ws.createAction(ctx, w, gate, method, args, origin)
+
+	case "createDataAction":
+		var args CreateDataActionArgs
+		if e := json.Unmarshal([]byte(argsJSON), &args); e != nil {
+			return "", fmt.Errorf("invalid args: %w", e)
+		}
+		createArgs, e := ws.dataActionArgs(args.ProtocolPrefix, args.Data)
+		if e != nil {
+			return "", e
 		}
-		if int64(totalSats) > 0 {
+		result, err = ws.createAction(ctx, w, gate, method, createArgs, origin)
+
+	case "createActions":
+		var args CreateActionsArgs
+		if e := json.Unmarshal([]byte(argsJSON), &args); e != nil {
+			return "", fmt.Errorf("invalid args: %w", e)
+		}
+		// One prompt covers the whole batch.
+		totalSats, e := batchSpendSatoshis(args.Actions)
+		if e != nil {
+			return "", e
+		}
+		if totalSats > 0 {
 			extra := map[string]interface{}{
-				"description": args.Description,
-				"outputCount": len(args.Outputs),
-				"inputCount":  len(args.Inputs),
+				"actionCount": len(args.Actions),
 			}
-			if len(args.Labels) > 0 {
-				extra["labels"] = args.Labels
-			}
-			if err := checkPermission(gate, method, origin, "spend", extra, int64(totalSats),
-				fmt.Sprintf("Create transaction: %s (%d sats)", args.Description, totalSats)); err != nil {
+			if err := checkPermission(gate, method, origin, "spend", extra, totalSats,
+				fmt.Sprintf("Create %d transactions (%d sats)", len(args.Actions), totalSats)); err != nil {
 				return "", err
 			}
 		}
-		result, err = w.CreateAction(ctx, args, origin)
+		results, e := ws.createActions(ctx, w, args.Actions, origin)
+		var batchErr *CreateActionsError
+		if e != nil && !errors.As(e, &batchErr) {
+			return "", e
+		}
+		batch := CreateActionsResult{Results: make([]CreateActionsItem, len(results))}
+		for i, r := range results {
+			batch.Results[i].Result = r
+			if batchErr != nil && batchErr.Errs[i] != nil {
+				batch.Results[i].Error = batchErr.Errs[i].Error()
+			}
+		}
+		result = batch
+
+	case "payURI":
+		var args PayURIArgs
+		if e := json.Unmarshal([]byte(argsJSON), &args); e != nil {
+			return "", fmt.Errorf("invalid args: %w", e)
+		}
+		req, e := parsePaymentURI(args.URI, defs.BSVNetwork(ws.GetNetwork()))
+		if e != nil {
+			return "", e
+		}
+		createArgs, e := req.createActionArgs()
+		if e != nil {
+			return "", e
+		}
+		extra := map[string]interface{}{
+			"description": createArgs.Description,
+			"address":     req.Address.AddressString,
+		}
+		if req.Message != "" {
+			extra["message"] = req.Message
+		}
+		if err := checkPermission(gate, method, origin, "spend", extra, int64(req.Satoshis),
+			fmt.Sprintf("Pay %s (%d sats)", req.Address.AddressString, req.Satoshis)); err != nil {
+			return "", err
+		}
+		var created *sdk.CreateActionResult
+		if created, err = w.CreateAction(ctx, createArgs, origin); err == nil {
+			ws.actionCreated(created, int64(req.Satoshis))
+			result = created
+		}
 
 	// ---------------------------------------------------------------
 	// Spend Authorization — signAction
@@ -324,7 +635,11 @@ func (ws *WalletService) CallWalletMethod(method string, argsJSON string, origin
 		if e := json.Unmarshal([]byte(argsJSON), &args); e != nil {
 			return "", fmt.Errorf("invalid args: %w", e)
 		}
-		result, err = w.SignAction(ctx, args, origin)
+		var signed *sdk.SignActionResult
+		if signed, err = w.SignAction(ctx, args, origin); err == nil {
+			ws.actionSigned(signed)
+			result = signed
+		}
 
 	case "abortAction":
 		var args SDKAbortActionArgs
@@ -338,7 +653,29 @@ func (ws *WalletService) CallWalletMethod(method string, argsJSON string, origin
 		if e := json.Unmarshal([]byte(argsJSON), &args); e != nil {
 			return "", fmt.Errorf("invalid args: %w", e)
 		}
-		result, err = w.ListActions(ctx, args, origin)
+		if ws.maxUnfailRetries > 0 && isUnfailRequest(args.Labels) {
+			result, err = ws.listFailedActionsCapped(ctx, w, store, args, origin)
+		} else {
+			result, err = w.ListActions(ctx, args, origin)
+		}
+
+	case "labelAction":
+		var args LabelActionArgs
+		if e := json.Unmarshal([]byte(argsJSON), &args); e != nil {
+			return "", fmt.Errorf("invalid args: %w", e)
+		}
+		if err = labelAction(ctx, w, store, args.TxID, args.Labels, origin); err == nil {
+			result = LabelActionResult{Labeled: true}
+		}
+
+	case "unlabelAction":
+		var args LabelActionArgs
+		if e := json.Unmarshal([]byte(argsJSON), &args); e != nil {
+			return "", fmt.Errorf("invalid args: %w", e)
+		}
+		if err = unlabelAction(ctx, w, store, args.TxID, args.Labels, origin); err == nil {
+			result = UnlabelActionResult{Unlabeled: true}
+		}
 
 	// ---------------------------------------------------------------
 	// Spend Authorization — internalizeAction
@@ -348,7 +685,24 @@ func (ws *WalletService) CallWalletMethod(method string, argsJSON string, origin
 		if e := json.Unmarshal([]byte(argsJSON), &args); e != nil {
 			return "", fmt.Errorf("invalid args: %w", e)
 		}
-		result, err = w.InternalizeAction(ctx, args, origin)
+		result, err = ws.internalizeAction(ctx, w, args, origin)
+
+	case "createReceipt":
+		var args CreateReceiptArgs
+		if e := json.Unmarshal([]byte(argsJSON), &args); e != nil {
+			return "", fmt.Errorf("invalid args: %w", e)
+		}
+		result, err = receiptFor(ctx, w, store, args.Txid, origin)
+
+	case "verifyReceipt":
+		var args Receipt
+		if e := json.Unmarshal([]byte(argsJSON), &args); e != nil {
+			return "", fmt.Errorf("invalid args: %w", e)
+		}
+		var valid bool
+		if valid, err = verifyReceipt(ctx, w, &args, origin); err == nil {
+			result = sdk.VerifySignatureResult{Valid: valid}
+		}
 
 	// ---------------------------------------------------------------
 	// Basket Access — listOutputs
@@ -367,6 +721,15 @@ func (ws *WalletService) CallWalletMethod(method string, argsJSON string, origin
 		}
 		result, err = w.RelinquishOutput(ctx, args, origin)
 
+	case "nextReceivingScript":
+		var args NextReceivingScriptArgs
+		if argsJSON != "" {
+			if e := json.Unmarshal([]byte(argsJSON), &args); e != nil {
+				return "", fmt.Errorf("invalid args: %w", e)
+			}
+		}
+		result, err = ws.nextReceivingScript(ctx, w, store, args.Basket, origin)
+
 	// ---------------------------------------------------------------
 	// Protocol Access — getPublicKey, encrypt, decrypt, hmac, signatures
 	// ---------------------------------------------------------------
@@ -375,49 +738,80 @@ func (ws *WalletService) CallWalletMethod(method string, argsJSON string, origin
 		if e := json.Unmarshal([]byte(argsJSON), &args); e != nil {
 			return "", fmt.Errorf("invalid args: %w", e)
 		}
-		result, err = w.GetPublicKey(ctx, args, origin)
+		result, err = keys.GetPublicKey(ctx, args, origin)
 
 	case "encrypt":
 		var args SDKEncryptArgs
 		if e := json.Unmarshal([]byte(argsJSON), &args); e != nil {
 			return "", fmt.Errorf("invalid args: %w", e)
 		}
-		result, err = w.Encrypt(ctx, args, origin)
+		result, err = keys.Encrypt(ctx, args, origin)
 
 	case "decrypt":
 		var args SDKDecryptArgs
 		if e := json.Unmarshal([]byte(argsJSON), &args); e != nil {
 			return "", fmt.Errorf("invalid args: %w", e)
 		}
-		result, err = w.Decrypt(ctx, args, origin)
+		result, err = keys.Decrypt(ctx, args, origin)
 
 	case "createHmac":
 		var args SDKCreateHMACArgs
 		if e := json.Unmarshal([]byte(argsJSON), &args); e != nil {
 			return "", fmt.Errorf("invalid args: %w", e)
 		}
-		result, err = w.CreateHMAC(ctx, args, origin)
+		result, err = keys.CreateHMAC(ctx, args, origin)
 
 	case "verifyHmac":
 		var args SDKVerifyHMACArgs
 		if e := json.Unmarshal([]byte(argsJSON), &args); e != nil {
 			return "", fmt.Errorf("invalid args: %w", e)
 		}
-		result, err = w.VerifyHMAC(ctx, args, origin)
+		result, err = keys.VerifyHMAC(ctx, args, origin)
 
 	case "createSignature":
 		var args SDKCreateSignatureArgs
 		if e := json.Unmarshal([]byte(argsJSON), &args); e != nil {
 			return "", fmt.Errorf("invalid args: %w", e)
 		}
-		result, err = w.CreateSignature(ctx, args, origin)
+		result, err = keys.CreateSignature(ctx, args, origin)
 
 	case "verifySignature":
 		var args SDKVerifySignatureArgs
 		if e := json.Unmarshal([]byte(argsJSON), &args); e != nil {
 			return "", fmt.Errorf("invalid args: %w", e)
 		}
-		result, err = w.VerifySignature(ctx, args, origin)
+		result, err = keys.VerifySignature(ctx, args, origin)
+
+	case "createSignedEnvelope":
+		var args SDKCreateSignatureArgs
+		if e := json.Unmarshal([]byte(argsJSON), &args); e != nil {
+			return "", fmt.Errorf("invalid args: %w", e)
+		}
+		result, err = createSignedEnvelope(ctx, keys, args, origin)
+
+	case "verifySignedEnvelope":
+		var args VerifySignedEnvelopeArgs
+		if e := json.Unmarshal([]byte(argsJSON), &args); e != nil {
+			return "", fmt.Errorf("invalid args: %w", e)
+		}
+		var valid bool
+		if valid, err = verifySignedEnvelope(ctx, w, args.Data, &args.Envelope, origin); err == nil {
+			result = sdk.VerifySignatureResult{Valid: valid}
+		}
+
+	case "verifyExternalSignature":
+		var args VerifyExternalSignatureArgs
+		if e := json.Unmarshal([]byte(argsJSON), &args); e != nil {
+			return "", fmt.Errorf("invalid args: %w", e)
+		}
+		signer, e := ec.PublicKeyFromString(args.SignerIdentityKey)
+		if e != nil {
+			return "", fmt.Errorf("invalid signer identity key: %w", e)
+		}
+		var valid bool
+		if valid, err = verifySignedMessage(ctx, w, args.Message, args.Signature, signer, origin); err == nil {
+			result = sdk.VerifySignatureResult{Valid: valid}
+		}
 
 	// ---------------------------------------------------------------
 	// Counterparty — revealCounterpartyKeyLinkage, revealSpecificKeyLinkage
@@ -512,14 +906,14 @@ func (ws *WalletService) CallWalletMethod(method string, argsJSON string, origin
 		if e := json.Unmarshal([]byte(argsJSON), &args); e != nil {
 			return "", fmt.Errorf("invalid args: %w", e)
 		}
-		result, err = w.DiscoverByIdentityKey(ctx, args, origin)
+		result, err = ws.discoverWallet(w).DiscoverByIdentityKey(ctx, args, origin)
 
 	case "discoverByAttributes":
 		var args SDKDiscoverByAttributesArgs
 		if e := json.Unmarshal([]byte(argsJSON), &args); e != nil {
 			return "", fmt.Errorf("invalid args: %w", e)
 		}
-		result, err = w.DiscoverByAttributes(ctx, args, origin)
+		result, err = ws.discoverWallet(w).DiscoverByAttributes(ctx, args, origin)
 
 	case "isAuthenticated":
 		result, err = w.IsAuthenticated(ctx, nil, origin)
@@ -527,6 +921,31 @@ func (ws *WalletService) CallWalletMethod(method string, argsJSON string, origin
 	case "waitForAuthentication":
 		result, err = w.WaitForAuthentication(ctx, nil, origin)
 
+	case "resolvePaymail":
+		var args ResolvePaymailArgs
+		if e := json.Unmarshal([]byte(argsJSON), &args); e != nil {
+			return "", fmt.Errorf("invalid args: %w", e)
+		}
+		var lockingScript *script.Script
+		if lockingScript, err = ws.ResolvePaymail(ctx, args.Paymail, args.Amount, origin); err == nil {
+			result = ResolvePaymailResult{LockingScript: lockingScript.String()}
+		}
+
+	case "sendToPaymail":
+		var args SendToPaymailArgs
+		if e := json.Unmarshal([]byte(argsJSON), &args); e != nil {
+			return "", fmt.Errorf("invalid args: %w", e)
+		}
+		extra := map[string]interface{}{"paymail": args.Paymail}
+		if args.Note != "" {
+			extra["note"] = args.Note
+		}
+		if err := checkPermission(gate, method, origin, "spend", extra, int64(args.Amount),
+			fmt.Sprintf("Pay %s (%d sats)", args.Paymail, args.Amount)); err != nil {
+			return "", err
+		}
+		result, err = ws.sendToPaymail(ctx, w, args.Paymail, args.Amount, args.Note, origin)
+
 	case "getHeight":
 		result, err = w.GetHeight(ctx, nil, origin)
 
@@ -543,12 +962,27 @@ func (ws *WalletService) CallWalletMethod(method string, argsJSON string, origin
 	case "getVersion":
 		result, err = w.GetVersion(ctx, nil, origin)
 
+	case "capabilities":
+		result, err = ws.Capabilities(ctx, origin)
+
+	case "getBalance":
+		var args BalanceArgs
+		if argsJSON != "" {
+			if e := json.Unmarshal([]byte(argsJSON), &args); e != nil {
+				return "", fmt.Errorf("invalid args: %w", e)
+			}
+		}
+		result, err = walletBalance(ctx, w, args, origin)
+
+	case "stats":
+		result, err = walletStats(ctx, w, origin)
+
 	default:
 		return "", fmt.Errorf("unknown wallet method: %s", method)
 	}
 
 	if err != nil {
-		return "", err
+		return "", ws.operationError(ctx, err)
 	}
 
 	resultJSON, err := json.Marshal(result)
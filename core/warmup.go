@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sdk "github.com/bsv-blockchain/go-sdk/wallet"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/wallet"
+)
+
+const (
+	// defaultWarmUpRetry is how long a failed warm-up check waits before
+	// it is run again.
+	defaultWarmUpRetry = 2 * time.Second
+	// warmUpOriginator is the originator warm-up checks call the wallet as.
+	warmUpOriginator = "warmup.gebunden.local"
+)
+
+var errWarmingUp = errors.New("wallet warming up")
+
+// warmUpCheck is one dependency WarmUp waits for.
+type warmUpCheck struct {
+	name string
+	run  func(ctx context.Context, w *wallet.Wallet) error
+}
+
+// warmUpChecks are run in order: the chain services answer a height, the
+// overlay answers a discovery, and storage answers a listing.
+var warmUpChecks = []warmUpCheck{
+	{"height", func(ctx context.Context, w *wallet.Wallet) error {
+		_, err := w.GetHeight(ctx, nil, warmUpOriginator)
+		return err
+	}},
+	{"discovery", func(ctx context.Context, w *wallet.Wallet) error {
+		identity, err := w.GetPublicKey(ctx, sdk.GetPublicKeyArgs{IdentityKey: true}, warmUpOriginator)
+		if err != nil {
+			return err
+		}
+		_, err = w.DiscoverByIdentityKey(ctx, sdk.DiscoverByIdentityKeyArgs{IdentityKey: identity.PublicKey}, warmUpOriginator)
+		return err
+	}},
+	{"storage", func(ctx context.Context, w *wallet.Wallet) error {
+		limit := uint32(1)
+		_, err := w.ListOutputs(ctx, sdk.ListOutputsArgs{Basket: defaultBasket, Limit: &limit}, warmUpOriginator)
+		return err
+	}},
+}
+
+// WithWarmUp holds readiness back until WarmUp has passed: until then
+// Ready fails and the HTTP server turns wallet calls away. WarmUp gives up
+// after timeout; 0 means it never does.
+func WithWarmUp(timeout time.Duration) WalletServiceOption {
+	return func(ws *WalletService) {
+		ws.warmUpTimeout = timeout
+		ws.warmingUp.Store(true)
+	}
+}
+
+// WarmUp runs the warm-up checks against the initialized wallet, each
+// retried until it passes, and marks the wallet ready once all have. It
+// does nothing unless the service was built WithWarmUp.
+func (ws *WalletService) WarmUp(ctx context.Context) error {
+	if !ws.warmingUp.Load() {
+		return nil
+	}
+	ws.mu.RLock()
+	w := ws.wallet
+	ws.mu.RUnlock()
+
+	if w == nil {
+		return errWalletNotInitialized
+	}
+	if ws.warmUpTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ws.warmUpTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	for _, check := range ws.warmUpChecks {
+		for attempt := 1; ; attempt++ {
+			err := check.run(ctx, w)
+			if err == nil {
+				break
+			}
+			ws.logger.Warn("Warm-up check failed, retrying", "check", check.name, "attempt", attempt, "error", err)
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("warm-up %s check did not pass: %w", check.name, err)
+			case <-time.After(ws.warmUpRetry):
+			}
+		}
+	}
+	ws.warmingUp.Store(false)
+	ws.logger.Info("Warm-up passed", "took", time.Since(start).Round(time.Millisecond))
+	return nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/wallet"
+)
+
+func TestWarmUpDelaysReadiness(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ctx := context.Background()
+
+	// An overlay that takes three tries to answer.
+	var tries atomic.Int32
+	ws := NewWalletService(WithWarmUp(5 * time.Second))
+	ws.warmUpRetry = 10 * time.Millisecond
+	ws.warmUpChecks = []warmUpCheck{{"discovery", func(context.Context, *wallet.Wallet) error {
+		if tries.Add(1) < 3 {
+			return errors.New("overlay not reachable")
+		}
+		return nil
+	}}}
+	if err := ws.InitializeWallet(newKey(t).Hex(), "test"); err != nil {
+		t.Fatalf("InitializeWallet: %v", err)
+	}
+	defer ws.ShutdownWallet()
+
+	if err := ws.Ready(ctx); !errors.Is(err, errWarmingUp) {
+		t.Fatalf("Ready before warm-up = %v, want %v", err, errWarmingUp)
+	}
+	s := NewHTTPServer(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	s.SetWalletService(ws)
+	srv := httptest.NewServer(http.HandlerFunc(s.handleRequest))
+	defer srv.Close()
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/getVersion", nil)
+	req.Header.Set("Origin", "http://example.com")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || !strings.Contains(string(body), "warming up") {
+		t.Fatalf("call before warm-up: %d %s, want 503 warming up", resp.StatusCode, body)
+	}
+
+	if err := ws.WarmUp(ctx); err != nil {
+		t.Fatalf("WarmUp: %v", err)
+	}
+	if n := tries.Load(); n != 3 {
+		t.Fatalf("check ran %d times, want it retried until it passed on the third", n)
+	}
+	if err := ws.Ready(ctx); err != nil {
+		t.Fatalf("Ready after warm-up: %v", err)
+	}
+}
+
+func TestWarmUpTimesOut(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ws := NewWalletService(WithWarmUp(50 * time.Millisecond))
+	ws.warmUpRetry = 10 * time.Millisecond
+	ws.warmUpChecks = []warmUpCheck{{"height", func(context.Context, *wallet.Wallet) error {
+		return errors.New("no chain services")
+	}}}
+	if err := ws.InitializeWallet(newKey(t).Hex(), "test"); err != nil {
+		t.Fatalf("InitializeWallet: %v", err)
+	}
+	defer ws.ShutdownWallet()
+
+	err := ws.WarmUp(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "warm-up height check did not pass: no chain services") {
+		t.Fatalf("WarmUp = %v, want the height check to time out", err)
+	}
+	if err := ws.Ready(context.Background()); !errors.Is(err, errWarmingUp) {
+		t.Fatalf("Ready after a failed warm-up = %v, want %v", err, errWarmingUp)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/wdk"
+	"golang.org/x/term"
+)
+
+// runWipeIdentity implements `gebunden wipe-identity`: overwrite and remove
+// a wallet identity file and, with -storage, the identity's wallet
+// databases. It refuses to run without -yes and, on a terminal, asks once
+// more before destroying anything.
+func runWipeIdentity(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("wipe-identity", flag.ContinueOnError)
+	keyFile := fs.String("key-file", "", "Identity file to wipe (default ~/.gebunden/wallet-identity.json)")
+	wipeStorage := fs.Bool("storage", false, "Also wipe the identity's wallet databases in ~/.gebunden")
+	yes := fs.Bool("yes", false, "Confirm that the key is to be destroyed; it cannot be recovered afterwards")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get home directory: %w", err)
+	}
+	path := *keyFile
+	if path == "" {
+		path = filepath.Join(homeDir, ".gebunden", "wallet-identity.json")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read key file %s: %w", path, err)
+	}
+	var identity walletIdentity
+	if err := json.Unmarshal(data, &identity); err != nil {
+		return fmt.Errorf("%s is not a wallet identity file: %w", path, err)
+	}
+	identityKey := identity.IdentityKey
+	if identityKey == "" && identity.RootKeyHex != "" {
+		identityKey, _ = wdk.IdentityKey(identity.RootKeyHex)
+	}
+
+	files := []string{path}
+	if *wipeStorage {
+		if identityKey == "" {
+			return fmt.Errorf("%s does not record its identity key, so its storage cannot be found", path)
+		}
+		// The databases of every network, with their journal files.
+		dbs, err := filepath.Glob(filepath.Join(homeDir, ".gebunden", "wallet-"+identityKey+"-*.sqlite*"))
+		if err != nil {
+			return err
+		}
+		files = append(files, dbs...)
+	}
+
+	if !*yes {
+		return fmt.Errorf("refusing to wipe without -yes; it would destroy:\n  %s", strings.Join(files, "\n  "))
+	}
+	if f, ok := stdin.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		confirmed, err := confirmWipe(stdin, os.Stderr, identityKey, files)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return fmt.Errorf("not confirmed; nothing was wiped")
+		}
+	}
+
+	for _, f := range files {
+		if err := wipeFile(f); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Wiped %s\n", f)
+	}
+	return nil
+}
+
+// confirmWipe lists what is about to be destroyed and reports whether the
+// user answers yes.
+func confirmWipe(in io.Reader, out io.Writer, identityKey string, files []string) (bool, error) {
+	if identityKey != "" {
+		fmt.Fprintf(out, "This destroys the wallet key for identity %s.\n", identityKey)
+	}
+	fmt.Fprintf(out, "Files to wipe:\n  %s\nFunds the key controls are lost unless it is backed up elsewhere.\nType yes to continue: ", strings.Join(files, "\n  "))
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	return strings.TrimSpace(answer) == "yes", nil
+}
+
+// wipeFile overwrites path with random bytes, flushes them to disk and
+// removes it. Copy-on-write filesystems and SSD wear levelling can keep the
+// old blocks regardless, so this stops casual recovery of the key, not a
+// forensic one.
+func wipeFile(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	info, err := f.Stat()
+	if err == nil {
+		_, err = io.CopyN(f, rand.Reader, info.Size())
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to overwrite %s: %w", path, err)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWipeIdentity(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dataDir := filepath.Join(home, ".gebunden")
+	path := filepath.Join(dataDir, "wallet-identity.json")
+
+	var stdout bytes.Buffer
+	if err := runGenkey(nil, &stdout); err != nil {
+		t.Fatalf("genkey: %v", err)
+	}
+	identityKey := strings.TrimSpace(stdout.String())
+	db := filepath.Join(dataDir, "wallet-"+identityKey+"-main.sqlite")
+	other := filepath.Join(dataDir, "wallet-"+strings.Repeat("0", 66)+"-main.sqlite")
+	for _, f := range []string{db, db + "-wal", other} {
+		os.WriteFile(f, []byte("wallet data"), 0o600)
+	}
+	original, _ := os.ReadFile(path)
+
+	// A second link to the key file sees what is written to it before the
+	// unlink, showing the key was overwritten rather than only unlinked.
+	link := filepath.Join(t.TempDir(), "link")
+	linked := os.Link(path, link) == nil
+
+	if err := runWipeIdentity([]string{"-storage"}, strings.NewReader(""), &stdout); err == nil || !strings.Contains(err.Error(), "-yes") {
+		t.Fatalf("wipe without -yes: err = %v, want a refusal", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("key file gone after a refused wipe: %v", err)
+	}
+
+	if err := runWipeIdentity([]string{"-storage", "-yes"}, strings.NewReader(""), &stdout); err != nil {
+		t.Fatalf("wipe-identity: %v", err)
+	}
+	for _, f := range []string{path, db, db + "-wal"} {
+		if _, err := os.Stat(f); !os.IsNotExist(err) {
+			t.Errorf("%s still exists (err %v)", f, err)
+		}
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("another identity's storage was removed: %v", err)
+	}
+	if linked {
+		wiped, err := os.ReadFile(link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(wiped) != len(original) || bytes.Equal(wiped, original) {
+			t.Fatalf("key file contents were not overwritten before removal")
+		}
+		var identity walletIdentity
+		json.Unmarshal(original, &identity)
+		if identity.RootKeyHex == "" || bytes.Contains(wiped, []byte(identity.RootKeyHex)) {
+			t.Fatal("root key still readable after the wipe")
+		}
+	}
+}
+
+func TestConfirmWipe(t *testing.T) {
+	var prompt bytes.Buffer
+	for answer, want := range map[string]bool{"yes\n": true, "y\n": false, "\n": false, "": false} {
+		got, err := confirmWipe(strings.NewReader(answer), &prompt, "02ab", []string{"wallet-identity.json"})
+		if err != nil || got != want {
+			t.Errorf("answer %q: confirmed = %v, %v; want %v", answer, got, err, want)
+		}
+	}
+	if !strings.Contains(prompt.String(), "wallet-identity.json") {
+		t.Errorf("prompt %q does not list the files", prompt.String())
+	}
+}